@@ -258,6 +258,27 @@ func BenchmarkContainsOne100Unsafe(b *testing.B) {
 	benchContainsOne(b, 100, NewThreadUnsafeSet[int]())
 }
 
+func benchContainsOneFrozen(b *testing.B, n int) {
+	f := Freeze(NewThreadUnsafeSet(nrand(n)...))
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		f.ContainsOne(-1)
+	}
+}
+
+func BenchmarkContainsOne1Frozen(b *testing.B) {
+	benchContainsOneFrozen(b, 1)
+}
+
+func BenchmarkContainsOne10Frozen(b *testing.B) {
+	benchContainsOneFrozen(b, 10)
+}
+
+func BenchmarkContainsOne100Frozen(b *testing.B) {
+	benchContainsOneFrozen(b, 100)
+}
+
 // In this scenario, Contains argument escapes to the heap, while ContainsOne does not.
 func benchContainsComparison(b *testing.B, n int, s Set[int]) {
 	nums := nrand(n)
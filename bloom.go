@@ -0,0 +1,275 @@
+/*
+Open Source Initiative OSI - The MIT License (MIT):Licensing
+
+The MIT License (MIT)
+Copyright (c) 2013 - 2026 Ralph Caraveo (deckarep@gmail.com)
+
+Permission is hereby granted, free of charge, to any person obtaining a copy of
+this software and associated documentation files (the "Software"), to deal in
+the Software without restriction, including without limitation the rights to
+use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies
+of the Software, and to permit persons to whom the Software is furnished to do
+so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+
+package mapset
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+	"math/bits"
+)
+
+// ErrIncompatibleFilters is returned when combining filters whose
+// parameters differ.
+var ErrIncompatibleFilters = errors.New("mapset: incompatible filters")
+
+// BloomFilter is an approximate set. MayContain never reports a false
+// negative but reports false positives at a rate determined by the size
+// of the filter and the number of elements added. Elements cannot be
+// removed.
+//
+// A BloomFilter is not safe for concurrent use.
+type BloomFilter[T comparable] struct {
+	bits   []uint64
+	m      uint64
+	k      uint32
+	hasher Hasher[T]
+}
+
+// NewBloomFilter creates and returns a Bloom filter sized to hold n
+// elements with a false-positive rate of about fpRate. If hasher is nil,
+// DefaultHasher is used.
+func NewBloomFilter[T comparable](n uint64, fpRate float64, hasher Hasher[T]) *BloomFilter[T] {
+	m, k := bloomParameters(n, fpRate)
+	return NewBloomFilterWithSize(m, k, hasher)
+}
+
+// NewBloomFilterWithSize creates and returns a Bloom filter of m bits that
+// sets k bits per element, up to 64. If hasher is nil, DefaultHasher is
+// used.
+func NewBloomFilterWithSize[T comparable](m uint64, k uint32, hasher Hasher[T]) *BloomFilter[T] {
+	if m == 0 {
+		m = 1
+	}
+	if k == 0 {
+		k = 1
+	} else if k > bloomMaxHashes {
+		k = bloomMaxHashes
+	}
+	return &BloomFilter[T]{
+		bits:   make([]uint64, (m+63)/64),
+		m:      m,
+		k:      k,
+		hasher: hasherOrDefault(hasher),
+	}
+}
+
+// ToBloom creates and returns a Bloom filter sized for the elements of s
+// with a false-positive rate of about fpRate, and adds them to it. If
+// hasher is nil, DefaultHasher is used.
+func ToBloom[T comparable](s Set[T], fpRate float64, hasher Hasher[T]) *BloomFilter[T] {
+	vs := s.ToSlice()
+	f := NewBloomFilter(uint64(len(vs)), fpRate, hasher)
+	for _, v := range vs {
+		f.Add(v)
+	}
+	return f
+}
+
+// bloomParameters returns the optimal number of bits and hash functions
+// for n elements at the given false-positive rate.
+func bloomParameters(n uint64, fpRate float64) (m uint64, k uint32) {
+	if n == 0 {
+		n = 1
+	}
+	if fpRate <= 0 || fpRate >= 1 {
+		fpRate = 0.01
+	}
+	m = uint64(math.Ceil(-float64(n) * math.Log(fpRate) / (math.Ln2 * math.Ln2)))
+	k = uint32(math.Round(float64(m) / float64(n) * math.Ln2))
+	return m, k
+}
+
+// locations calls fn with each of the k bit positions for hash h, derived
+// by double hashing.
+func (f *BloomFilter[T]) locations(h uint64, fn func(i uint64) bool) bool {
+	h2 := mix64(h) | 1
+	for i := uint32(0); i < f.k; i++ {
+		if !fn((h + uint64(i)*h2) % f.m) {
+			return false
+		}
+	}
+	return true
+}
+
+// Add adds an element to the filter.
+func (f *BloomFilter[T]) Add(val T) {
+	f.locations(f.hasher(val), func(i uint64) bool {
+		f.bits[i/64] |= 1 << (i % 64)
+		return true
+	})
+}
+
+// Append adds multiple elements to the filter.
+func (f *BloomFilter[T]) Append(val ...T) {
+	for _, v := range val {
+		f.Add(v)
+	}
+}
+
+// MayContain returns whether the element may have been added. A result
+// of false is definitive.
+func (f *BloomFilter[T]) MayContain(val T) bool {
+	return f.locations(f.hasher(val), func(i uint64) bool {
+		return f.bits[i/64]&(1<<(i%64)) != 0
+	})
+}
+
+// Clear removes all elements from the filter.
+func (f *BloomFilter[T]) Clear() {
+	for i := range f.bits {
+		f.bits[i] = 0
+	}
+}
+
+// BitSize returns the number of bits in the filter.
+func (f *BloomFilter[T]) BitSize() uint64 {
+	return f.m
+}
+
+// HashCount returns the number of bits set per element.
+func (f *BloomFilter[T]) HashCount() uint32 {
+	return f.k
+}
+
+// onesCount returns the number of bits set in the filter.
+func (f *BloomFilter[T]) onesCount() uint64 {
+	var n uint64
+	for _, w := range f.bits {
+		n += uint64(bits.OnesCount64(w))
+	}
+	return n
+}
+
+// EstimatedCardinality returns an estimate of the number of distinct
+// elements added to the filter, derived from the fraction of bits set.
+func (f *BloomFilter[T]) EstimatedCardinality() uint64 {
+	x := f.onesCount()
+	if x >= f.m {
+		return math.MaxUint64
+	}
+	m, k := float64(f.m), float64(f.k)
+	return uint64(math.Round(-m / k * math.Log(1-float64(x)/m)))
+}
+
+// FalsePositiveRate returns the expected false-positive rate of
+// MayContain given the bits currently set.
+func (f *BloomFilter[T]) FalsePositiveRate() float64 {
+	return math.Pow(float64(f.onesCount())/float64(f.m), float64(f.k))
+}
+
+func (f *BloomFilter[T]) compatible(other *BloomFilter[T]) error {
+	if f.m != other.m || f.k != other.k {
+		return fmt.Errorf("%w: %d bits/%d hashes and %d bits/%d hashes",
+			ErrIncompatibleFilters, f.m, f.k, other.m, other.k)
+	}
+	return nil
+}
+
+// Union returns a new filter that may contain every element that may be
+// contained by either filter. Both filters must have the same size and
+// hash count and must use the same Hasher.
+func (f *BloomFilter[T]) Union(other *BloomFilter[T]) (*BloomFilter[T], error) {
+	if err := f.compatible(other); err != nil {
+		return nil, err
+	}
+	u := NewBloomFilterWithSize(f.m, f.k, f.hasher)
+	for i := range u.bits {
+		u.bits[i] = f.bits[i] | other.bits[i]
+	}
+	return u, nil
+}
+
+// Intersect returns a new filter that may contain every element that may
+// be contained by both filters. Its false-positive rate is at least that
+// of a filter built from the true intersection. Both filters must have
+// the same size and hash count and must use the same Hasher.
+func (f *BloomFilter[T]) Intersect(other *BloomFilter[T]) (*BloomFilter[T], error) {
+	if err := f.compatible(other); err != nil {
+		return nil, err
+	}
+	u := NewBloomFilterWithSize(f.m, f.k, f.hasher)
+	for i := range u.bits {
+		u.bits[i] = f.bits[i] & other.bits[i]
+	}
+	return u, nil
+}
+
+const (
+	bloomMagic   = "MSBF"
+	bloomVersion = 1
+
+	// bloomMaxHashes bounds the bits set per element, which no
+	// useful false-positive rate comes near, so that a decoded filter
+	// cannot make every operation arbitrarily slow.
+	bloomMaxHashes = 64
+)
+
+// MarshalBinary encodes the filter. The Hasher is not encoded; the filter
+// must be decoded with the same Hasher it was built with.
+func (f *BloomFilter[T]) MarshalBinary() ([]byte, error) {
+	b := make([]byte, 0, 17+8*len(f.bits))
+	b = append(b, bloomMagic...)
+	b = append(b, bloomVersion)
+	b = appendUint32(b, f.k)
+	b = appendUint64(b, f.m)
+	for _, w := range f.bits {
+		b = appendUint64(b, w)
+	}
+	return b, nil
+}
+
+// UnmarshalBinary decodes a filter encoded by MarshalBinary, replacing the
+// contents of f. The receiver's Hasher is kept, or DefaultHasher is used
+// if it has none.
+func (f *BloomFilter[T]) UnmarshalBinary(b []byte) error {
+	if len(b) < 17 || string(b[:4]) != bloomMagic {
+		return errors.New("mapset: invalid Bloom filter encoding")
+	}
+	if b[4] != bloomVersion {
+		return fmt.Errorf("mapset: unsupported Bloom filter version %d", b[4])
+	}
+	k := binary.LittleEndian.Uint32(b[5:])
+	m := binary.LittleEndian.Uint64(b[9:])
+	b = b[17:]
+	// Count words without rounding m up, which would overflow.
+	n := m / 64
+	if m%64 != 0 {
+		n++
+	}
+	if k == 0 || k > bloomMaxHashes || m == 0 || len(b)%8 != 0 || uint64(len(b))/8 != n {
+		return errors.New("mapset: invalid Bloom filter encoding")
+	}
+
+	words := make([]uint64, len(b)/8)
+	for i := range words {
+		words[i] = binary.LittleEndian.Uint64(b[8*i:])
+	}
+	f.bits, f.m, f.k = words, m, k
+	f.hasher = hasherOrDefault(f.hasher)
+	return nil
+}
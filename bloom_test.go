@@ -0,0 +1,171 @@
+/*
+Open Source Initiative OSI - The MIT License (MIT):Licensing
+
+The MIT License (MIT)
+Copyright (c) 2013 - 2026 Ralph Caraveo (deckarep@gmail.com)
+
+Permission is hereby granted, free of charge, to any person obtaining a copy of
+this software and associated documentation files (the "Software"), to deal in
+the Software without restriction, including without limitation the rights to
+use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies
+of the Software, and to permit persons to whom the Software is furnished to do
+so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+
+package mapset
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+	"testing"
+)
+
+func Test_BloomFilterNoFalseNegatives(t *testing.T) {
+	f := NewBloomFilter[int](1000, 0.01, nil)
+	for i := 0; i < 1000; i++ {
+		f.Add(i)
+	}
+	for i := 0; i < 1000; i++ {
+		if !f.MayContain(i) {
+			t.Fatalf("False negative for %d", i)
+		}
+	}
+}
+
+func Test_BloomFilterFalsePositiveRate(t *testing.T) {
+	f := NewBloomFilter[string](10000, 0.01, nil)
+	for i := 0; i < 10000; i++ {
+		f.Add(fmt.Sprintf("member-%d", i))
+	}
+
+	var fp int
+	for i := 0; i < 10000; i++ {
+		if f.MayContain(fmt.Sprintf("other-%d", i)) {
+			fp++
+		}
+	}
+	if rate := float64(fp) / 10000; rate > 0.02 {
+		t.Errorf("False-positive rate %.4f is well above the configured 0.01", rate)
+	}
+	if rate := f.FalsePositiveRate(); rate > 0.02 {
+		t.Errorf("Expected false-positive rate %.4f is well above the configured 0.01", rate)
+	}
+}
+
+func Test_BloomFilterEstimatedCardinality(t *testing.T) {
+	f := NewBloomFilter[int](5000, 0.01, nil)
+	for i := 0; i < 5000; i++ {
+		f.Add(i)
+		f.Add(i)
+	}
+	if n := f.EstimatedCardinality(); n < 4750 || n > 5250 {
+		t.Errorf("Expected an estimate near 5000, got %d", n)
+	}
+}
+
+func Test_BloomFilterUnionIntersect(t *testing.T) {
+	a := NewBloomFilterWithSize[int](4096, 4, nil)
+	b := NewBloomFilterWithSize[int](4096, 4, nil)
+	a.Append(1, 2, 3)
+	b.Append(3, 4, 5)
+
+	u, err := a.Union(b)
+	if err != nil {
+		t.Fatalf("Error should be nil: %v", err)
+	}
+	for i := 1; i <= 5; i++ {
+		if !u.MayContain(i) {
+			t.Errorf("Union should contain %d", i)
+		}
+	}
+
+	in, err := a.Intersect(b)
+	if err != nil {
+		t.Fatalf("Error should be nil: %v", err)
+	}
+	if !in.MayContain(3) {
+		t.Error("Intersect should contain 3")
+	}
+
+	c := NewBloomFilterWithSize[int](2048, 4, nil)
+	if _, err := a.Union(c); !errors.Is(err, ErrIncompatibleFilters) {
+		t.Errorf("Expected ErrIncompatibleFilters, got %v", err)
+	}
+}
+
+func Test_BloomFilterMarshalBinary(t *testing.T) {
+	f := ToBloom(NewSet("a", "b", "c"), 0.001, nil)
+
+	b, err := f.MarshalBinary()
+	if err != nil {
+		t.Fatalf("Error should be nil: %v", err)
+	}
+
+	var g BloomFilter[string]
+	if err := g.UnmarshalBinary(b); err != nil {
+		t.Fatalf("Error should be nil: %v", err)
+	}
+	if g.BitSize() != f.BitSize() || g.HashCount() != f.HashCount() {
+		t.Errorf("Parameters differ after decoding: %d/%d vs %d/%d",
+			g.BitSize(), g.HashCount(), f.BitSize(), f.HashCount())
+	}
+	for _, v := range []string{"a", "b", "c"} {
+		if !g.MayContain(v) {
+			t.Errorf("Decoded filter should contain %s", v)
+		}
+	}
+
+	hugeM := append([]byte(nil), b[:17]...)
+	binary.LittleEndian.PutUint64(hugeM[9:], math.MaxUint64)
+	hugeK := append([]byte(nil), b...)
+	binary.LittleEndian.PutUint32(hugeK[5:], math.MaxUint32)
+	for name, bad := range map[string][]byte{
+		"truncated": b[:len(b)-1],
+		"magic":     append([]byte("XXXX"), b[4:]...),
+		"header":    b[:16],
+		"huge m":    hugeM,
+		"huge k":    hugeK,
+	} {
+		if err := g.UnmarshalBinary(bad); err == nil {
+			t.Errorf("Decoding a filter with a bad %s should fail", name)
+		}
+	}
+
+	// Filters are created with no more hashes than can be decoded.
+	h := NewBloomFilterWithSize[string](1024, math.MaxUint32, nil)
+	if h.HashCount() != bloomMaxHashes {
+		t.Errorf("Expected %d hashes, got %d", bloomMaxHashes, h.HashCount())
+	}
+	if b, err = h.MarshalBinary(); err != nil {
+		t.Fatal(err)
+	}
+	if err := g.UnmarshalBinary(b); err != nil {
+		t.Errorf("Decoding a filter with the most hashes should succeed: %v", err)
+	}
+}
+
+func Test_BloomFilterHasher(t *testing.T) {
+	var calls int
+	f := NewBloomFilter[int](10, 0.01, func(v int) uint64 {
+		calls++
+		return uint64(v)
+	})
+	f.Add(1)
+	f.MayContain(1)
+	if calls != 2 {
+		t.Errorf("Expected the custom hasher to be called twice, got %d", calls)
+	}
+}
@@ -0,0 +1,659 @@
+/*
+Open Source Initiative OSI - The MIT License (MIT):Licensing
+
+The MIT License (MIT)
+Copyright (c) 2013 - 2026 Ralph Caraveo (deckarep@gmail.com)
+
+Permission is hereby granted, free of charge, to any person obtaining a copy of
+this software and associated documentation files (the "Software"), to deal in
+the Software without restriction, including without limitation the rights to
+use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies
+of the Software, and to permit persons to whom the Software is furnished to do
+so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+
+package mapset
+
+import (
+	"container/heap"
+	"container/list"
+	"encoding/json"
+	"fmt"
+	"math/rand"
+	"strings"
+	"sync"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/bsontype"
+)
+
+// EvictionPolicy selects which element a BoundedSet evicts when an
+// element is added to a set that is already at capacity.
+type EvictionPolicy int
+
+const (
+	// EvictLRU evicts the least recently used element. Adding an
+	// element that is already present counts as a use.
+	EvictLRU EvictionPolicy = iota
+
+	// EvictLFU evicts the least frequently used element, breaking
+	// ties by evicting the least recently used one.
+	EvictLFU
+
+	// EvictFIFO evicts the element that was added first. Uses of
+	// an element do not affect eviction order.
+	EvictFIFO
+
+	// EvictRandom evicts an element chosen at random.
+	EvictRandom
+)
+
+func (p EvictionPolicy) String() string {
+	switch p {
+	case EvictLRU:
+		return "LRU"
+	case EvictLFU:
+		return "LFU"
+	case EvictFIFO:
+		return "FIFO"
+	case EvictRandom:
+		return "Random"
+	}
+	return fmt.Sprintf("EvictionPolicy(%d)", int(p))
+}
+
+// BoundedSet is a Set that holds at most a fixed number of elements.
+// Adding an element to a full set evicts another element according to
+// the set's EvictionPolicy.
+//
+// Methods that return a new set (Union, Intersect, Difference,
+// SymmetricDifference and Filter) return an ordinary thread-safe Set,
+// since their results are not bounded. Unlike the other Set
+// implementations, the argument to these methods may be any Set.
+//
+// Operations on a BoundedSet are thread-safe.
+type BoundedSet[T comparable] interface {
+	Set[T]
+
+	// AddEvict adds an element to the set and returns the element
+	// that was evicted to make room for it, if any.
+	AddEvict(val T) (evicted T, ok bool)
+
+	// Capacity returns the maximum number of elements the set holds.
+	Capacity() int
+
+	// Policy returns the eviction policy of the set.
+	Policy() EvictionPolicy
+
+	// Stats returns the eviction metrics of the set.
+	Stats() BoundedSetStats
+}
+
+// BoundedSetOptions configures a BoundedSet. The zero value is valid
+// and selects EvictLRU.
+type BoundedSetOptions[T comparable] struct {
+	// Policy is the eviction policy.
+	Policy EvictionPolicy
+
+	// TouchOnContains makes ContainsOne, Contains and ContainsAny count
+	// as a use of the elements they find, as Add always does.
+	TouchOnContains bool
+
+	// OnEvict, if set, is called for every evicted element. It is called
+	// while the set's lock is held and so must not use the set.
+	OnEvict func(val T)
+}
+
+// BoundedSetStats holds the eviction metrics of a BoundedSet.
+type BoundedSetStats struct {
+	// Evictions is the number of elements evicted to make room
+	// for new ones.
+	Evictions uint64
+
+	// Hits and Misses count the lookups made by ContainsOne,
+	// Contains and ContainsAny that found and did not find
+	// an element respectively.
+	Hits   uint64
+	Misses uint64
+}
+
+// NewBoundedSet creates and returns a new, empty set that holds at most
+// capacity elements. NewBoundedSet panics if capacity is not positive.
+func NewBoundedSet[T comparable](capacity int, opts BoundedSetOptions[T]) BoundedSet[T] {
+	if capacity <= 0 {
+		panic(fmt.Sprintf("mapset: bounded set capacity must be positive, got %d", capacity))
+	}
+	return &boundedSet[T]{
+		capacity: capacity,
+		opts:     opts,
+		uss:      newThreadUnsafeSetWithSize[T](capacity),
+		evictor:  newEvictor[T](opts.Policy),
+	}
+}
+
+// evictor tracks the elements of a bounded set and chooses eviction victims.
+type evictor[T comparable] interface {
+	// add starts tracking a new element.
+	add(v T)
+	// touch records a use of a tracked element.
+	touch(v T)
+	// remove stops tracking an element.
+	remove(v T)
+	// victim stops tracking and returns the next element to evict.
+	victim() T
+	// clone returns an independent copy of the evictor.
+	clone() evictor[T]
+}
+
+func newEvictor[T comparable](p EvictionPolicy) evictor[T] {
+	switch p {
+	case EvictLRU:
+		return newListEvictor[T](true)
+	case EvictLFU:
+		return &lfuEvictor[T]{index: make(map[T]*lfuEntry[T])}
+	case EvictFIFO:
+		return newListEvictor[T](false)
+	case EvictRandom:
+		return &randomEvictor[T]{index: make(map[T]int)}
+	}
+	panic(fmt.Sprintf("mapset: unknown eviction policy %v", p))
+}
+
+// listEvictor implements EvictLRU and EvictFIFO with a queue ordered from
+// the next victim to the most recently added or used element.
+type listEvictor[T comparable] struct {
+	lru   bool
+	order *list.List
+	index map[T]*list.Element
+}
+
+func newListEvictor[T comparable](lru bool) *listEvictor[T] {
+	return &listEvictor[T]{
+		lru:   lru,
+		order: list.New(),
+		index: make(map[T]*list.Element),
+	}
+}
+
+func (e *listEvictor[T]) add(v T) {
+	e.index[v] = e.order.PushBack(v)
+}
+
+func (e *listEvictor[T]) touch(v T) {
+	if e.lru {
+		e.order.MoveToBack(e.index[v])
+	}
+}
+
+func (e *listEvictor[T]) remove(v T) {
+	e.order.Remove(e.index[v])
+	delete(e.index, v)
+}
+
+func (e *listEvictor[T]) victim() T {
+	v := e.order.Remove(e.order.Front()).(T)
+	delete(e.index, v)
+	return v
+}
+
+func (e *listEvictor[T]) clone() evictor[T] {
+	c := newListEvictor[T](e.lru)
+	for el := e.order.Front(); el != nil; el = el.Next() {
+		c.add(el.Value.(T))
+	}
+	return c
+}
+
+type lfuEntry[T comparable] struct {
+	v     T
+	freq  uint64
+	seq   uint64
+	index int
+}
+
+// lfuEvictor implements EvictLFU with a min-heap ordered by use count and
+// then by the time of last use.
+type lfuEvictor[T comparable] struct {
+	entries []*lfuEntry[T]
+	index   map[T]*lfuEntry[T]
+	seq     uint64
+}
+
+func (e *lfuEvictor[T]) Len() int { return len(e.entries) }
+
+func (e *lfuEvictor[T]) Less(i, j int) bool {
+	a, b := e.entries[i], e.entries[j]
+	return a.freq < b.freq || (a.freq == b.freq && a.seq < b.seq)
+}
+
+func (e *lfuEvictor[T]) Swap(i, j int) {
+	e.entries[i], e.entries[j] = e.entries[j], e.entries[i]
+	e.entries[i].index = i
+	e.entries[j].index = j
+}
+
+func (e *lfuEvictor[T]) Push(x any) {
+	entry := x.(*lfuEntry[T])
+	entry.index = len(e.entries)
+	e.entries = append(e.entries, entry)
+}
+
+func (e *lfuEvictor[T]) Pop() any {
+	n := len(e.entries) - 1
+	entry := e.entries[n]
+	e.entries[n] = nil
+	e.entries = e.entries[:n]
+	return entry
+}
+
+func (e *lfuEvictor[T]) add(v T) {
+	e.seq++
+	entry := &lfuEntry[T]{v: v, freq: 1, seq: e.seq}
+	e.index[v] = entry
+	heap.Push(e, entry)
+}
+
+func (e *lfuEvictor[T]) touch(v T) {
+	e.seq++
+	entry := e.index[v]
+	entry.freq++
+	entry.seq = e.seq
+	heap.Fix(e, entry.index)
+}
+
+func (e *lfuEvictor[T]) remove(v T) {
+	heap.Remove(e, e.index[v].index)
+	delete(e.index, v)
+}
+
+func (e *lfuEvictor[T]) victim() T {
+	entry := heap.Pop(e).(*lfuEntry[T])
+	delete(e.index, entry.v)
+	return entry.v
+}
+
+func (e *lfuEvictor[T]) clone() evictor[T] {
+	c := &lfuEvictor[T]{
+		entries: make([]*lfuEntry[T], len(e.entries)),
+		index:   make(map[T]*lfuEntry[T], len(e.index)),
+		seq:     e.seq,
+	}
+	for i, entry := range e.entries {
+		cp := *entry
+		c.entries[i] = &cp
+		c.index[cp.v] = &cp
+	}
+	return c
+}
+
+// randomEvictor implements EvictRandom with a dense slice of elements so
+// that a victim can be chosen and removed in constant time.
+type randomEvictor[T comparable] struct {
+	elems []T
+	index map[T]int
+}
+
+func (e *randomEvictor[T]) add(v T) {
+	e.index[v] = len(e.elems)
+	e.elems = append(e.elems, v)
+}
+
+func (e *randomEvictor[T]) touch(T) {}
+
+func (e *randomEvictor[T]) remove(v T) {
+	i := e.index[v]
+	last := len(e.elems) - 1
+	e.elems[i] = e.elems[last]
+	e.index[e.elems[i]] = i
+	e.elems = e.elems[:last]
+	delete(e.index, v)
+}
+
+func (e *randomEvictor[T]) victim() T {
+	v := e.elems[rand.Intn(len(e.elems))]
+	e.remove(v)
+	return v
+}
+
+func (e *randomEvictor[T]) clone() evictor[T] {
+	c := &randomEvictor[T]{index: make(map[T]int, len(e.index))}
+	for _, v := range e.elems {
+		c.add(v)
+	}
+	return c
+}
+
+type boundedSet[T comparable] struct {
+	sync.Mutex
+	capacity int
+	opts     BoundedSetOptions[T]
+	uss      *threadUnsafeSet[T]
+	evictor  evictor[T]
+	stats    BoundedSetStats
+}
+
+// Assert concrete type:boundedSet adheres to BoundedSet interface.
+var _ BoundedSet[string] = (*boundedSet[string])(nil)
+
+// add adds v, evicting an element first if the set is full. The lock must
+// be held.
+func (s *boundedSet[T]) add(v T) (added bool, evicted T, ok bool) {
+	if s.uss.contains(v) {
+		s.evictor.touch(v)
+		return false, evicted, false
+	}
+	if len(*s.uss) >= s.capacity {
+		evicted, ok = s.evictor.victim(), true
+		delete(*s.uss, evicted)
+		s.stats.Evictions++
+		if s.opts.OnEvict != nil {
+			s.opts.OnEvict(evicted)
+		}
+	}
+	s.uss.add(v)
+	s.evictor.add(v)
+	return true, evicted, ok
+}
+
+// contains looks up v, recording the lookup. The lock must be held.
+func (s *boundedSet[T]) contains(v T) bool {
+	if !s.uss.contains(v) {
+		s.stats.Misses++
+		return false
+	}
+	s.stats.Hits++
+	if s.opts.TouchOnContains {
+		s.evictor.touch(v)
+	}
+	return true
+}
+
+// remove removes v if present. The lock must be held.
+func (s *boundedSet[T]) remove(v T) {
+	if s.uss.contains(v) {
+		delete(*s.uss, v)
+		s.evictor.remove(v)
+	}
+}
+
+// snapshot returns a copy of the current elements.
+func (s *boundedSet[T]) snapshot() *threadUnsafeSet[T] {
+	s.Lock()
+	defer s.Unlock()
+	return s.uss.Clone().(*threadUnsafeSet[T])
+}
+
+func (s *boundedSet[T]) Add(v T) bool {
+	s.Lock()
+	added, _, _ := s.add(v)
+	s.Unlock()
+	return added
+}
+
+func (s *boundedSet[T]) AddEvict(v T) (T, bool) {
+	s.Lock()
+	_, evicted, ok := s.add(v)
+	s.Unlock()
+	return evicted, ok
+}
+
+func (s *boundedSet[T]) Append(vs ...T) int {
+	s.Lock()
+	defer s.Unlock()
+
+	var n int
+	for _, v := range vs {
+		if added, _, _ := s.add(v); added {
+			n++
+		}
+	}
+	return n
+}
+
+func (s *boundedSet[T]) AppendFrom(other Set[T]) int {
+	return s.Append(other.ToSlice()...)
+}
+
+func (s *boundedSet[T]) Capacity() int {
+	return s.capacity
+}
+
+func (s *boundedSet[T]) Policy() EvictionPolicy {
+	return s.opts.Policy
+}
+
+func (s *boundedSet[T]) Stats() BoundedSetStats {
+	s.Lock()
+	defer s.Unlock()
+	return s.stats
+}
+
+func (s *boundedSet[T]) Cardinality() int {
+	s.Lock()
+	defer s.Unlock()
+	return len(*s.uss)
+}
+
+func (s *boundedSet[T]) Clear() {
+	s.Lock()
+	s.uss.Clear()
+	s.evictor = newEvictor[T](s.opts.Policy)
+	s.Unlock()
+}
+
+// Clone returns a bounded set with the same capacity, options and
+// eviction state. Stats are not copied.
+func (s *boundedSet[T]) Clone() Set[T] {
+	s.Lock()
+	defer s.Unlock()
+	return &boundedSet[T]{
+		capacity: s.capacity,
+		opts:     s.opts,
+		uss:      s.uss.Clone().(*threadUnsafeSet[T]),
+		evictor:  s.evictor.clone(),
+	}
+}
+
+func (s *boundedSet[T]) Contains(vs ...T) bool {
+	s.Lock()
+	defer s.Unlock()
+	for _, v := range vs {
+		if !s.contains(v) {
+			return false
+		}
+	}
+	return true
+}
+
+func (s *boundedSet[T]) ContainsOne(v T) bool {
+	s.Lock()
+	ret := s.contains(v)
+	s.Unlock()
+	return ret
+}
+
+func (s *boundedSet[T]) ContainsAny(vs ...T) bool {
+	s.Lock()
+	defer s.Unlock()
+	for _, v := range vs {
+		if s.contains(v) {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *boundedSet[T]) ContainsAnyElement(other Set[T]) bool {
+	return s.snapshot().ContainsAnyElement(toThreadUnsafeSet(other))
+}
+
+func (s *boundedSet[T]) Difference(other Set[T]) Set[T] {
+	diff := s.snapshot().Difference(toThreadUnsafeSet(other)).(*threadUnsafeSet[T])
+	return &threadSafeSet[T]{uss: diff}
+}
+
+func (s *boundedSet[T]) Equal(other Set[T]) bool {
+	return s.snapshot().Equal(toThreadUnsafeSet(other))
+}
+
+func (s *boundedSet[T]) Intersect(other Set[T]) Set[T] {
+	intersection := s.snapshot().Intersect(toThreadUnsafeSet(other)).(*threadUnsafeSet[T])
+	return &threadSafeSet[T]{uss: intersection}
+}
+
+func (s *boundedSet[T]) IsEmpty() bool {
+	return s.Cardinality() == 0
+}
+
+func (s *boundedSet[T]) IsProperSubset(other Set[T]) bool {
+	return s.snapshot().IsProperSubset(toThreadUnsafeSet(other))
+}
+
+func (s *boundedSet[T]) IsProperSuperset(other Set[T]) bool {
+	return toThreadUnsafeSet(other).IsProperSubset(s.snapshot())
+}
+
+func (s *boundedSet[T]) IsSubset(other Set[T]) bool {
+	return s.snapshot().IsSubset(toThreadUnsafeSet(other))
+}
+
+func (s *boundedSet[T]) IsSuperset(other Set[T]) bool {
+	return toThreadUnsafeSet(other).IsSubset(s.snapshot())
+}
+
+func (s *boundedSet[T]) Each(cb func(T) bool) {
+	s.snapshot().Each(cb)
+}
+
+func (s *boundedSet[T]) Filter(cb func(T) bool) Set[T] {
+	filtered := s.snapshot().Filter(cb).(*threadUnsafeSet[T])
+	return &threadSafeSet[T]{uss: filtered}
+}
+
+func (s *boundedSet[T]) Iter() <-chan T {
+	return s.snapshot().Iter()
+}
+
+func (s *boundedSet[T]) Iterator() *Iterator[T] {
+	return s.snapshot().Iterator()
+}
+
+func (s *boundedSet[T]) Remove(v T) {
+	s.Lock()
+	s.remove(v)
+	s.Unlock()
+}
+
+func (s *boundedSet[T]) RemoveAll(vs ...T) {
+	s.Lock()
+	for _, v := range vs {
+		s.remove(v)
+	}
+	s.Unlock()
+}
+
+func (s *boundedSet[T]) String() string {
+	items := make([]string, 0, s.Cardinality())
+	for _, v := range s.ToSlice() {
+		items = append(items, fmt.Sprintf("%v", v))
+	}
+	return fmt.Sprintf("Set{%s}", strings.Join(items, ", "))
+}
+
+func (s *boundedSet[T]) SymmetricDifference(other Set[T]) Set[T] {
+	sd := s.snapshot().SymmetricDifference(toThreadUnsafeSet(other)).(*threadUnsafeSet[T])
+	return &threadSafeSet[T]{uss: sd}
+}
+
+func (s *boundedSet[T]) Union(other Set[T]) Set[T] {
+	union := s.snapshot().Union(toThreadUnsafeSet(other)).(*threadUnsafeSet[T])
+	return &threadSafeSet[T]{uss: union}
+}
+
+// Pop removes and returns the element that would be evicted next.
+func (s *boundedSet[T]) Pop() (v T, ok bool) {
+	s.Lock()
+	defer s.Unlock()
+	if len(*s.uss) == 0 {
+		return v, false
+	}
+	v = s.evictor.victim()
+	delete(*s.uss, v)
+	return v, true
+}
+
+// PopN removes and returns up to n elements in the order they would
+// be evicted.
+func (s *boundedSet[T]) PopN(n int) (items []T, count int) {
+	s.Lock()
+	defer s.Unlock()
+	if n <= 0 || len(*s.uss) == 0 {
+		return make([]T, 0), 0
+	}
+	if n > len(*s.uss) {
+		n = len(*s.uss)
+	}
+	items = make([]T, 0, n)
+	for count < n {
+		v := s.evictor.victim()
+		delete(*s.uss, v)
+		items = append(items, v)
+		count++
+	}
+	return items, count
+}
+
+func (s *boundedSet[T]) ToSlice() []T {
+	s.Lock()
+	defer s.Unlock()
+	return s.uss.ToSlice()
+}
+
+func (s *boundedSet[T]) MarshalJSON() ([]byte, error) {
+	return json.Marshal(s.ToSlice())
+}
+
+// UnmarshalJSON adds the elements of a JSON array to the set, evicting
+// elements as needed.
+func (s *boundedSet[T]) UnmarshalJSON(b []byte) error {
+	var i []T
+	err := json.Unmarshal(b, &i)
+	if err != nil {
+		return err
+	}
+	s.Append(i...)
+
+	return nil
+}
+
+func (s *boundedSet[T]) MarshalBSONValue() (bsontype.Type, []byte, error) {
+	return bson.MarshalValue(s.ToSlice())
+}
+
+// UnmarshalBSONValue adds the elements of a BSON array to the set,
+// evicting elements as needed.
+func (s *boundedSet[T]) UnmarshalBSONValue(bt bsontype.Type, b []byte) error {
+	if bt != bson.TypeArray {
+		return fmt.Errorf("must use BSON Array to unmarshal Set")
+	}
+
+	var i []T
+	err := bson.UnmarshalValue(bt, b, &i)
+	if err != nil {
+		return err
+	}
+	s.Append(i...)
+
+	return nil
+}
@@ -0,0 +1,196 @@
+/*
+Open Source Initiative OSI - The MIT License (MIT):Licensing
+
+The MIT License (MIT)
+Copyright (c) 2013 - 2026 Ralph Caraveo (deckarep@gmail.com)
+
+Permission is hereby granted, free of charge, to any person obtaining a copy of
+this software and associated documentation files (the "Software"), to deal in
+the Software without restriction, including without limitation the rights to
+use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies
+of the Software, and to permit persons to whom the Software is furnished to do
+so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+
+package mapset
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func Test_BoundedSetLRU(t *testing.T) {
+	s := NewBoundedSet[int](3, BoundedSetOptions[int]{Policy: EvictLRU})
+	s.Append(1, 2, 3)
+	s.Add(1)
+
+	evicted, ok := s.AddEvict(4)
+	if !ok || evicted != 2 {
+		t.Errorf("Expected 2 to be evicted, got %v (%v)", evicted, ok)
+	}
+	if !s.Equal(NewSet(1, 3, 4)) {
+		t.Errorf("Unexpected elements: %v", s)
+	}
+}
+
+func Test_BoundedSetTouchOnContains(t *testing.T) {
+	for _, touch := range []bool{false, true} {
+		s := NewBoundedSet[int](2, BoundedSetOptions[int]{TouchOnContains: touch})
+		s.Append(1, 2)
+		s.ContainsOne(1)
+
+		evicted, _ := s.AddEvict(3)
+		want := 1
+		if touch {
+			want = 2
+		}
+		if evicted != want {
+			t.Errorf("TouchOnContains=%v: expected %d to be evicted, got %d", touch, want, evicted)
+		}
+	}
+}
+
+func Test_BoundedSetLFU(t *testing.T) {
+	s := NewBoundedSet[string](3, BoundedSetOptions[string]{Policy: EvictLFU})
+	s.Append("a", "b", "c")
+	s.Add("a")
+	s.Add("a")
+	s.Add("b")
+
+	evicted, ok := s.AddEvict("d")
+	if !ok || evicted != "c" {
+		t.Errorf("Expected c to be evicted, got %v (%v)", evicted, ok)
+	}
+	evicted, _ = s.AddEvict("e")
+	if evicted != "d" {
+		t.Errorf("Expected d to be evicted, got %v", evicted)
+	}
+	s.Remove("a")
+	if _, ok := s.AddEvict("f"); ok {
+		t.Error("Adding to a set below capacity should not evict")
+	}
+}
+
+func Test_BoundedSetFIFO(t *testing.T) {
+	s := NewBoundedSet[int](2, BoundedSetOptions[int]{Policy: EvictFIFO, TouchOnContains: true})
+	s.Append(1, 2)
+	s.Add(1)
+	s.ContainsOne(1)
+
+	if evicted, _ := s.AddEvict(3); evicted != 1 {
+		t.Errorf("Expected 1 to be evicted, got %d", evicted)
+	}
+}
+
+func Test_BoundedSetRandom(t *testing.T) {
+	s := NewBoundedSet[int](10, BoundedSetOptions[int]{Policy: EvictRandom})
+	for i := 0; i < 100; i++ {
+		s.Add(i)
+		if s.Cardinality() > 10 {
+			t.Fatalf("Cardinality %d exceeds capacity", s.Cardinality())
+		}
+	}
+	if !s.ContainsOne(99) {
+		t.Error("The most recently added element should never be evicted")
+	}
+	if s.Stats().Evictions != 90 {
+		t.Errorf("Expected 90 evictions, got %d", s.Stats().Evictions)
+	}
+}
+
+func Test_BoundedSetStats(t *testing.T) {
+	var evicted []int
+	s := NewBoundedSet[int](1, BoundedSetOptions[int]{
+		OnEvict: func(v int) {
+			evicted = append(evicted, v)
+		},
+	})
+	s.Append(1, 2, 3)
+	s.Contains(3, 4)
+
+	want := BoundedSetStats{Evictions: 2, Hits: 1, Misses: 1}
+	if s.Stats() != want {
+		t.Errorf("Expected %+v, got %+v", want, s.Stats())
+	}
+	if len(evicted) != 2 || evicted[0] != 1 || evicted[1] != 2 {
+		t.Errorf("Expected OnEvict for 1 and 2, got %v", evicted)
+	}
+}
+
+func Test_BoundedSetPop(t *testing.T) {
+	s := NewBoundedSet[int](3, BoundedSetOptions[int]{Policy: EvictFIFO})
+	s.Append(1, 2, 3)
+
+	if v, ok := s.Pop(); !ok || v != 1 {
+		t.Errorf("Expected Pop to return the oldest element, got %v (%v)", v, ok)
+	}
+	items, n := s.PopN(5)
+	if n != 2 || items[0] != 2 || items[1] != 3 {
+		t.Errorf("Expected PopN to return [2 3], got %v", items)
+	}
+	if _, ok := s.Pop(); ok {
+		t.Error("Pop on an empty set should return false")
+	}
+}
+
+func Test_BoundedSetClone(t *testing.T) {
+	s := NewBoundedSet[int](2, BoundedSetOptions[int]{})
+	s.Append(1, 2)
+
+	c := s.Clone().(BoundedSet[int])
+	c.Add(1)
+	if evicted, _ := c.AddEvict(3); evicted != 2 {
+		t.Errorf("Expected the clone to evict 2, got %d", evicted)
+	}
+	if evicted, _ := s.AddEvict(3); evicted != 1 {
+		t.Errorf("Expected the original to evict 1, got %d", evicted)
+	}
+}
+
+func Test_BoundedSetSetOperations(t *testing.T) {
+	s := NewBoundedSet[int](3, BoundedSetOptions[int]{})
+	s.Append(1, 2, 3)
+	other := NewThreadUnsafeSet(2, 3, 4)
+
+	assertEqual(s.Union(other), NewSet(1, 2, 3, 4), t)
+	assertEqual(s.Intersect(other), NewSet(2, 3), t)
+	assertEqual(s.Difference(other), NewSet(1), t)
+	assertEqual(s.SymmetricDifference(other), NewSet(1, 4), t)
+
+	if !s.IsSuperset(NewSet(1, 2)) || !s.IsSubset(NewSet(1, 2, 3, 4)) {
+		t.Error("Subset relations should accept any Set implementation")
+	}
+	if !s.Equal(s) {
+		t.Error("A bounded set should be equal to itself")
+	}
+}
+
+func Test_BoundedSetUnmarshalJSON(t *testing.T) {
+	s := NewBoundedSet[int](2, BoundedSetOptions[int]{Policy: EvictFIFO})
+	if err := json.Unmarshal([]byte(`[1, 2, 3]`), s); err != nil {
+		t.Fatalf("Error should be nil: %v", err)
+	}
+	if !s.Equal(NewSet(2, 3)) {
+		t.Errorf("Expected Set{2, 3}, got %v", s)
+	}
+}
+
+func Test_NewBoundedSetPanics(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Error("NewBoundedSet should panic on a non-positive capacity")
+		}
+	}()
+	NewBoundedSet[int](0, BoundedSetOptions[int]{})
+}
@@ -0,0 +1,245 @@
+/*
+Open Source Initiative OSI - The MIT License (MIT):Licensing
+
+The MIT License (MIT)
+Copyright (c) 2013 - 2026 Ralph Caraveo (deckarep@gmail.com)
+
+Permission is hereby granted, free of charge, to any person obtaining a copy of
+this software and associated documentation files (the "Software"), to deal in
+the Software without restriction, including without limitation the rights to
+use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies
+of the Software, and to permit persons to whom the Software is furnished to do
+so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+
+package mapset
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/bsontype"
+)
+
+// ErrMaxCardinality is returned when adding elements would grow a
+// ConstrainedSet beyond its maximum cardinality.
+var ErrMaxCardinality = errors.New("mapset: set is at its maximum cardinality")
+
+// Validator checks an element before it is added to a ConstrainedSet,
+// returning a non-nil error to reject it.
+type Validator[T comparable] func(val T) error
+
+// InSet returns a Validator that accepts only the elements of allowed.
+func InSet[T comparable](allowed Set[T]) Validator[T] {
+	return func(v T) error {
+		if !allowed.ContainsOne(v) {
+			return errors.New("not an allowed value")
+		}
+		return nil
+	}
+}
+
+// ElementError is returned when a Validator rejects an element.
+type ElementError[T comparable] struct {
+	Element T
+	Err     error
+}
+
+func (e *ElementError[T]) Error() string {
+	return fmt.Sprintf("mapset: invalid element %v: %v", e.Element, e.Err)
+}
+
+func (e *ElementError[T]) Unwrap() error {
+	return e.Err
+}
+
+// ConstrainedSetOptions configures a ConstrainedSet. The zero value
+// imposes no constraints.
+type ConstrainedSetOptions[T comparable] struct {
+	// Validators are called, in order, for every element added.
+	Validators []Validator[T]
+
+	// MaxCardinality is the maximum number of elements in the set.
+	// If it is not positive the cardinality is not limited.
+	MaxCardinality int
+}
+
+// ConstrainedSet is a Set that enforces invariants on its elements. Add
+// and Append silently skip elements that would violate them; the Try
+// variants report why an element was rejected.
+//
+// Clone returns a ConstrainedSet with the same constraints. Methods that
+// return a new set (Union, Intersect, Difference, SymmetricDifference and
+// Filter) return sets of the wrapped set's implementation, which are not
+// constrained.
+//
+// Operations on a ConstrainedSet are thread-safe if the wrapped set is.
+type ConstrainedSet[T comparable] interface {
+	Set[T]
+
+	// TryAdd adds an element to the set. Returns whether the item
+	// was added, or an *ElementError if a validator rejected it, or
+	// ErrMaxCardinality if the set is full.
+	TryAdd(val T) (bool, error)
+
+	// TryAppend adds multiple elements to the set, either all of
+	// them or, if any element is rejected, none. Returns the number
+	// of elements added, or the error for the first rejected
+	// element.
+	TryAppend(val ...T) (int, error)
+
+	// TryUnmarshal adds the elements of a JSON array to the set with
+	// the semantics of TryAppend.
+	TryUnmarshal(b []byte) error
+}
+
+// NewConstrainedSet returns a ConstrainedSet that wraps s and enforces
+// the constraints in opts. It returns an error if s already violates
+// them. Changes made directly to s are not checked.
+func NewConstrainedSet[T comparable](s Set[T], opts ConstrainedSetOptions[T]) (ConstrainedSet[T], error) {
+	c := &constrainedSet[T]{opts: opts}
+	c.Set = s
+	if opts.MaxCardinality > 0 && s.Cardinality() > opts.MaxCardinality {
+		return nil, fmt.Errorf("%w: %d elements exceeds %d",
+			ErrMaxCardinality, s.Cardinality(), opts.MaxCardinality)
+	}
+	var err error
+	s.Each(func(v T) bool {
+		err = c.validate(v)
+		return err != nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+type constrainedSet[T comparable] struct {
+	wrappedSet[T]
+	opts ConstrainedSetOptions[T]
+
+	// mu serializes additions, so that the cardinality limit holds.
+	mu sync.Mutex
+}
+
+// Assert concrete type:constrainedSet adheres to ConstrainedSet interface.
+var _ ConstrainedSet[string] = (*constrainedSet[string])(nil)
+
+func (s *constrainedSet[T]) validate(v T) error {
+	for _, validate := range s.opts.Validators {
+		if err := validate(v); err != nil {
+			return &ElementError[T]{Element: v, Err: err}
+		}
+	}
+	return nil
+}
+
+// tryAppend adds vs if they are all valid and fit. It is called with
+// s.mu held.
+func (s *constrainedSet[T]) tryAppend(vs []T) (int, error) {
+	// Keep the order of vs, which matters to sets such as BoundedSet.
+	var added []T
+	seen := newThreadUnsafeSet[T]()
+	for _, v := range vs {
+		if s.Set.ContainsOne(v) || seen.contains(v) {
+			continue
+		}
+		if err := s.validate(v); err != nil {
+			return 0, err
+		}
+		seen.add(v)
+		added = append(added, v)
+	}
+
+	limit, n := s.opts.MaxCardinality, len(added)
+	if limit > 0 && n > 0 && s.Set.Cardinality()+n > limit {
+		return 0, fmt.Errorf("%w: cannot add %d elements to %d of %d",
+			ErrMaxCardinality, n, s.Set.Cardinality(), limit)
+	}
+	return s.Set.Append(added...), nil
+}
+
+func (s *constrainedSet[T]) TryAdd(v T) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	n, err := s.tryAppend([]T{v})
+	return n == 1, err
+}
+
+func (s *constrainedSet[T]) TryAppend(vs ...T) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.tryAppend(vs)
+}
+
+func (s *constrainedSet[T]) TryUnmarshal(b []byte) error {
+	var i []T
+	if err := json.Unmarshal(b, &i); err != nil {
+		return err
+	}
+	_, err := s.TryAppend(i...)
+	return err
+}
+
+func (s *constrainedSet[T]) Add(v T) bool {
+	added, _ := s.TryAdd(v)
+	return added
+}
+
+// Append adds the elements that satisfy the set's constraints, in order,
+// skipping the others.
+func (s *constrainedSet[T]) Append(vs ...T) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var n int
+	for _, v := range vs {
+		added, _ := s.tryAppend([]T{v})
+		n += added
+	}
+	return n
+}
+
+func (s *constrainedSet[T]) AppendFrom(other Set[T]) int {
+	return s.Append(other.ToSlice()...)
+}
+
+func (s *constrainedSet[T]) Clone() Set[T] {
+	c := &constrainedSet[T]{opts: s.opts}
+	c.Set = s.Set.Clone()
+	return c
+}
+
+// UnmarshalJSON adds the elements of a JSON array to the set with the
+// semantics of TryUnmarshal.
+func (s *constrainedSet[T]) UnmarshalJSON(b []byte) error {
+	return s.TryUnmarshal(b)
+}
+
+// UnmarshalBSONValue adds the elements of a BSON array to the set with
+// the semantics of TryAppend.
+func (s *constrainedSet[T]) UnmarshalBSONValue(bt bsontype.Type, b []byte) error {
+	if bt != bson.TypeArray {
+		return fmt.Errorf("must use BSON Array to unmarshal Set")
+	}
+
+	var i []T
+	if err := bson.UnmarshalValue(bt, b, &i); err != nil {
+		return err
+	}
+	_, err := s.TryAppend(i...)
+	return err
+}
@@ -0,0 +1,168 @@
+/*
+Open Source Initiative OSI - The MIT License (MIT):Licensing
+
+The MIT License (MIT)
+Copyright (c) 2013 - 2026 Ralph Caraveo (deckarep@gmail.com)
+
+Permission is hereby granted, free of charge, to any person obtaining a copy of
+this software and associated documentation files (the "Software"), to deal in
+the Software without restriction, including without limitation the rights to
+use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies
+of the Software, and to permit persons to whom the Software is furnished to do
+so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+
+package mapset
+
+import (
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+var errNotLowercase = errors.New("not lowercase")
+
+func lowercase(v string) error {
+	if v != strings.ToLower(v) {
+		return errNotLowercase
+	}
+	return nil
+}
+
+func newTestConstrainedSet(t *testing.T, limit int) ConstrainedSet[string] {
+	s, err := NewConstrainedSet(NewSet[string](), ConstrainedSetOptions[string]{
+		Validators:     []Validator[string]{lowercase},
+		MaxCardinality: limit,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return s
+}
+
+func Test_ConstrainedSetTryAdd(t *testing.T) {
+	s := newTestConstrainedSet(t, 2)
+
+	if added, err := s.TryAdd("a"); !added || err != nil {
+		t.Errorf("Expected a to be added, got %v, %v", added, err)
+	}
+	if added, err := s.TryAdd("a"); added || err != nil {
+		t.Errorf("Expected a to be present, got %v, %v", added, err)
+	}
+
+	_, err := s.TryAdd("B")
+	var elemErr *ElementError[string]
+	if !errors.As(err, &elemErr) || elemErr.Element != "B" || !errors.Is(err, errNotLowercase) {
+		t.Errorf("Expected an ElementError wrapping the validator's error, got %v", err)
+	}
+
+	s.Add("b")
+	if _, err := s.TryAdd("c"); !errors.Is(err, ErrMaxCardinality) {
+		t.Errorf("Expected ErrMaxCardinality, got %v", err)
+	}
+	if s.Add("c") || s.Cardinality() != 2 {
+		t.Error("Add should not exceed the maximum cardinality")
+	}
+}
+
+func Test_ConstrainedSetTryAppend(t *testing.T) {
+	s := newTestConstrainedSet(t, 3)
+
+	if n, err := s.TryAppend("a", "B", "c"); n != 0 || err == nil || s.Cardinality() != 0 {
+		t.Errorf("TryAppend should add nothing if an element is invalid, got %d, %v", n, err)
+	}
+	if n, err := s.TryAppend("a", "b", "c", "d"); n != 0 || !errors.Is(err, ErrMaxCardinality) {
+		t.Errorf("TryAppend should add nothing if the elements do not fit, got %d, %v", n, err)
+	}
+	if n, err := s.TryAppend("a", "b", "a", "b"); n != 2 || err != nil {
+		t.Errorf("TryAppend should count repeated elements once, got %d, %v", n, err)
+	}
+	if n, err := s.TryAppend("a", "c"); n != 1 || err != nil {
+		t.Errorf("Expected 1 element added, got %d, %v", n, err)
+	}
+}
+
+func Test_ConstrainedSetAppend(t *testing.T) {
+	s := newTestConstrainedSet(t, 3)
+	if n := s.Append("a", "B", "c", "d", "e"); n != 3 || !s.Contains("a", "c", "d") {
+		t.Errorf("Append should skip rejected elements, added %d: %v", n, s)
+	}
+	if n := s.AppendFrom(NewSet("f")); n != 0 {
+		t.Errorf("AppendFrom should respect the maximum cardinality, added %d", n)
+	}
+}
+
+func Test_ConstrainedSetInSet(t *testing.T) {
+	s, err := NewConstrainedSet(NewThreadUnsafeSet[int](), ConstrainedSetOptions[int]{
+		Validators: []Validator[int]{InSet(NewSet(1, 2, 3))},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !s.Add(1) || s.Add(4) {
+		t.Error("Only allowed values should be added")
+	}
+}
+
+func Test_NewConstrainedSetViolation(t *testing.T) {
+	opts := ConstrainedSetOptions[string]{
+		Validators:     []Validator[string]{lowercase},
+		MaxCardinality: 2,
+	}
+	if _, err := NewConstrainedSet(NewSet("a", "B"), opts); !errors.Is(err, errNotLowercase) {
+		t.Errorf("Expected an invalid element error, got %v", err)
+	}
+	if _, err := NewConstrainedSet(NewSet("a", "b", "c"), opts); !errors.Is(err, ErrMaxCardinality) {
+		t.Errorf("Expected ErrMaxCardinality, got %v", err)
+	}
+}
+
+func Test_ConstrainedSetUnmarshal(t *testing.T) {
+	s := newTestConstrainedSet(t, 0)
+	if err := s.TryUnmarshal([]byte(`["a", "B"]`)); !errors.Is(err, errNotLowercase) || s.Cardinality() != 0 {
+		t.Errorf("TryUnmarshal should reject invalid elements, got %v", err)
+	}
+	if err := json.Unmarshal([]byte(`["a", "b"]`), s); err != nil || s.Cardinality() != 2 {
+		t.Errorf("UnmarshalJSON should add valid elements, got %v", err)
+	}
+	if err := s.TryUnmarshal([]byte(`{`)); err == nil {
+		t.Error("Expected a syntax error")
+	}
+
+	bt, b, err := bson.MarshalValue(NewSet("C"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := bson.UnmarshalValue(bt, b, s); !errors.Is(err, errNotLowercase) {
+		t.Errorf("UnmarshalBSONValue should reject invalid elements, got %v", err)
+	}
+}
+
+func Test_ConstrainedSetClone(t *testing.T) {
+	s := newTestConstrainedSet(t, 1)
+	s.Add("a")
+	c := s.Clone().(ConstrainedSet[string])
+	if _, err := c.TryAdd("b"); !errors.Is(err, ErrMaxCardinality) {
+		t.Error("A clone should keep the constraints")
+	}
+	if !c.Equal(s) || !s.Union(NewSet("b")).Equal(NewSet("a", "b")) {
+		t.Error("Binary operations should accept constrained sets")
+	}
+	if !NewObservableSet[string](s).Equal(c) {
+		t.Error("Wrapped sets should unwrap each other")
+	}
+}
@@ -0,0 +1,430 @@
+/*
+Open Source Initiative OSI - The MIT License (MIT):Licensing
+
+The MIT License (MIT)
+Copyright (c) 2013 - 2026 Ralph Caraveo (deckarep@gmail.com)
+
+Permission is hereby granted, free of charge, to any person obtaining a copy of
+this software and associated documentation files (the "Software"), to deal in
+the Software without restriction, including without limitation the rights to
+use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies
+of the Software, and to permit persons to whom the Software is furnished to do
+so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+
+package mapset
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/bsontype"
+)
+
+// GSet is a grow-only set, a state-based CRDT. Elements can be added but
+// never removed, and replicas converge by merging their states in any
+// order.
+//
+// Operations on a GSet are thread-safe.
+type GSet[T comparable] interface {
+	// Add adds an element to the set. Returns whether
+	// the item was added.
+	Add(val T) bool
+
+	// Append multiple elements to the set. Returns
+	// the number of elements added.
+	Append(val ...T) int
+
+	// Cardinality returns the number of elements in the set.
+	Cardinality() int
+
+	// Contains returns whether the given items
+	// are all in the set.
+	Contains(val ...T) bool
+
+	// ContainsOne returns whether the given item
+	// is in the set.
+	ContainsOne(val T) bool
+
+	// Each iterates over elements and executes the passed func against each element.
+	// If passed func returns true, stop iteration at the time.
+	Each(func(T) bool)
+
+	// Merge adds the elements of other to the set.
+	//
+	// Note that the argument to Merge must be of the same type
+	// as the receiver of the method. Otherwise, Merge will panic.
+	Merge(other GSet[T])
+
+	// String provides a convenient string representation
+	// of the current state of the set.
+	String() string
+
+	// ToSet returns the elements as a thread-safe Set.
+	ToSet() Set[T]
+
+	// ToSlice returns the members of the set as a slice.
+	ToSlice() []T
+
+	// MarshalJSON will marshal the set's state into a JSON array.
+	MarshalJSON() ([]byte, error)
+
+	// UnmarshalJSON will merge a state created by MarshalJSON
+	// into the set.
+	UnmarshalJSON(b []byte) error
+
+	// MarshalBSONValue will marshal the set's state into a BSON array.
+	MarshalBSONValue() (bsontype.Type, []byte, error)
+
+	// UnmarshalBSONValue will merge a state created by
+	// MarshalBSONValue into the set.
+	UnmarshalBSONValue(bt bsontype.Type, b []byte) error
+}
+
+// NewGSet creates and returns a new grow-only set with the given
+// elements.
+func NewGSet[T comparable](vs ...T) GSet[T] {
+	s := &gSet[T]{elems: newThreadSafeSetWithSize[T](len(vs))}
+	s.elems.Append(vs...)
+	return s
+}
+
+type gSet[T comparable] struct {
+	elems *threadSafeSet[T]
+}
+
+// Assert concrete type:gSet adheres to GSet interface.
+var _ GSet[string] = (*gSet[string])(nil)
+
+func (s *gSet[T]) Add(v T) bool {
+	return s.elems.Add(v)
+}
+
+func (s *gSet[T]) Append(vs ...T) int {
+	return s.elems.Append(vs...)
+}
+
+func (s *gSet[T]) Cardinality() int {
+	return s.elems.Cardinality()
+}
+
+func (s *gSet[T]) Contains(vs ...T) bool {
+	return s.elems.Contains(vs...)
+}
+
+func (s *gSet[T]) ContainsOne(v T) bool {
+	return s.elems.ContainsOne(v)
+}
+
+func (s *gSet[T]) Each(cb func(T) bool) {
+	s.elems.Each(cb)
+}
+
+func (s *gSet[T]) Merge(other GSet[T]) {
+	o := other.(*gSet[T])
+
+	// Copy other before locking s, so concurrent merges in opposite
+	// directions cannot deadlock.
+	s.elems.Append(o.elems.ToSlice()...)
+}
+
+func (s *gSet[T]) String() string {
+	vs := s.elems.ToSlice()
+	items := make([]string, 0, len(vs))
+	for _, v := range vs {
+		items = append(items, fmt.Sprintf("%v", v))
+	}
+	return fmt.Sprintf("GSet{%s}", strings.Join(items, ", "))
+}
+
+func (s *gSet[T]) ToSet() Set[T] {
+	return s.elems.Clone()
+}
+
+func (s *gSet[T]) ToSlice() []T {
+	return s.elems.ToSlice()
+}
+
+func (s *gSet[T]) MarshalJSON() ([]byte, error) {
+	return s.elems.MarshalJSON()
+}
+
+func (s *gSet[T]) UnmarshalJSON(b []byte) error {
+	return s.elems.UnmarshalJSON(b)
+}
+
+func (s *gSet[T]) MarshalBSONValue() (bsontype.Type, []byte, error) {
+	return s.elems.MarshalBSONValue()
+}
+
+func (s *gSet[T]) UnmarshalBSONValue(bt bsontype.Type, b []byte) error {
+	return s.elems.UnmarshalBSONValue(bt, b)
+}
+
+// TwoPhaseSet is a set from which elements can be added and then removed
+// once, a state-based CRDT. Removed elements are remembered as tombstones
+// and can never be added again; a removal wins over a concurrent add.
+// Replicas converge by merging their states in any order.
+//
+// Operations on a TwoPhaseSet are thread-safe.
+type TwoPhaseSet[T comparable] interface {
+	// Add adds an element to the set. Returns whether the item was
+	// added, which is false if the item is present or was removed.
+	Add(val T) bool
+
+	// Append multiple elements to the set. Returns
+	// the number of elements added.
+	Append(val ...T) int
+
+	// Cardinality returns the number of elements in the set.
+	Cardinality() int
+
+	// Contains returns whether the given items
+	// are all in the set.
+	Contains(val ...T) bool
+
+	// ContainsOne returns whether the given item
+	// is in the set.
+	ContainsOne(val T) bool
+
+	// Each iterates over elements and executes the passed func against each element.
+	// If passed func returns true, stop iteration at the time.
+	Each(func(T) bool)
+
+	// Merge combines the added elements and tombstones of other
+	// with those of the set.
+	//
+	// Note that the argument to Merge must be of the same type
+	// as the receiver of the method. Otherwise, Merge will panic.
+	Merge(other TwoPhaseSet[T])
+
+	// Remove removes an element from the set, leaving a tombstone
+	// that prevents it from being added again. Returns whether the
+	// item was removed, which is false if it was not present.
+	Remove(val T) bool
+
+	// Removed returns whether the given item has been removed.
+	Removed(val T) bool
+
+	// String provides a convenient string representation
+	// of the current state of the set.
+	String() string
+
+	// ToSet returns the elements as a thread-safe Set.
+	ToSet() Set[T]
+
+	// ToSlice returns the members of the set as a slice.
+	ToSlice() []T
+
+	// MarshalJSON will marshal the set's state, including
+	// tombstones, into a JSON object.
+	MarshalJSON() ([]byte, error)
+
+	// UnmarshalJSON will merge a state created by MarshalJSON
+	// into the set.
+	UnmarshalJSON(b []byte) error
+
+	// MarshalBSONValue will marshal the set's state, including
+	// tombstones, into a BSON document.
+	MarshalBSONValue() (bsontype.Type, []byte, error)
+
+	// UnmarshalBSONValue will merge a state created by
+	// MarshalBSONValue into the set.
+	UnmarshalBSONValue(bt bsontype.Type, b []byte) error
+}
+
+// NewTwoPhaseSet creates and returns a new two-phase set with the given
+// elements.
+func NewTwoPhaseSet[T comparable](vs ...T) TwoPhaseSet[T] {
+	s := &twoPhaseSet[T]{
+		added:   newThreadUnsafeSetWithSize[T](len(vs)),
+		removed: newThreadUnsafeSet[T](),
+	}
+	s.added.append(vs...)
+	return s
+}
+
+// twoPhaseSet holds every element ever added, including removed ones, in
+// added, and the tombstones in removed.
+type twoPhaseSet[T comparable] struct {
+	sync.RWMutex
+	added   *threadUnsafeSet[T]
+	removed *threadUnsafeSet[T]
+}
+
+// Assert concrete type:twoPhaseSet adheres to TwoPhaseSet interface.
+var _ TwoPhaseSet[string] = (*twoPhaseSet[string])(nil)
+
+// private version of ContainsOne which expects the lock to be held
+func (s *twoPhaseSet[T]) contains(v T) bool {
+	return s.added.contains(v) && !s.removed.contains(v)
+}
+
+func (s *twoPhaseSet[T]) Add(v T) bool {
+	s.Lock()
+	defer s.Unlock()
+	if s.added.contains(v) || s.removed.contains(v) {
+		return false
+	}
+	s.added.add(v)
+	return true
+}
+
+func (s *twoPhaseSet[T]) Append(vs ...T) int {
+	var n int
+	for _, v := range vs {
+		if s.Add(v) {
+			n++
+		}
+	}
+	return n
+}
+
+func (s *twoPhaseSet[T]) Cardinality() int {
+	s.RLock()
+	defer s.RUnlock()
+	return s.added.Cardinality() - s.removed.Cardinality()
+}
+
+func (s *twoPhaseSet[T]) Contains(vs ...T) bool {
+	s.RLock()
+	defer s.RUnlock()
+	for _, v := range vs {
+		if !s.contains(v) {
+			return false
+		}
+	}
+	return true
+}
+
+func (s *twoPhaseSet[T]) ContainsOne(v T) bool {
+	s.RLock()
+	defer s.RUnlock()
+	return s.contains(v)
+}
+
+func (s *twoPhaseSet[T]) Each(cb func(T) bool) {
+	s.RLock()
+	defer s.RUnlock()
+	for elem := range *s.added {
+		if !s.removed.contains(elem) && cb(elem) {
+			break
+		}
+	}
+}
+
+// state returns copies of the added elements and tombstones.
+func (s *twoPhaseSet[T]) state() twoPhaseState[T] {
+	s.RLock()
+	defer s.RUnlock()
+	return twoPhaseState[T]{Added: s.added.ToSlice(), Removed: s.removed.ToSlice()}
+}
+
+// merge adds the elements and tombstones of st to the set. Tombstones
+// are added to the added elements too, so that Cardinality stays exact
+// when a tombstone arrives before its add.
+func (s *twoPhaseSet[T]) merge(st twoPhaseState[T]) {
+	s.Lock()
+	defer s.Unlock()
+	s.added.append(st.Added...)
+	s.added.append(st.Removed...)
+	s.removed.append(st.Removed...)
+}
+
+func (s *twoPhaseSet[T]) Merge(other TwoPhaseSet[T]) {
+	o := other.(*twoPhaseSet[T])
+
+	// Copy other before locking s, so concurrent merges in opposite
+	// directions cannot deadlock.
+	s.merge(o.state())
+}
+
+func (s *twoPhaseSet[T]) Remove(v T) bool {
+	s.Lock()
+	defer s.Unlock()
+	if !s.contains(v) {
+		return false
+	}
+	s.removed.add(v)
+	return true
+}
+
+func (s *twoPhaseSet[T]) Removed(v T) bool {
+	s.RLock()
+	defer s.RUnlock()
+	return s.removed.contains(v)
+}
+
+func (s *twoPhaseSet[T]) String() string {
+	vs := s.ToSlice()
+	items := make([]string, 0, len(vs))
+	for _, v := range vs {
+		items = append(items, fmt.Sprintf("%v", v))
+	}
+	return fmt.Sprintf("TwoPhaseSet{%s}", strings.Join(items, ", "))
+}
+
+func (s *twoPhaseSet[T]) ToSet() Set[T] {
+	return NewSet(s.ToSlice()...)
+}
+
+func (s *twoPhaseSet[T]) ToSlice() []T {
+	s.RLock()
+	defer s.RUnlock()
+	keys := make([]T, 0, s.added.Cardinality()-s.removed.Cardinality())
+	for elem := range *s.added {
+		if !s.removed.contains(elem) {
+			keys = append(keys, elem)
+		}
+	}
+	return keys
+}
+
+type twoPhaseState[T comparable] struct {
+	Added   []T `json:"added" bson:"added"`
+	Removed []T `json:"removed" bson:"removed"`
+}
+
+func (s *twoPhaseSet[T]) MarshalJSON() ([]byte, error) {
+	return json.Marshal(s.state())
+}
+
+func (s *twoPhaseSet[T]) UnmarshalJSON(b []byte) error {
+	var st twoPhaseState[T]
+	if err := json.Unmarshal(b, &st); err != nil {
+		return err
+	}
+	s.merge(st)
+	return nil
+}
+
+func (s *twoPhaseSet[T]) MarshalBSONValue() (bsontype.Type, []byte, error) {
+	return bson.MarshalValue(s.state())
+}
+
+func (s *twoPhaseSet[T]) UnmarshalBSONValue(bt bsontype.Type, b []byte) error {
+	if bt != bson.TypeEmbeddedDocument {
+		return fmt.Errorf("must use BSON Document to unmarshal TwoPhaseSet")
+	}
+
+	var st twoPhaseState[T]
+	if err := bson.UnmarshalValue(bt, b, &st); err != nil {
+		return err
+	}
+	s.merge(st)
+	return nil
+}
@@ -0,0 +1,233 @@
+/*
+Open Source Initiative OSI - The MIT License (MIT):Licensing
+
+The MIT License (MIT)
+Copyright (c) 2013 - 2026 Ralph Caraveo (deckarep@gmail.com)
+
+Permission is hereby granted, free of charge, to any person obtaining a copy of
+this software and associated documentation files (the "Software"), to deal in
+the Software without restriction, including without limitation the rights to
+use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies
+of the Software, and to permit persons to whom the Software is furnished to do
+so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+
+package mapset
+
+import (
+	"encoding/json"
+	"math/rand"
+	"testing"
+
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// randomGSet returns a GSet with a few random elements from a small
+// range, so that replicas overlap.
+func randomGSet(r *rand.Rand) GSet[int] {
+	s := NewGSet[int]()
+	for i := r.Intn(10); i > 0; i-- {
+		s.Add(r.Intn(20))
+	}
+	return s
+}
+
+// randomTwoPhaseSet returns a TwoPhaseSet built from a random sequence of
+// adds and removes.
+func randomTwoPhaseSet(r *rand.Rand) TwoPhaseSet[int] {
+	s := NewTwoPhaseSet[int]()
+	for i := r.Intn(20); i > 0; i-- {
+		v := r.Intn(20)
+		if r.Intn(3) == 0 {
+			s.Remove(v)
+		} else {
+			s.Add(v)
+		}
+	}
+	return s
+}
+
+func gSetMerge(a, b GSet[int]) GSet[int] {
+	m := NewGSet[int]()
+	m.Merge(a)
+	m.Merge(b)
+	return m
+}
+
+func twoPhaseSetMerge(a, b TwoPhaseSet[int]) TwoPhaseSet[int] {
+	m := NewTwoPhaseSet[int]()
+	m.Merge(a)
+	m.Merge(b)
+	return m
+}
+
+// twoPhaseSetEqual compares the full states of a and b, including
+// tombstones.
+func twoPhaseSetEqual(a, b TwoPhaseSet[int]) bool {
+	x, y := a.(*twoPhaseSet[int]), b.(*twoPhaseSet[int])
+	return x.added.Equal(y.added) && x.removed.Equal(y.removed)
+}
+
+func Test_GSetMergeProperties(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	for i := 0; i < 200; i++ {
+		a, b, c := randomGSet(r), randomGSet(r), randomGSet(r)
+		if !gSetMerge(a, b).ToSet().Equal(gSetMerge(b, a).ToSet()) {
+			t.Fatalf("Merge should be commutative: %v, %v", a, b)
+		}
+		if !gSetMerge(gSetMerge(a, b), c).ToSet().Equal(gSetMerge(a, gSetMerge(b, c)).ToSet()) {
+			t.Fatalf("Merge should be associative: %v, %v, %v", a, b, c)
+		}
+		if !gSetMerge(a, a).ToSet().Equal(a.ToSet()) {
+			t.Fatalf("Merge should be idempotent: %v", a)
+		}
+	}
+}
+
+func Test_TwoPhaseSetMergeProperties(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	for i := 0; i < 200; i++ {
+		a, b, c := randomTwoPhaseSet(r), randomTwoPhaseSet(r), randomTwoPhaseSet(r)
+		if !twoPhaseSetEqual(twoPhaseSetMerge(a, b), twoPhaseSetMerge(b, a)) {
+			t.Fatalf("Merge should be commutative: %v, %v", a, b)
+		}
+		if !twoPhaseSetEqual(twoPhaseSetMerge(twoPhaseSetMerge(a, b), c), twoPhaseSetMerge(a, twoPhaseSetMerge(b, c))) {
+			t.Fatalf("Merge should be associative: %v, %v, %v", a, b, c)
+		}
+		if !twoPhaseSetEqual(twoPhaseSetMerge(a, a), a) {
+			t.Fatalf("Merge should be idempotent: %v", a)
+		}
+	}
+}
+
+func Test_GSet(t *testing.T) {
+	s := NewGSet(1, 2)
+	if !s.Add(3) || s.Add(3) || s.Append(3, 4, 5) != 2 {
+		t.Error("Add and Append should report new elements")
+	}
+	if s.Cardinality() != 5 || !s.Contains(1, 5) || s.ContainsOne(6) {
+		t.Errorf("Unexpected contents %v", s)
+	}
+
+	o := NewGSet(6)
+	o.Merge(s)
+	if !o.ToSet().Equal(NewSet(1, 2, 3, 4, 5, 6)) {
+		t.Errorf("Merge should add the other set's elements, got %v", o)
+	}
+	if s.String() == "" || NewGSet[int]().String() != "GSet{}" {
+		t.Error("Unexpected string representation")
+	}
+}
+
+func Test_TwoPhaseSet(t *testing.T) {
+	s := NewTwoPhaseSet(1, 2, 3)
+	if s.Remove(4) {
+		t.Error("Removing an absent element should fail")
+	}
+	if !s.Remove(2) || s.Remove(2) {
+		t.Error("An element should be removed once")
+	}
+	if s.ContainsOne(2) || !s.Removed(2) || s.Cardinality() != 2 {
+		t.Errorf("Unexpected contents %v", s)
+	}
+	if s.Add(2) || s.Append(2, 4) != 1 {
+		t.Error("A removed element should not be added again")
+	}
+	if !s.ToSet().Equal(NewSet(1, 3, 4)) {
+		t.Errorf("Expected {1, 3, 4}, got %v", s)
+	}
+
+	var seen []int
+	s.Each(func(v int) bool {
+		seen = append(seen, v)
+		return false
+	})
+	if len(seen) != 3 {
+		t.Errorf("Each should skip removed elements, got %v", seen)
+	}
+}
+
+func Test_TwoPhaseSetRemoveWins(t *testing.T) {
+	a := NewTwoPhaseSet("x")
+	b := NewTwoPhaseSet("x")
+	b.Remove("x")
+	c := NewTwoPhaseSet[string]()
+
+	// c learns of the removal before the add.
+	c.Merge(b)
+	c.Merge(a)
+	a.Merge(b)
+	if a.ContainsOne("x") || c.ContainsOne("x") || c.Cardinality() != 0 {
+		t.Error("A removal should win over an add")
+	}
+}
+
+func Test_GSetSerialization(t *testing.T) {
+	s := NewGSet("a", "b")
+	b, err := json.Marshal(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := NewGSet("c")
+	if err := json.Unmarshal(b, got); err != nil {
+		t.Fatal(err)
+	}
+	if !got.ToSet().Equal(NewSet("a", "b", "c")) {
+		t.Errorf("UnmarshalJSON should merge the state, got %v", got)
+	}
+
+	bt, raw, err := bson.MarshalValue(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got = NewGSet[string]()
+	if err := bson.UnmarshalValue(bt, raw, got); err != nil {
+		t.Fatal(err)
+	}
+	if !got.ToSet().Equal(s.ToSet()) {
+		t.Errorf("Expected %v, got %v", s, got)
+	}
+}
+
+func Test_TwoPhaseSetSerialization(t *testing.T) {
+	s := NewTwoPhaseSet(1, 2, 3)
+	s.Remove(2)
+
+	b, err := json.Marshal(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := NewTwoPhaseSet(2, 4)
+	if err := json.Unmarshal(b, got); err != nil {
+		t.Fatal(err)
+	}
+	if !got.ToSet().Equal(NewSet(1, 3, 4)) || !got.Removed(2) {
+		t.Errorf("UnmarshalJSON should merge the state, got %v", got)
+	}
+
+	bt, raw, err := bson.MarshalValue(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got = NewTwoPhaseSet[int]()
+	if err := bson.UnmarshalValue(bt, raw, got); err != nil {
+		t.Fatal(err)
+	}
+	if !twoPhaseSetEqual(got, s) {
+		t.Errorf("Expected %v, got %v", s, got)
+	}
+	if err := got.UnmarshalBSONValue(bson.TypeArray, raw); err == nil {
+		t.Error("Expected an error decoding an array")
+	}
+}
@@ -0,0 +1,421 @@
+/*
+Open Source Initiative OSI - The MIT License (MIT):Licensing
+
+The MIT License (MIT)
+Copyright (c) 2013 - 2026 Ralph Caraveo (deckarep@gmail.com)
+
+Permission is hereby granted, free of charge, to any person obtaining a copy of
+this software and associated documentation files (the "Software"), to deal in
+the Software without restriction, including without limitation the rights to
+use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies
+of the Software, and to permit persons to whom the Software is furnished to do
+so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+
+package mapset
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math/rand"
+	"sync"
+)
+
+// ErrFilterFull is returned when an element cannot be added to a filter
+// because it has run out of space.
+var ErrFilterFull = errors.New("mapset: filter is full")
+
+// CuckooFilter is an approximate set that, unlike a BloomFilter, supports
+// removal. MayContain never reports a false negative for an element that
+// was added and not removed, and reports false positives at a rate of
+// about 0.01%.
+//
+// Each Add stores a fingerprint, so adding an element twice requires
+// removing it twice. Only elements that were added may be removed;
+// removing other elements may remove a colliding element's fingerprint
+// and introduce false negatives.
+type CuckooFilter[T comparable] interface {
+	// Add adds an element to the filter. Once the filter has run out
+	// of room, Add returns ErrFilterFull and the element is not added.
+	Add(val T) error
+
+	// MayContain returns whether the element may be in the filter. A
+	// result of false is definitive.
+	MayContain(val T) bool
+
+	// Remove removes one occurrence of an element from the filter.
+	// Returns whether a matching fingerprint was found.
+	Remove(val T) bool
+
+	// Count returns the number of fingerprints stored in the filter.
+	Count() int
+
+	// Capacity returns the number of fingerprint slots in the filter.
+	Capacity() int
+
+	// LoadFactor returns the fraction of fingerprint slots in use.
+	// Insertions typically start failing above 0.95.
+	LoadFactor() float64
+
+	// Clear removes all elements from the filter.
+	Clear()
+
+	// MarshalBinary encodes the filter. The Hasher is not encoded; the
+	// filter must be decoded with the same Hasher it was built with.
+	MarshalBinary() ([]byte, error)
+
+	// UnmarshalBinary decodes a filter encoded by MarshalBinary,
+	// replacing the contents of the filter.
+	UnmarshalBinary(b []byte) error
+}
+
+// NewCuckooFilter creates and returns a cuckoo filter with room for about
+// capacity elements. If hasher is nil, DefaultHasher is used. Operations
+// on the resulting filter are thread-safe.
+func NewCuckooFilter[T comparable](capacity uint, hasher Hasher[T]) CuckooFilter[T] {
+	return &threadSafeCuckooFilter[T]{
+		ucf: newThreadUnsafeCuckooFilter(capacity, hasher),
+	}
+}
+
+// NewThreadUnsafeCuckooFilter creates and returns a cuckoo filter with
+// room for about capacity elements. If hasher is nil, DefaultHasher is
+// used. Operations on the resulting filter are not thread-safe.
+func NewThreadUnsafeCuckooFilter[T comparable](capacity uint, hasher Hasher[T]) CuckooFilter[T] {
+	return newThreadUnsafeCuckooFilter(capacity, hasher)
+}
+
+const (
+	cuckooBucketSize = 4
+	cuckooMaxKicks   = 500
+	cuckooMagic      = "MSCF"
+	cuckooVersion    = 1
+)
+
+type cuckooBucket [cuckooBucketSize]uint16
+
+// cuckooVictim holds a fingerprint that could not be placed after the
+// maximum number of kicks. Keeping it avoids losing an element that was
+// already in the filter; while it is occupied the filter is full.
+type cuckooVictim struct {
+	used  bool
+	index uint64
+	fp    uint16
+}
+
+type threadUnsafeCuckooFilter[T comparable] struct {
+	buckets []cuckooBucket
+	mask    uint64
+	count   int
+	victim  cuckooVictim
+	hasher  Hasher[T]
+}
+
+// Assert concrete type:threadUnsafeCuckooFilter adheres to CuckooFilter interface.
+var _ CuckooFilter[string] = (*threadUnsafeCuckooFilter[string])(nil)
+
+func newThreadUnsafeCuckooFilter[T comparable](capacity uint, hasher Hasher[T]) *threadUnsafeCuckooFilter[T] {
+	n := uint64(1)
+	for n*cuckooBucketSize < uint64(capacity) {
+		n <<= 1
+	}
+	// Insertions start failing at around 95% occupancy.
+	if float64(capacity)/float64(n*cuckooBucketSize) > 0.95 {
+		n <<= 1
+	}
+	return &threadUnsafeCuckooFilter[T]{
+		buckets: make([]cuckooBucket, n),
+		mask:    n - 1,
+		hasher:  hasherOrDefault(hasher),
+	}
+}
+
+// fingerprint returns the fingerprint and primary bucket index of val.
+// Fingerprints are never zero, which marks an empty slot.
+func (f *threadUnsafeCuckooFilter[T]) fingerprint(val T) (uint16, uint64) {
+	h := f.hasher(val)
+	fp := uint16(h >> 48)
+	if fp == 0 {
+		fp = 1
+	}
+	return fp, h & f.mask
+}
+
+// altIndex returns the other bucket a fingerprint in bucket i may occupy.
+func (f *threadUnsafeCuckooFilter[T]) altIndex(i uint64, fp uint16) uint64 {
+	return (i ^ mix64(uint64(fp))) & f.mask
+}
+
+func (b *cuckooBucket) insert(fp uint16) bool {
+	for i, slot := range b {
+		if slot == 0 {
+			b[i] = fp
+			return true
+		}
+	}
+	return false
+}
+
+func (b *cuckooBucket) remove(fp uint16) bool {
+	for i, slot := range b {
+		if slot == fp {
+			b[i] = 0
+			return true
+		}
+	}
+	return false
+}
+
+func (b *cuckooBucket) contains(fp uint16) bool {
+	for _, slot := range b {
+		if slot == fp {
+			return true
+		}
+	}
+	return false
+}
+
+func (f *threadUnsafeCuckooFilter[T]) Add(val T) error {
+	if f.victim.used {
+		return ErrFilterFull
+	}
+
+	fp, i1 := f.fingerprint(val)
+	i2 := f.altIndex(i1, fp)
+	if f.buckets[i1].insert(fp) || f.buckets[i2].insert(fp) {
+		f.count++
+		return nil
+	}
+
+	// Both buckets are full: evict fingerprints to their alternate
+	// buckets until one finds room.
+	i := i1
+	if rand.Intn(2) == 1 {
+		i = i2
+	}
+	for n := 0; n < cuckooMaxKicks; n++ {
+		slot := rand.Intn(cuckooBucketSize)
+		fp, f.buckets[i][slot] = f.buckets[i][slot], fp
+		i = f.altIndex(i, fp)
+		if f.buckets[i].insert(fp) {
+			f.count++
+			return nil
+		}
+	}
+
+	// The new element is in the filter but a previously stored
+	// fingerprint is homeless; keep it aside so it is not lost.
+	f.victim = cuckooVictim{used: true, index: i, fp: fp}
+	f.count++
+	return nil
+}
+
+func (f *threadUnsafeCuckooFilter[T]) MayContain(val T) bool {
+	fp, i1 := f.fingerprint(val)
+	i2 := f.altIndex(i1, fp)
+	if f.victim.used && f.victim.fp == fp && (f.victim.index == i1 || f.victim.index == i2) {
+		return true
+	}
+	return f.buckets[i1].contains(fp) || f.buckets[i2].contains(fp)
+}
+
+func (f *threadUnsafeCuckooFilter[T]) Remove(val T) bool {
+	fp, i1 := f.fingerprint(val)
+	i2 := f.altIndex(i1, fp)
+
+	if f.victim.used && f.victim.fp == fp && (f.victim.index == i1 || f.victim.index == i2) {
+		f.victim = cuckooVictim{}
+		f.count--
+		return true
+	}
+	if !f.buckets[i1].remove(fp) && !f.buckets[i2].remove(fp) {
+		return false
+	}
+	f.count--
+
+	// A slot has been freed, so the victim may now fit.
+	if f.victim.used {
+		v := f.victim
+		f.victim = cuckooVictim{}
+		f.reinsert(v.fp, v.index)
+	}
+	return true
+}
+
+// reinsert places a fingerprint already counted in the filter that belongs
+// in bucket i or its alternate, kicking other fingerprints as Add does.
+func (f *threadUnsafeCuckooFilter[T]) reinsert(fp uint16, i uint64) {
+	for n := 0; n < cuckooMaxKicks; n++ {
+		if f.buckets[i].insert(fp) {
+			return
+		}
+		if alt := f.altIndex(i, fp); f.buckets[alt].insert(fp) {
+			return
+		}
+		slot := rand.Intn(cuckooBucketSize)
+		fp, f.buckets[i][slot] = f.buckets[i][slot], fp
+		i = f.altIndex(i, fp)
+	}
+	f.victim = cuckooVictim{used: true, index: i, fp: fp}
+}
+
+func (f *threadUnsafeCuckooFilter[T]) Count() int {
+	return f.count
+}
+
+func (f *threadUnsafeCuckooFilter[T]) Capacity() int {
+	return len(f.buckets) * cuckooBucketSize
+}
+
+func (f *threadUnsafeCuckooFilter[T]) LoadFactor() float64 {
+	stored := f.count
+	if f.victim.used {
+		stored--
+	}
+	return float64(stored) / float64(f.Capacity())
+}
+
+func (f *threadUnsafeCuckooFilter[T]) Clear() {
+	for i := range f.buckets {
+		f.buckets[i] = cuckooBucket{}
+	}
+	f.count = 0
+	f.victim = cuckooVictim{}
+}
+
+func (f *threadUnsafeCuckooFilter[T]) MarshalBinary() ([]byte, error) {
+	b := make([]byte, 0, 32+2*cuckooBucketSize*len(f.buckets))
+	b = append(b, cuckooMagic...)
+	b = append(b, cuckooVersion)
+	b = appendUint64(b, uint64(len(f.buckets)))
+	b = appendUint64(b, uint64(f.count))
+	if f.victim.used {
+		b = append(b, 1)
+	} else {
+		b = append(b, 0)
+	}
+	b = appendUint64(b, f.victim.index)
+	b = appendUint32(b, uint32(f.victim.fp))
+	for _, bucket := range f.buckets {
+		for _, fp := range bucket {
+			b = append(b, byte(fp), byte(fp>>8))
+		}
+	}
+	return b, nil
+}
+
+func (f *threadUnsafeCuckooFilter[T]) UnmarshalBinary(b []byte) error {
+	const headerLen = 4 + 1 + 8 + 8 + 1 + 8 + 4
+	if len(b) < headerLen || string(b[:4]) != cuckooMagic {
+		return errors.New("mapset: invalid cuckoo filter encoding")
+	}
+	if b[4] != cuckooVersion {
+		return fmt.Errorf("mapset: unsupported cuckoo filter version %d", b[4])
+	}
+	n := binary.LittleEndian.Uint64(b[5:])
+	count := binary.LittleEndian.Uint64(b[13:])
+	victim := cuckooVictim{
+		used:  b[21] == 1,
+		index: binary.LittleEndian.Uint64(b[22:]),
+		fp:    uint16(binary.LittleEndian.Uint32(b[30:])),
+	}
+	b = b[headerLen:]
+	// Bound n by the body before multiplying, so that the product
+	// cannot overflow.
+	if n == 0 || n&(n-1) != 0 || n > uint64(len(b))/(2*cuckooBucketSize) ||
+		uint64(len(b)) != n*2*cuckooBucketSize || victim.index >= n {
+		return errors.New("mapset: invalid cuckoo filter encoding")
+	}
+
+	buckets := make([]cuckooBucket, n)
+	for i := range buckets {
+		for j := range buckets[i] {
+			buckets[i][j] = binary.LittleEndian.Uint16(b)
+			b = b[2:]
+		}
+	}
+	f.buckets, f.mask, f.count, f.victim = buckets, n-1, int(count), victim
+	f.hasher = hasherOrDefault(f.hasher)
+	return nil
+}
+
+type threadSafeCuckooFilter[T comparable] struct {
+	sync.RWMutex
+	ucf *threadUnsafeCuckooFilter[T]
+}
+
+// Assert concrete type:threadSafeCuckooFilter adheres to CuckooFilter interface.
+var _ CuckooFilter[string] = (*threadSafeCuckooFilter[string])(nil)
+
+func (t *threadSafeCuckooFilter[T]) Add(v T) error {
+	t.Lock()
+	err := t.ucf.Add(v)
+	t.Unlock()
+	return err
+}
+
+func (t *threadSafeCuckooFilter[T]) MayContain(v T) bool {
+	t.RLock()
+	ret := t.ucf.MayContain(v)
+	t.RUnlock()
+	return ret
+}
+
+func (t *threadSafeCuckooFilter[T]) Remove(v T) bool {
+	t.Lock()
+	ret := t.ucf.Remove(v)
+	t.Unlock()
+	return ret
+}
+
+func (t *threadSafeCuckooFilter[T]) Count() int {
+	t.RLock()
+	defer t.RUnlock()
+	return t.ucf.Count()
+}
+
+func (t *threadSafeCuckooFilter[T]) Capacity() int {
+	t.RLock()
+	defer t.RUnlock()
+	return t.ucf.Capacity()
+}
+
+func (t *threadSafeCuckooFilter[T]) LoadFactor() float64 {
+	t.RLock()
+	defer t.RUnlock()
+	return t.ucf.LoadFactor()
+}
+
+func (t *threadSafeCuckooFilter[T]) Clear() {
+	t.Lock()
+	t.ucf.Clear()
+	t.Unlock()
+}
+
+func (t *threadSafeCuckooFilter[T]) MarshalBinary() ([]byte, error) {
+	t.RLock()
+	b, err := t.ucf.MarshalBinary()
+	t.RUnlock()
+
+	return b, err
+}
+
+func (t *threadSafeCuckooFilter[T]) UnmarshalBinary(p []byte) error {
+	t.Lock()
+	err := t.ucf.UnmarshalBinary(p)
+	t.Unlock()
+
+	return err
+}
@@ -0,0 +1,181 @@
+/*
+Open Source Initiative OSI - The MIT License (MIT):Licensing
+
+The MIT License (MIT)
+Copyright (c) 2013 - 2026 Ralph Caraveo (deckarep@gmail.com)
+
+Permission is hereby granted, free of charge, to any person obtaining a copy of
+this software and associated documentation files (the "Software"), to deal in
+the Software without restriction, including without limitation the rights to
+use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies
+of the Software, and to permit persons to whom the Software is furnished to do
+so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+
+package mapset
+
+import (
+	"encoding/binary"
+	"errors"
+	"sync"
+	"testing"
+)
+
+func testCuckooFilter(t *testing.T, test func(t *testing.T, ctor func(capacity uint, hasher Hasher[int]) CuckooFilter[int])) {
+	t.Run("Safe", func(t *testing.T) {
+		test(t, NewCuckooFilter[int])
+	})
+	t.Run("Unsafe", func(t *testing.T) {
+		test(t, NewThreadUnsafeCuckooFilter[int])
+	})
+}
+
+func Test_CuckooFilterAddRemove(t *testing.T) {
+	testCuckooFilter(t, func(t *testing.T, ctor func(uint, Hasher[int]) CuckooFilter[int]) {
+		f := ctor(1000, nil)
+		for i := 0; i < 1000; i++ {
+			if err := f.Add(i); err != nil {
+				t.Fatalf("Error should be nil: %v", err)
+			}
+		}
+		for i := 0; i < 1000; i++ {
+			if !f.MayContain(i) {
+				t.Fatalf("False negative for %d", i)
+			}
+		}
+		if f.Count() != 1000 {
+			t.Errorf("Expected count 1000, got %d", f.Count())
+		}
+
+		for i := 0; i < 500; i++ {
+			if !f.Remove(i) {
+				t.Errorf("Remove(%d) should find the element", i)
+			}
+		}
+		for i := 500; i < 1000; i++ {
+			if !f.MayContain(i) {
+				t.Fatalf("False negative for %d after removals", i)
+			}
+		}
+		if f.Count() != 500 {
+			t.Errorf("Expected count 500, got %d", f.Count())
+		}
+
+		var fp int
+		for i := 0; i < 500; i++ {
+			if f.MayContain(i) {
+				fp++
+			}
+		}
+		if fp > 5 {
+			t.Errorf("Too many removed elements still reported: %d", fp)
+		}
+	})
+}
+
+func Test_CuckooFilterFull(t *testing.T) {
+	testCuckooFilter(t, func(t *testing.T, ctor func(uint, Hasher[int]) CuckooFilter[int]) {
+		f := ctor(64, nil)
+
+		var added []int
+		var err error
+		for i := 0; i < 1000; i++ {
+			if err = f.Add(i); err != nil {
+				break
+			}
+			added = append(added, i)
+		}
+		if !errors.Is(err, ErrFilterFull) {
+			t.Fatalf("Expected ErrFilterFull, got %v", err)
+		}
+		if lf := f.LoadFactor(); lf < 0.8 || lf > 1 {
+			t.Errorf("Expected a high load factor when full, got %.2f", lf)
+		}
+		for _, v := range added {
+			if !f.MayContain(v) {
+				t.Fatalf("False negative for %d in a full filter", v)
+			}
+		}
+
+		f.Remove(added[0])
+		if err := f.Add(-1); err != nil {
+			t.Errorf("Add should succeed after a removal, got %v", err)
+		}
+		for _, v := range added[1:] {
+			if !f.MayContain(v) {
+				t.Fatalf("False negative for %d after a removal", v)
+			}
+		}
+	})
+}
+
+func Test_CuckooFilterMarshalBinary(t *testing.T) {
+	testCuckooFilter(t, func(t *testing.T, ctor func(uint, Hasher[int]) CuckooFilter[int]) {
+		f := ctor(100, nil)
+		for i := 0; i < 50; i++ {
+			f.Add(i)
+		}
+
+		b, err := f.MarshalBinary()
+		if err != nil {
+			t.Fatalf("Error should be nil: %v", err)
+		}
+
+		g := ctor(1, nil)
+		if err := g.UnmarshalBinary(b); err != nil {
+			t.Fatalf("Error should be nil: %v", err)
+		}
+		if g.Count() != 50 || g.Capacity() != f.Capacity() {
+			t.Errorf("Expected count 50 and capacity %d, got %d and %d", f.Capacity(), g.Count(), g.Capacity())
+		}
+		for i := 0; i < 50; i++ {
+			if !g.MayContain(i) {
+				t.Errorf("Decoded filter should contain %d", i)
+			}
+		}
+
+		if err := g.UnmarshalBinary(b[:10]); err == nil {
+			t.Error("Decoding a truncated filter should fail")
+		}
+
+		// A bucket count whose size in bytes overflows must not be
+		// mistaken for an empty body.
+		huge := append([]byte(nil), b[:34]...)
+		binary.LittleEndian.PutUint64(huge[5:], 1<<62)
+		if err := g.UnmarshalBinary(huge); err == nil {
+			t.Error("Decoding a filter with an overflowing bucket count should fail")
+		}
+	})
+}
+
+func Test_CuckooFilterConcurrent(t *testing.T) {
+	f := NewCuckooFilter[int](10000, nil)
+
+	var wg sync.WaitGroup
+	for g := 0; g < 4; g++ {
+		wg.Add(1)
+		go func(g int) {
+			defer wg.Done()
+			for i := g * 1000; i < (g+1)*1000; i++ {
+				f.Add(i)
+				f.MayContain(i)
+			}
+		}(g)
+	}
+	wg.Wait()
+
+	if f.Count() != 4000 {
+		t.Errorf("Expected count 4000, got %d", f.Count())
+	}
+}
@@ -0,0 +1,218 @@
+/*
+Open Source Initiative OSI - The MIT License (MIT):Licensing
+
+The MIT License (MIT)
+Copyright (c) 2013 - 2026 Ralph Caraveo (deckarep@gmail.com)
+
+Permission is hereby granted, free of charge, to any person obtaining a copy of
+this software and associated documentation files (the "Software"), to deal in
+the Software without restriction, including without limitation the rights to
+use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies
+of the Software, and to permit persons to whom the Software is furnished to do
+so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+
+package mapset
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/bsontype"
+)
+
+// Delta is the change between two versions of a set: the elements Added
+// to and Removed from the old version to obtain the new one. A nil Added
+// or Removed set is treated as empty, so the zero Delta is a no-op.
+//
+// Deltas produced by this package never hold an element in both Added and
+// Removed.
+type Delta[T comparable] struct {
+	Added   Set[T]
+	Removed Set[T]
+}
+
+// deltaSet returns s as a threadUnsafeSet, treating nil as empty.
+func deltaSet[T comparable](s Set[T]) *threadUnsafeSet[T] {
+	if s == nil {
+		return newThreadUnsafeSet[T]()
+	}
+	return toThreadUnsafeSet(s)
+}
+
+func newDelta[T comparable](added, removed *threadUnsafeSet[T]) Delta[T] {
+	return Delta[T]{
+		Added:   &threadSafeSet[T]{uss: added},
+		Removed: &threadSafeSet[T]{uss: removed},
+	}
+}
+
+// Diff returns the delta that turns old into new. The sets may be of any
+// Set implementation; the delta holds thread-safe sets.
+func Diff[T comparable](old, new Set[T]) Delta[T] {
+	o, n := deltaSet(old), deltaSet(new)
+	return newDelta(
+		n.Difference(o).(*threadUnsafeSet[T]),
+		o.Difference(n).(*threadUnsafeSet[T]),
+	)
+}
+
+// Apply modifies s by removing the elements removed by d and adding the
+// elements added by d.
+func Apply[T comparable](s Set[T], d Delta[T]) {
+	if d.Removed != nil {
+		s.RemoveAll(d.Removed.ToSlice()...)
+	}
+	if d.Added != nil {
+		s.Append(d.Added.ToSlice()...)
+	}
+}
+
+// Invert returns the delta that undoes d: applying d and then Invert(d)
+// to a set restores it, provided d was computed against that set.
+func Invert[T comparable](d Delta[T]) Delta[T] {
+	return newDelta(
+		deltaSet(d.Removed).Clone().(*threadUnsafeSet[T]),
+		deltaSet(d.Added).Clone().(*threadUnsafeSet[T]),
+	)
+}
+
+// Compose returns a single delta equivalent to applying first and then
+// second. Changes made by first and undone by second cancel out.
+func Compose[T comparable](first, second Delta[T]) Delta[T] {
+	a1, r1 := deltaSet(first.Added), deltaSet(first.Removed)
+	a2, r2 := deltaSet(second.Added), deltaSet(second.Removed)
+
+	added := a1.Difference(r2).(*threadUnsafeSet[T])
+	for elem := range *a2 {
+		if !r1.contains(elem) {
+			added.add(elem)
+		}
+	}
+	removed := r1.Difference(a2).(*threadUnsafeSet[T])
+	for elem := range *r2 {
+		if !a1.contains(elem) {
+			removed.add(elem)
+		}
+	}
+	return newDelta(added, removed)
+}
+
+// IsEmpty returns whether the delta adds or removes no elements.
+func (d Delta[T]) IsEmpty() bool {
+	return (d.Added == nil || d.Added.IsEmpty()) && (d.Removed == nil || d.Removed.IsEmpty())
+}
+
+// sortedStrings returns the string forms of the elements of s, sorted so
+// that output is deterministic.
+func sortedStrings[T comparable](s Set[T]) []string {
+	if s == nil {
+		return nil
+	}
+	items := make([]string, 0, s.Cardinality())
+	s.Each(func(v T) bool {
+		items = append(items, fmt.Sprintf("%v", v))
+		return false
+	})
+	sort.Strings(items)
+	return items
+}
+
+// String provides a compact representation of the delta, with elements in
+// sorted order.
+func (d Delta[T]) String() string {
+	return fmt.Sprintf("Delta{+[%s], -[%s]}",
+		strings.Join(sortedStrings(d.Added), ", "),
+		strings.Join(sortedStrings(d.Removed), ", "))
+}
+
+// Report returns a human-readable, line-oriented description of the
+// delta, suitable for test failure messages. Elements are listed in
+// sorted order, added elements prefixed by "+" and removed elements by
+// "-".
+func (d Delta[T]) Report() string {
+	added, removed := sortedStrings(d.Added), sortedStrings(d.Removed)
+	if len(added) == 0 && len(removed) == 0 {
+		return "no changes"
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "%d added, %d removed", len(added), len(removed))
+	for _, item := range added {
+		b.WriteString("\n+ " + item)
+	}
+	for _, item := range removed {
+		b.WriteString("\n- " + item)
+	}
+	return b.String()
+}
+
+type deltaElements[T comparable] struct {
+	Added   []T `json:"added" bson:"added"`
+	Removed []T `json:"removed" bson:"removed"`
+}
+
+func (d Delta[T]) elements() deltaElements[T] {
+	return deltaElements[T]{
+		Added:   deltaSet(d.Added).ToSlice(),
+		Removed: deltaSet(d.Removed).ToSlice(),
+	}
+}
+
+func (d *Delta[T]) setElements(e deltaElements[T]) {
+	added, removed := newThreadUnsafeSet[T](), newThreadUnsafeSet[T]()
+	added.append(e.Added...)
+	removed.append(e.Removed...)
+	*d = newDelta(added, removed)
+}
+
+// MarshalJSON creates a JSON object with "added" and "removed" arrays.
+func (d Delta[T]) MarshalJSON() ([]byte, error) {
+	return json.Marshal(d.elements())
+}
+
+// UnmarshalJSON replaces the delta with one decoded from a JSON object
+// created by MarshalJSON.
+func (d *Delta[T]) UnmarshalJSON(b []byte) error {
+	var e deltaElements[T]
+	if err := json.Unmarshal(b, &e); err != nil {
+		return err
+	}
+	d.setElements(e)
+	return nil
+}
+
+// MarshalBSONValue creates a BSON document with "added" and "removed"
+// arrays.
+func (d Delta[T]) MarshalBSONValue() (bsontype.Type, []byte, error) {
+	return bson.MarshalValue(d.elements())
+}
+
+// UnmarshalBSONValue replaces the delta with one decoded from a BSON
+// document created by MarshalBSONValue.
+func (d *Delta[T]) UnmarshalBSONValue(bt bsontype.Type, b []byte) error {
+	if bt != bson.TypeEmbeddedDocument {
+		return fmt.Errorf("must use BSON Document to unmarshal Delta")
+	}
+
+	var e deltaElements[T]
+	if err := bson.UnmarshalValue(bt, b, &e); err != nil {
+		return err
+	}
+	d.setElements(e)
+	return nil
+}
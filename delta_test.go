@@ -0,0 +1,164 @@
+/*
+Open Source Initiative OSI - The MIT License (MIT):Licensing
+
+The MIT License (MIT)
+Copyright (c) 2013 - 2026 Ralph Caraveo (deckarep@gmail.com)
+
+Permission is hereby granted, free of charge, to any person obtaining a copy of
+this software and associated documentation files (the "Software"), to deal in
+the Software without restriction, including without limitation the rights to
+use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies
+of the Software, and to permit persons to whom the Software is furnished to do
+so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+
+package mapset
+
+import (
+	"encoding/json"
+	"testing"
+
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+func Test_DiffApply(t *testing.T) {
+	old := NewSet("a", "b", "c")
+	new := NewThreadUnsafeSet("b", "c", "d", "e")
+
+	d := Diff[string](old, new)
+	if !d.Added.Equal(NewSet("d", "e")) || !d.Removed.Equal(NewSet("a")) {
+		t.Fatalf("Unexpected delta %v", d)
+	}
+
+	Apply[string](old, d)
+	if !old.Equal(NewSet("b", "c", "d", "e")) {
+		t.Errorf("Apply should produce the new set, got %v", old)
+	}
+
+	if d := Diff[string](old, old); !d.IsEmpty() {
+		t.Errorf("Expected an empty delta, got %v", d)
+	}
+}
+
+func Test_DeltaZeroValue(t *testing.T) {
+	var d Delta[int]
+	if !d.IsEmpty() {
+		t.Error("The zero Delta should be empty")
+	}
+	s := NewSet(1, 2)
+	Apply(s, d)
+	if !s.Equal(NewSet(1, 2)) {
+		t.Error("Applying the zero Delta should not change the set")
+	}
+	if !Invert(d).IsEmpty() || !Compose(d, d).IsEmpty() {
+		t.Error("Operations on the zero Delta should produce empty deltas")
+	}
+	if d.String() != "Delta{+[], -[]}" || d.Report() != "no changes" {
+		t.Errorf("Unexpected representation %q", d.String())
+	}
+}
+
+func Test_DeltaInvert(t *testing.T) {
+	old := NewSet(1, 2, 3)
+	new := NewSet(2, 3, 4)
+	d := Diff(old, new)
+
+	s := old.Clone()
+	Apply(s, d)
+	Apply(s, Invert(d))
+	if !s.Equal(old) {
+		t.Errorf("Applying a delta and its inverse should restore the set, got %v", s)
+	}
+	if !Invert(Invert(d)).Added.Equal(d.Added) {
+		t.Error("Inverting twice should restore the delta")
+	}
+}
+
+func Test_DeltaCompose(t *testing.T) {
+	v1 := NewSet(1, 2, 3)
+	v2 := NewSet(2, 3, 4, 5)
+	v3 := NewSet(1, 3, 5, 6)
+
+	d := Compose(Diff(v1, v2), Diff(v2, v3))
+	want := Diff(v1, v3)
+	if !d.Added.Equal(want.Added) || !d.Removed.Equal(want.Removed) {
+		t.Errorf("Expected %v, got %v", want, d)
+	}
+
+	// 4 is added then removed, 1 removed then added: both cancel out.
+	if !d.Added.Equal(NewSet(5, 6)) || !d.Removed.Equal(NewSet(2)) {
+		t.Errorf("Expected canceling changes to be dropped, got %v", d)
+	}
+
+	if d := Compose(Diff(v1, v2), Invert(Diff(v1, v2))); !d.IsEmpty() {
+		t.Errorf("Composing a delta with its inverse should be empty, got %v", d)
+	}
+}
+
+func Test_DeltaReport(t *testing.T) {
+	d := Diff(NewSet("x", "b"), NewSet("b", "c", "a"))
+	want := "2 added, 1 removed\n+ a\n+ c\n- x"
+	if d.Report() != want {
+		t.Errorf("Expected report:\n%s\ngot:\n%s", want, d.Report())
+	}
+	if d.String() != "Delta{+[a, c], -[x]}" {
+		t.Errorf("Unexpected string %q", d.String())
+	}
+}
+
+func Test_DeltaJSON(t *testing.T) {
+	d := Diff(NewSet(1, 2), NewSet(2, 3))
+	b, err := json.Marshal(d)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(b) != `{"added":[3],"removed":[1]}` {
+		t.Errorf("Unexpected encoding %s", b)
+	}
+
+	var got Delta[int]
+	if err := json.Unmarshal(b, &got); err != nil {
+		t.Fatal(err)
+	}
+	if !got.Added.Equal(d.Added) || !got.Removed.Equal(d.Removed) {
+		t.Errorf("Expected %v, got %v", d, got)
+	}
+
+	if err := json.Unmarshal([]byte(`[1]`), &got); err == nil {
+		t.Error("Expected an error decoding an array")
+	}
+}
+
+func Test_DeltaBSON(t *testing.T) {
+	d := Diff(NewSet("a", "b"), NewSet("b", "c"))
+	bt, b, err := bson.MarshalValue(d)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bt != bson.TypeEmbeddedDocument {
+		t.Fatalf("Expected a BSON document, got %v", bt)
+	}
+
+	var got Delta[string]
+	if err := bson.UnmarshalValue(bt, b, &got); err != nil {
+		t.Fatal(err)
+	}
+	if !got.Added.Equal(d.Added) || !got.Removed.Equal(d.Removed) {
+		t.Errorf("Expected %v, got %v", d, got)
+	}
+
+	if err := got.UnmarshalBSONValue(bson.TypeArray, b); err == nil {
+		t.Error("Expected an error decoding an array")
+	}
+}
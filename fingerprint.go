@@ -0,0 +1,596 @@
+/*
+Open Source Initiative OSI - The MIT License (MIT):Licensing
+
+The MIT License (MIT)
+Copyright (c) 2013 - 2026 Ralph Caraveo (deckarep@gmail.com)
+
+Permission is hereby granted, free of charge, to any person obtaining a copy of
+this software and associated documentation files (the "Software"), to deal in
+the Software without restriction, including without limitation the rights to
+use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies
+of the Software, and to permit persons to whom the Software is furnished to do
+so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+
+package mapset
+
+import (
+	"sync"
+
+	"go.mongodb.org/mongo-driver/bson/bsontype"
+)
+
+// FingerprintedSet is a Set that maintains an order-independent hash of
+// its elements, updated incrementally as elements are added and removed.
+//
+// Equal sets always have equal fingerprints, and unequal sets almost always
+// have different ones, so Equal rejects most unequal sets in constant time
+// and the fingerprint can serve as a cache key for results derived from the
+// set's contents. Unlike the other Set implementations, the argument to its
+// methods may be any Set; the fast path applies when it is also a
+// FingerprintedSet.
+type FingerprintedSet[T comparable] interface {
+	Set[T]
+
+	// Fingerprint returns the sum of the hashes of the elements of
+	// the set, as computed by DefaultHasher.
+	Fingerprint() uint64
+}
+
+// NewFingerprintedSet creates and returns a new fingerprinted set with the
+// given elements. Operations on the resulting set are thread-safe.
+func NewFingerprintedSet[T comparable](vs ...T) FingerprintedSet[T] {
+	s := newThreadUnsafeFingerprintedSet[T](len(vs))
+	s.append(vs...)
+	return &threadSafeFingerprintedSet[T]{ufs: s}
+}
+
+// NewThreadUnsafeFingerprintedSet creates and returns a new fingerprinted
+// set with the given elements. Operations on the resulting set are not
+// thread-safe.
+func NewThreadUnsafeFingerprintedSet[T comparable](vs ...T) FingerprintedSet[T] {
+	s := newThreadUnsafeFingerprintedSet[T](len(vs))
+	s.append(vs...)
+	return s
+}
+
+type threadUnsafeFingerprintedSet[T comparable] struct {
+	uss    *threadUnsafeSet[T]
+	sum    uint64
+	hasher Hasher[T]
+}
+
+// Assert concrete type:threadUnsafeFingerprintedSet adheres to FingerprintedSet interface.
+var _ FingerprintedSet[string] = (*threadUnsafeFingerprintedSet[string])(nil)
+
+func newThreadUnsafeFingerprintedSet[T comparable](cardinality int) *threadUnsafeFingerprintedSet[T] {
+	return &threadUnsafeFingerprintedSet[T]{
+		uss:    newThreadUnsafeSetWithSize[T](cardinality),
+		hasher: DefaultHasher[T](),
+	}
+}
+
+// fromThreadUnsafeSet wraps uss, computing its fingerprint.
+func (s *threadUnsafeFingerprintedSet[T]) fromThreadUnsafeSet(uss *threadUnsafeSet[T]) *threadUnsafeFingerprintedSet[T] {
+	r := &threadUnsafeFingerprintedSet[T]{uss: uss, hasher: s.hasher}
+	for elem := range *uss {
+		r.sum += r.hasher(elem)
+	}
+	return r
+}
+
+// fingerprintOf returns the fingerprint of other if it maintains one.
+func fingerprintOf[T comparable](other Set[T]) (uint64, bool) {
+	if f, ok := other.(FingerprintedSet[T]); ok {
+		return f.Fingerprint(), true
+	}
+	return 0, false
+}
+
+// private version of Add which returns whether v was added
+func (s *threadUnsafeFingerprintedSet[T]) add(v T) bool {
+	if s.uss.contains(v) {
+		return false
+	}
+	s.uss.add(v)
+	s.sum += s.hasher(v)
+	return true
+}
+
+// private version of Append which doesn't return a value
+func (s *threadUnsafeFingerprintedSet[T]) append(vs ...T) {
+	for _, v := range vs {
+		s.add(v)
+	}
+}
+
+// private version of Remove which returns whether v was removed
+func (s *threadUnsafeFingerprintedSet[T]) remove(v T) bool {
+	if !s.uss.contains(v) {
+		return false
+	}
+	delete(*s.uss, v)
+	s.sum -= s.hasher(v)
+	return true
+}
+
+func (s *threadUnsafeFingerprintedSet[T]) Fingerprint() uint64 {
+	return s.sum
+}
+
+func (s *threadUnsafeFingerprintedSet[T]) Add(v T) bool {
+	return s.add(v)
+}
+
+func (s *threadUnsafeFingerprintedSet[T]) Append(vs ...T) int {
+	prevLen := s.uss.Cardinality()
+	s.append(vs...)
+	return s.uss.Cardinality() - prevLen
+}
+
+func (s *threadUnsafeFingerprintedSet[T]) AppendFrom(other Set[T]) int {
+	return s.Append(other.ToSlice()...)
+}
+
+func (s *threadUnsafeFingerprintedSet[T]) Cardinality() int {
+	return s.uss.Cardinality()
+}
+
+func (s *threadUnsafeFingerprintedSet[T]) Clear() {
+	s.uss.Clear()
+	s.sum = 0
+}
+
+func (s *threadUnsafeFingerprintedSet[T]) Clone() Set[T] {
+	return &threadUnsafeFingerprintedSet[T]{
+		uss:    s.uss.Clone().(*threadUnsafeSet[T]),
+		sum:    s.sum,
+		hasher: s.hasher,
+	}
+}
+
+func (s *threadUnsafeFingerprintedSet[T]) Contains(v ...T) bool {
+	return s.uss.Contains(v...)
+}
+
+func (s *threadUnsafeFingerprintedSet[T]) ContainsOne(v T) bool {
+	return s.uss.contains(v)
+}
+
+func (s *threadUnsafeFingerprintedSet[T]) ContainsAny(v ...T) bool {
+	return s.uss.ContainsAny(v...)
+}
+
+func (s *threadUnsafeFingerprintedSet[T]) ContainsAnyElement(other Set[T]) bool {
+	return s.uss.ContainsAnyElement(toThreadUnsafeSet(other))
+}
+
+func (s *threadUnsafeFingerprintedSet[T]) Difference(other Set[T]) Set[T] {
+	return s.fromThreadUnsafeSet(s.uss.Difference(toThreadUnsafeSet(other)).(*threadUnsafeSet[T]))
+}
+
+func (s *threadUnsafeFingerprintedSet[T]) Each(cb func(T) bool) {
+	s.uss.Each(cb)
+}
+
+// Equal compares fingerprints first when other is a FingerprintedSet,
+// falling back to comparing elements only if they match.
+func (s *threadUnsafeFingerprintedSet[T]) Equal(other Set[T]) bool {
+	if s.Cardinality() != other.Cardinality() {
+		return false
+	}
+	if fp, ok := fingerprintOf(other); ok && fp != s.sum {
+		return false
+	}
+	return s.uss.Equal(toThreadUnsafeSet(other))
+}
+
+func (s *threadUnsafeFingerprintedSet[T]) Filter(cb func(T) bool) Set[T] {
+	return s.fromThreadUnsafeSet(s.uss.Filter(cb).(*threadUnsafeSet[T]))
+}
+
+func (s *threadUnsafeFingerprintedSet[T]) Intersect(other Set[T]) Set[T] {
+	return s.fromThreadUnsafeSet(s.uss.Intersect(toThreadUnsafeSet(other)).(*threadUnsafeSet[T]))
+}
+
+func (s *threadUnsafeFingerprintedSet[T]) IsEmpty() bool {
+	return s.Cardinality() == 0
+}
+
+func (s *threadUnsafeFingerprintedSet[T]) IsProperSubset(other Set[T]) bool {
+	return s.uss.IsProperSubset(toThreadUnsafeSet(other))
+}
+
+func (s *threadUnsafeFingerprintedSet[T]) IsProperSuperset(other Set[T]) bool {
+	return toThreadUnsafeSet(other).IsProperSubset(s.uss)
+}
+
+func (s *threadUnsafeFingerprintedSet[T]) IsSubset(other Set[T]) bool {
+	return s.uss.IsSubset(toThreadUnsafeSet(other))
+}
+
+func (s *threadUnsafeFingerprintedSet[T]) IsSuperset(other Set[T]) bool {
+	return toThreadUnsafeSet(other).IsSubset(s.uss)
+}
+
+func (s *threadUnsafeFingerprintedSet[T]) Iter() <-chan T {
+	return s.uss.Iter()
+}
+
+func (s *threadUnsafeFingerprintedSet[T]) Iterator() *Iterator[T] {
+	return s.uss.Iterator()
+}
+
+func (s *threadUnsafeFingerprintedSet[T]) Pop() (v T, ok bool) {
+	v, ok = s.uss.Pop()
+	if ok {
+		s.sum -= s.hasher(v)
+	}
+	return v, ok
+}
+
+func (s *threadUnsafeFingerprintedSet[T]) PopN(n int) ([]T, int) {
+	items, count := s.uss.PopN(n)
+	for _, v := range items {
+		s.sum -= s.hasher(v)
+	}
+	return items, count
+}
+
+func (s *threadUnsafeFingerprintedSet[T]) Remove(v T) {
+	s.remove(v)
+}
+
+func (s *threadUnsafeFingerprintedSet[T]) RemoveAll(i ...T) {
+	for _, v := range i {
+		s.remove(v)
+	}
+}
+
+func (s *threadUnsafeFingerprintedSet[T]) String() string {
+	return s.uss.String()
+}
+
+func (s *threadUnsafeFingerprintedSet[T]) SymmetricDifference(other Set[T]) Set[T] {
+	return s.fromThreadUnsafeSet(s.uss.SymmetricDifference(toThreadUnsafeSet(other)).(*threadUnsafeSet[T]))
+}
+
+func (s *threadUnsafeFingerprintedSet[T]) ToSlice() []T {
+	return s.uss.ToSlice()
+}
+
+func (s *threadUnsafeFingerprintedSet[T]) Union(other Set[T]) Set[T] {
+	return s.fromThreadUnsafeSet(s.uss.Union(toThreadUnsafeSet(other)).(*threadUnsafeSet[T]))
+}
+
+func (s *threadUnsafeFingerprintedSet[T]) MarshalJSON() ([]byte, error) {
+	return s.uss.MarshalJSON()
+}
+
+func (s *threadUnsafeFingerprintedSet[T]) UnmarshalJSON(b []byte) error {
+	decoded := newThreadUnsafeSet[T]()
+	if err := decoded.UnmarshalJSON(b); err != nil {
+		return err
+	}
+	for elem := range *decoded {
+		s.add(elem)
+	}
+	return nil
+}
+
+func (s *threadUnsafeFingerprintedSet[T]) MarshalBSONValue() (bsontype.Type, []byte, error) {
+	return s.uss.MarshalBSONValue()
+}
+
+func (s *threadUnsafeFingerprintedSet[T]) UnmarshalBSONValue(bt bsontype.Type, b []byte) error {
+	decoded := newThreadUnsafeSet[T]()
+	if err := decoded.UnmarshalBSONValue(bt, b); err != nil {
+		return err
+	}
+	for elem := range *decoded {
+		s.add(elem)
+	}
+	return nil
+}
+
+type threadSafeFingerprintedSet[T comparable] struct {
+	sync.RWMutex
+	ufs *threadUnsafeFingerprintedSet[T]
+}
+
+// Assert concrete type:threadSafeFingerprintedSet adheres to FingerprintedSet interface.
+var _ FingerprintedSet[string] = (*threadSafeFingerprintedSet[string])(nil)
+
+// wrap returns a thread-safe set around a set produced from t.
+func (t *threadSafeFingerprintedSet[T]) wrap(s Set[T]) Set[T] {
+	return &threadSafeFingerprintedSet[T]{ufs: s.(*threadUnsafeFingerprintedSet[T])}
+}
+
+func (t *threadSafeFingerprintedSet[T]) Fingerprint() uint64 {
+	t.RLock()
+	defer t.RUnlock()
+	return t.ufs.sum
+}
+
+func (t *threadSafeFingerprintedSet[T]) Add(v T) bool {
+	t.Lock()
+	ret := t.ufs.Add(v)
+	t.Unlock()
+	return ret
+}
+
+func (t *threadSafeFingerprintedSet[T]) Append(v ...T) int {
+	t.Lock()
+	ret := t.ufs.Append(v...)
+	t.Unlock()
+	return ret
+}
+
+func (t *threadSafeFingerprintedSet[T]) AppendFrom(other Set[T]) int {
+	// Copy other before locking t, which may be other.
+	vs := other.ToSlice()
+
+	t.Lock()
+	defer t.Unlock()
+	return t.ufs.Append(vs...)
+}
+
+func (t *threadSafeFingerprintedSet[T]) Cardinality() int {
+	t.RLock()
+	defer t.RUnlock()
+	return t.ufs.Cardinality()
+}
+
+func (t *threadSafeFingerprintedSet[T]) Clear() {
+	t.Lock()
+	t.ufs.Clear()
+	t.Unlock()
+}
+
+func (t *threadSafeFingerprintedSet[T]) Clone() Set[T] {
+	t.RLock()
+	defer t.RUnlock()
+	return t.wrap(t.ufs.Clone())
+}
+
+func (t *threadSafeFingerprintedSet[T]) Contains(v ...T) bool {
+	t.RLock()
+	ret := t.ufs.Contains(v...)
+	t.RUnlock()
+
+	return ret
+}
+
+func (t *threadSafeFingerprintedSet[T]) ContainsOne(v T) bool {
+	t.RLock()
+	ret := t.ufs.ContainsOne(v)
+	t.RUnlock()
+
+	return ret
+}
+
+func (t *threadSafeFingerprintedSet[T]) ContainsAny(v ...T) bool {
+	t.RLock()
+	ret := t.ufs.ContainsAny(v...)
+	t.RUnlock()
+
+	return ret
+}
+
+func (t *threadSafeFingerprintedSet[T]) ContainsAnyElement(other Set[T]) bool {
+	o := toThreadUnsafeSet(other)
+
+	t.RLock()
+	defer t.RUnlock()
+	return t.ufs.ContainsAnyElement(o)
+}
+
+func (t *threadSafeFingerprintedSet[T]) Difference(other Set[T]) Set[T] {
+	o := toThreadUnsafeSet(other)
+
+	t.RLock()
+	defer t.RUnlock()
+	return t.wrap(t.ufs.Difference(o))
+}
+
+func (t *threadSafeFingerprintedSet[T]) Each(cb func(T) bool) {
+	t.RLock()
+	defer t.RUnlock()
+	t.ufs.Each(cb)
+}
+
+func (t *threadSafeFingerprintedSet[T]) Equal(other Set[T]) bool {
+	if other == Set[T](t) {
+		return true
+	}
+	fp, ok := fingerprintOf(other)
+	if ok && fp != t.Fingerprint() {
+		return false
+	}
+	o := toThreadUnsafeSet(other)
+
+	t.RLock()
+	defer t.RUnlock()
+	return t.ufs.Equal(o)
+}
+
+func (t *threadSafeFingerprintedSet[T]) Filter(cb func(T) bool) Set[T] {
+	t.RLock()
+	defer t.RUnlock()
+	return t.wrap(t.ufs.Filter(cb))
+}
+
+func (t *threadSafeFingerprintedSet[T]) Intersect(other Set[T]) Set[T] {
+	o := toThreadUnsafeSet(other)
+
+	t.RLock()
+	defer t.RUnlock()
+	return t.wrap(t.ufs.Intersect(o))
+}
+
+func (t *threadSafeFingerprintedSet[T]) IsEmpty() bool {
+	return t.Cardinality() == 0
+}
+
+func (t *threadSafeFingerprintedSet[T]) IsProperSubset(other Set[T]) bool {
+	o := toThreadUnsafeSet(other)
+
+	t.RLock()
+	defer t.RUnlock()
+	return t.ufs.IsProperSubset(o)
+}
+
+func (t *threadSafeFingerprintedSet[T]) IsProperSuperset(other Set[T]) bool {
+	o := toThreadUnsafeSet(other)
+
+	t.RLock()
+	defer t.RUnlock()
+	return t.ufs.IsProperSuperset(o)
+}
+
+func (t *threadSafeFingerprintedSet[T]) IsSubset(other Set[T]) bool {
+	o := toThreadUnsafeSet(other)
+
+	t.RLock()
+	defer t.RUnlock()
+	return t.ufs.IsSubset(o)
+}
+
+func (t *threadSafeFingerprintedSet[T]) IsSuperset(other Set[T]) bool {
+	o := toThreadUnsafeSet(other)
+
+	t.RLock()
+	defer t.RUnlock()
+	return t.ufs.IsSuperset(o)
+}
+
+func (t *threadSafeFingerprintedSet[T]) Iter() <-chan T {
+	ch := make(chan T)
+	go func() {
+		t.RLock()
+
+		for elem := range *t.ufs.uss {
+			ch <- elem
+		}
+		close(ch)
+		t.RUnlock()
+	}()
+
+	return ch
+}
+
+func (t *threadSafeFingerprintedSet[T]) Iterator() *Iterator[T] {
+	iterator, ch, stopCh := newIterator[T]()
+
+	go func() {
+		t.RLock()
+	L:
+		for elem := range *t.ufs.uss {
+			select {
+			case <-stopCh:
+				break L
+			case ch <- elem:
+			}
+		}
+		close(ch)
+		t.RUnlock()
+	}()
+
+	return iterator
+}
+
+func (t *threadSafeFingerprintedSet[T]) Pop() (T, bool) {
+	t.Lock()
+	defer t.Unlock()
+	return t.ufs.Pop()
+}
+
+func (t *threadSafeFingerprintedSet[T]) PopN(n int) ([]T, int) {
+	t.Lock()
+	defer t.Unlock()
+	return t.ufs.PopN(n)
+}
+
+func (t *threadSafeFingerprintedSet[T]) Remove(v T) {
+	t.Lock()
+	t.ufs.Remove(v)
+	t.Unlock()
+}
+
+func (t *threadSafeFingerprintedSet[T]) RemoveAll(i ...T) {
+	t.Lock()
+	t.ufs.RemoveAll(i...)
+	t.Unlock()
+}
+
+func (t *threadSafeFingerprintedSet[T]) String() string {
+	t.RLock()
+	ret := t.ufs.String()
+	t.RUnlock()
+	return ret
+}
+
+func (t *threadSafeFingerprintedSet[T]) SymmetricDifference(other Set[T]) Set[T] {
+	o := toThreadUnsafeSet(other)
+
+	t.RLock()
+	defer t.RUnlock()
+	return t.wrap(t.ufs.SymmetricDifference(o))
+}
+
+func (t *threadSafeFingerprintedSet[T]) ToSlice() []T {
+	t.RLock()
+	defer t.RUnlock()
+	return t.ufs.ToSlice()
+}
+
+func (t *threadSafeFingerprintedSet[T]) Union(other Set[T]) Set[T] {
+	o := toThreadUnsafeSet(other)
+
+	t.RLock()
+	defer t.RUnlock()
+	return t.wrap(t.ufs.Union(o))
+}
+
+func (t *threadSafeFingerprintedSet[T]) MarshalJSON() ([]byte, error) {
+	t.RLock()
+	b, err := t.ufs.MarshalJSON()
+	t.RUnlock()
+
+	return b, err
+}
+
+func (t *threadSafeFingerprintedSet[T]) UnmarshalJSON(p []byte) error {
+	t.Lock()
+	err := t.ufs.UnmarshalJSON(p)
+	t.Unlock()
+
+	return err
+}
+
+func (t *threadSafeFingerprintedSet[T]) MarshalBSONValue() (bsontype.Type, []byte, error) {
+	t.RLock()
+	bt, b, err := t.ufs.MarshalBSONValue()
+	t.RUnlock()
+
+	return bt, b, err
+}
+
+func (t *threadSafeFingerprintedSet[T]) UnmarshalBSONValue(bt bsontype.Type, p []byte) error {
+	t.Lock()
+	err := t.ufs.UnmarshalBSONValue(bt, p)
+	t.Unlock()
+
+	return err
+}
@@ -0,0 +1,180 @@
+/*
+Open Source Initiative OSI - The MIT License (MIT):Licensing
+
+The MIT License (MIT)
+Copyright (c) 2013 - 2026 Ralph Caraveo (deckarep@gmail.com)
+
+Permission is hereby granted, free of charge, to any person obtaining a copy of
+this software and associated documentation files (the "Software"), to deal in
+the Software without restriction, including without limitation the rights to
+use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies
+of the Software, and to permit persons to whom the Software is furnished to do
+so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+
+package mapset
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func testFingerprintedSet(t *testing.T, test func(t *testing.T, newSet func(vs ...int) FingerprintedSet[int])) {
+	t.Run("Safe", func(t *testing.T) { test(t, NewFingerprintedSet[int]) })
+	t.Run("Unsafe", func(t *testing.T) { test(t, NewThreadUnsafeFingerprintedSet[int]) })
+}
+
+func Test_FingerprintOrderIndependent(t *testing.T) {
+	testFingerprintedSet(t, func(t *testing.T, newSet func(vs ...int) FingerprintedSet[int]) {
+		a := newSet(1, 2, 3, 4)
+		b := newSet(4, 3, 2, 1, 1, 2)
+		if a.Fingerprint() != b.Fingerprint() {
+			t.Error("Equal sets should have equal fingerprints")
+		}
+		if newSet().Fingerprint() != 0 {
+			t.Error("The empty set should have a zero fingerprint")
+		}
+		if a.Fingerprint() == newSet(1, 2, 3, 5).Fingerprint() {
+			t.Error("Unequal sets should have different fingerprints")
+		}
+	})
+}
+
+func Test_FingerprintIncremental(t *testing.T) {
+	testFingerprintedSet(t, func(t *testing.T, newSet func(vs ...int) FingerprintedSet[int]) {
+		s := newSet(1, 2, 3)
+		want := s.Fingerprint()
+
+		s.Add(4)
+		s.Add(4)
+		s.Remove(4)
+		s.Remove(5)
+		if s.Fingerprint() != want {
+			t.Error("Adding and removing an element should restore the fingerprint")
+		}
+
+		s.Append(7, 8, 9)
+		s.RemoveAll(7, 8, 9)
+		if s.Fingerprint() != want {
+			t.Error("Append and RemoveAll should restore the fingerprint")
+		}
+
+		v, _ := s.Pop()
+		s.Add(v)
+		if s.Fingerprint() != want {
+			t.Error("Pop should update the fingerprint")
+		}
+
+		vs, _ := s.PopN(2)
+		if s.Fingerprint() == want {
+			t.Error("PopN should update the fingerprint")
+		}
+		s.Append(vs...)
+		if s.Fingerprint() != want {
+			t.Error("PopN should update the fingerprint")
+		}
+
+		s.AppendFrom(NewSet(5, 6))
+		if s.Fingerprint() != newSet(1, 2, 3, 5, 6).Fingerprint() {
+			t.Error("AppendFrom should update the fingerprint")
+		}
+
+		s.Clear()
+		if s.Fingerprint() != 0 {
+			t.Error("Clear should reset the fingerprint")
+		}
+	})
+}
+
+func Test_FingerprintDerivedSets(t *testing.T) {
+	testFingerprintedSet(t, func(t *testing.T, newSet func(vs ...int) FingerprintedSet[int]) {
+		a := newSet(1, 2, 3, 4)
+		b := newSet(3, 4, 5)
+
+		tests := []struct {
+			name string
+			got  Set[int]
+			want FingerprintedSet[int]
+		}{
+			{"Union", a.Union(b), newSet(1, 2, 3, 4, 5)},
+			{"Intersect", a.Intersect(b), newSet(3, 4)},
+			{"Difference", a.Difference(b), newSet(1, 2)},
+			{"SymmetricDifference", a.SymmetricDifference(b), newSet(1, 2, 5)},
+			{"Filter", a.Filter(func(v int) bool { return v%2 == 0 }), newSet(2, 4)},
+			{"Clone", a.Clone(), a},
+		}
+		for _, tt := range tests {
+			got, ok := tt.got.(FingerprintedSet[int])
+			if !ok {
+				t.Errorf("%s should return a FingerprintedSet", tt.name)
+				continue
+			}
+			if got.Fingerprint() != tt.want.Fingerprint() || !got.Equal(tt.want) {
+				t.Errorf("%s: expected %v, got %v", tt.name, tt.want, got)
+			}
+		}
+	})
+}
+
+func Test_FingerprintEqual(t *testing.T) {
+	testFingerprintedSet(t, func(t *testing.T, newSet func(vs ...int) FingerprintedSet[int]) {
+		a := newSet(1, 2, 3)
+		if !a.Equal(a) || !a.Equal(newSet(3, 2, 1)) {
+			t.Error("Sets with the same elements should be equal")
+		}
+		if a.Equal(newSet(1, 2, 4)) || a.Equal(newSet(1, 2)) {
+			t.Error("Sets with different elements should not be equal")
+		}
+		if !a.Equal(NewSet(1, 2, 3)) || !a.Equal(NewThreadUnsafeSet(1, 2, 3)) {
+			t.Error("Equal should compare against any Set")
+		}
+		if a.Equal(NewSet(1, 2, 4)) {
+			t.Error("Equal should compare elements of sets without fingerprints")
+		}
+		if !a.IsSubset(NewSet(1, 2, 3, 4)) || !a.IsProperSuperset(NewSet(1)) {
+			t.Error("Subset relations should accept any Set")
+		}
+	})
+}
+
+func Test_FingerprintEqualFastReject(t *testing.T) {
+	a := NewThreadUnsafeFingerprintedSet(1, 2, 3)
+	b := NewThreadUnsafeFingerprintedSet(1, 2, 4).(*threadUnsafeFingerprintedSet[int])
+
+	// Corrupt b's elements without updating its fingerprint: Equal must
+	// then be decided by the fingerprint alone.
+	delete(*b.uss, 4)
+	b.uss.add(3)
+	if a.Equal(b) {
+		t.Error("Equal should reject sets with different fingerprints")
+	}
+}
+
+func Test_FingerprintUnmarshal(t *testing.T) {
+	testFingerprintedSet(t, func(t *testing.T, newSet func(vs ...int) FingerprintedSet[int]) {
+		a := newSet(1, 2, 3)
+		b, err := json.Marshal(a)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		c := newSet()
+		if err := json.Unmarshal(b, c); err != nil {
+			t.Fatal(err)
+		}
+		if c.Fingerprint() != a.Fingerprint() {
+			t.Error("UnmarshalJSON should update the fingerprint")
+		}
+	})
+}
@@ -0,0 +1,628 @@
+/*
+Open Source Initiative OSI - The MIT License (MIT):Licensing
+
+The MIT License (MIT)
+Copyright (c) 2013 - 2026 Ralph Caraveo (deckarep@gmail.com)
+
+Permission is hereby granted, free of charge, to any person obtaining a copy of
+this software and associated documentation files (the "Software"), to deal in
+the Software without restriction, including without limitation the rights to
+use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies
+of the Software, and to permit persons to whom the Software is furnished to do
+so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+
+package mapset
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/bits"
+	"reflect"
+	"sort"
+	"strings"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/bsontype"
+)
+
+// FrozenSet is an immutable set built once by Freeze. Elements are stored
+// in a flat array indexed by a minimal perfect hash function, so lookups
+// take a single probe and the set uses less memory than a map. As it is
+// never modified, a FrozenSet is safe for concurrent use without locks.
+//
+// FrozenSet provides the read-only subset of the Set interface. Methods
+// that combine a FrozenSet with another set accept any Set implementation
+// and return a new thread-safe Set. The zero value is an empty set.
+//
+// FrozenSet is a comparable value type: two FrozenSets are == exactly when
+// they contain the same elements. This holds because frozen sets with equal
+// contents share a single interned table, so a FrozenSet can be an element
+// of another set or a map key, e.g. NewSet[FrozenSet[string]]().
+type FrozenSet[T comparable] struct {
+	t *frozenTable[T]
+}
+
+// frozenTable is the minimal perfect hash table behind a FrozenSet. The
+// element with hash h is found at slots[slot(h)]. Elements whose 64-bit
+// hashes collide with another element's cannot be separated by the hash
+// function and are kept in overflow instead.
+type frozenTable[T comparable] struct {
+	salt     uint64
+	disp     []uint32
+	slots    []T
+	overflow []T
+	hasher   Hasher[T]
+}
+
+const (
+	// frozenDirect marks a displacement that holds the slot of a bucket's
+	// only element rather than a seed.
+	frozenDirect = 1 << 31
+
+	frozenBucketSize = 4
+	frozenMaxSeeds   = 1 << 16
+	frozenMaxSalts   = 16
+	frozenMagic      = "MSFZ"
+	frozenVersion    = 1
+)
+
+// Freeze returns an immutable copy of s.
+func Freeze[T comparable](s Set[T]) FrozenSet[T] {
+	return freeze(s.ToSlice())
+}
+
+// NewFrozenSet returns an immutable set of the given elements.
+func NewFrozenSet[T comparable](vs ...T) FrozenSet[T] {
+	return Freeze(NewThreadUnsafeSet(vs...))
+}
+
+// freeze returns the frozen set of distinct elements vs, reusing the
+// interned table of an equal set if there is one.
+func freeze[T comparable](vs []T) FrozenSet[T] {
+	if len(vs) == 0 {
+		return FrozenSet[T]{}
+	}
+	key := newFrozenKey(vs)
+	if t := loadFrozenTable[T](key); t != nil && t.hasElements(vs) {
+		return FrozenSet[T]{t: t}
+	}
+	return FrozenSet[T]{t: internFrozenTable(key, newFrozenTable(vs, DefaultHasher[T]()))}
+}
+
+// frozenKey identifies the contents of an interned table. The digest is
+// the sum of two independent hashes of each element's canonical encoding,
+// so it does not depend on the order of elements. Equal keys are always
+// confirmed by comparing elements.
+type frozenKey struct {
+	typ    reflect.Type
+	n      int
+	digest [2]uint64
+}
+
+func newFrozenKey[T comparable](vs []T) frozenKey {
+	key := frozenKey{typ: reflect.TypeOf((*T)(nil)).Elem(), n: len(vs)}
+	var buf [64]byte
+	for _, v := range vs {
+		b := appendElement(buf[:0], v)
+		h := hashBytes(b)
+		key.digest[0] += h
+		key.digest[1] += mix64(h ^ hashBytes(append(b, 0xff)))
+	}
+	return key
+}
+
+// internFrozenTable returns the interned table equal to t, interning t if
+// there is none.
+func internFrozenTable[T comparable](key frozenKey, t *frozenTable[T]) *frozenTable[T] {
+	canonical := storeFrozenTable(key, t)
+	if canonical != t && !canonical.hasElements(t.elements()) {
+		// Distinct contents with equal digests; leave t uninterned.
+		return t
+	}
+	return canonical
+}
+
+// hasElements reports whether the table holds exactly the distinct
+// elements vs.
+func (t *frozenTable[T]) hasElements(vs []T) bool {
+	if len(t.slots)+len(t.overflow) != len(vs) {
+		return false
+	}
+	for _, v := range vs {
+		if !t.contains(v) {
+			return false
+		}
+	}
+	return true
+}
+
+func (t *frozenTable[T]) elements() []T {
+	vs := make([]T, 0, len(t.slots)+len(t.overflow))
+	vs = append(vs, t.slots...)
+	return append(vs, t.overflow...)
+}
+
+// fastrange maps x uniformly onto [0, n).
+func fastrange(x uint64, n int) int {
+	hi, _ := bits.Mul64(x, uint64(n))
+	return int(hi)
+}
+
+func frozenSeed(d uint32) uint64 {
+	return (uint64(d) + 1) * 0x9e3779b97f4a7c15
+}
+
+// newFrozenTable builds a table from distinct elements using hash and
+// displace: elements are grouped into small buckets, and buckets, largest
+// first, search for a seed that sends all their elements to free slots.
+// Buckets of a single element, which come last, take any free slot.
+func newFrozenTable[T comparable](elems []T, hasher Hasher[T]) *frozenTable[T] {
+	t := &frozenTable[T]{hasher: hasher}
+	if len(elems) == 0 {
+		return t
+	}
+
+	type keyed struct {
+		v T
+		h uint64
+	}
+	keys := make([]keyed, len(elems))
+	for i, v := range elems {
+		keys[i] = keyed{v, t.hasher(v)}
+	}
+	sort.Slice(keys, func(i, j int) bool { return keys[i].h < keys[j].h })
+
+	var unique []keyed
+	for i, k := range keys {
+		if i > 0 && k.h == keys[i-1].h {
+			t.overflow = append(t.overflow, k.v)
+			continue
+		}
+		unique = append(unique, k)
+	}
+
+	n := len(unique)
+	buckets := make([][]uint64, n/frozenBucketSize+1)
+	t.slots = make([]T, n)
+	t.disp = make([]uint32, len(buckets))
+	taken := make([]bool, n)
+	pending := make([]int, n)
+
+	for salt := uint64(0); salt < frozenMaxSalts; salt++ {
+		t.salt = mix64(salt)
+		for i := range buckets {
+			buckets[i] = buckets[i][:0]
+			t.disp[i] = 0
+		}
+		for i := range taken {
+			taken[i] = false
+		}
+		for _, k := range unique {
+			x := mix64(k.h ^ t.salt)
+			b := fastrange(x, len(buckets))
+			buckets[b] = append(buckets[b], x)
+		}
+		if t.place(buckets, taken, pending) {
+			for _, k := range unique {
+				t.slots[t.slot(k.h)] = k.v
+			}
+			return t
+		}
+	}
+	panic("mapset: failed to build a perfect hash for the frozen set")
+}
+
+// place assigns every bucket a displacement. It reports false if some
+// bucket found no seed, in which case the build is retried with a new
+// salt.
+func (t *frozenTable[T]) place(buckets [][]uint64, taken []bool, pending []int) bool {
+	order := make([]int, len(buckets))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(i, j int) bool {
+		return len(buckets[order[i]]) > len(buckets[order[j]])
+	})
+
+	free := 0
+	for _, b := range order {
+		xs := buckets[b]
+		switch len(xs) {
+		case 0:
+			continue
+		case 1:
+			for taken[free] {
+				free++
+			}
+			taken[free] = true
+			t.disp[b] = frozenDirect | uint32(free)
+			continue
+		}
+
+		placed := false
+	seeds:
+		for d := uint32(0); d < frozenMaxSeeds; d++ {
+			seed := frozenSeed(d)
+			for i, x := range xs {
+				s := fastrange(mix64(x^seed), len(taken))
+				if taken[s] {
+					for _, p := range pending[:i] {
+						taken[p] = false
+					}
+					continue seeds
+				}
+				taken[s] = true
+				pending[i] = s
+			}
+			t.disp[b] = d
+			placed = true
+			break
+		}
+		if !placed {
+			return false
+		}
+	}
+	return true
+}
+
+// slot returns the slot that the element with hash h would occupy.
+func (t *frozenTable[T]) slot(h uint64) int {
+	x := mix64(h ^ t.salt)
+	d := t.disp[fastrange(x, len(t.disp))]
+	if d&frozenDirect != 0 {
+		return int(d &^ frozenDirect)
+	}
+	return fastrange(mix64(x^frozenSeed(d)), len(t.slots))
+}
+
+func (t *frozenTable[T]) contains(v T) bool {
+	if len(t.slots) == 0 {
+		return false
+	}
+	if t.slots[t.slot(t.hasher(v))] == v {
+		return true
+	}
+	for _, o := range t.overflow {
+		if o == v {
+			return true
+		}
+	}
+	return false
+}
+
+func (t *frozenTable[T]) each(cb func(T) bool) {
+	for _, v := range t.slots {
+		if cb(v) {
+			return
+		}
+	}
+	for _, v := range t.overflow {
+		if cb(v) {
+			return
+		}
+	}
+}
+
+func (f FrozenSet[T]) table() *frozenTable[T] {
+	if f.t == nil {
+		return &frozenTable[T]{}
+	}
+	return f.t
+}
+
+// Cardinality returns the number of elements in the set.
+func (f FrozenSet[T]) Cardinality() int {
+	t := f.table()
+	return len(t.slots) + len(t.overflow)
+}
+
+// IsEmpty determines if there are elements in the set.
+func (f FrozenSet[T]) IsEmpty() bool {
+	return f.Cardinality() == 0
+}
+
+// ContainsOne returns whether the given item is in the set.
+func (f FrozenSet[T]) ContainsOne(val T) bool {
+	return f.table().contains(val)
+}
+
+// Contains returns whether the given items are all in the set.
+func (f FrozenSet[T]) Contains(val ...T) bool {
+	t := f.table()
+	for _, v := range val {
+		if !t.contains(v) {
+			return false
+		}
+	}
+	return true
+}
+
+// ContainsAny returns whether at least one of the given items
+// are in the set.
+func (f FrozenSet[T]) ContainsAny(val ...T) bool {
+	t := f.table()
+	for _, v := range val {
+		if t.contains(v) {
+			return true
+		}
+	}
+	return false
+}
+
+// ContainsAnyElement returns whether at least one of the elements of
+// other is in the set.
+func (f FrozenSet[T]) ContainsAnyElement(other Set[T]) bool {
+	t := f.table()
+	var found bool
+	other.Each(func(v T) bool {
+		found = t.contains(v)
+		return found
+	})
+	return found
+}
+
+// Each iterates over elements and executes the passed func against each
+// element. If passed func returns true, stop iteration at the time.
+func (f FrozenSet[T]) Each(cb func(T) bool) {
+	f.table().each(cb)
+}
+
+// Equal determines if the set and other contain the same elements.
+func (f FrozenSet[T]) Equal(other Set[T]) bool {
+	return f.Cardinality() == other.Cardinality() && f.IsSuperset(other)
+}
+
+// IsSubset determines if every element in this set is in other.
+func (f FrozenSet[T]) IsSubset(other Set[T]) bool {
+	o := toThreadUnsafeSet(other)
+	if f.Cardinality() > o.Cardinality() {
+		return false
+	}
+	isSubset := true
+	f.Each(func(v T) bool {
+		isSubset = o.contains(v)
+		return !isSubset
+	})
+	return isSubset
+}
+
+// IsSuperset determines if every element in other is in this set.
+func (f FrozenSet[T]) IsSuperset(other Set[T]) bool {
+	t := f.table()
+	isSuperset := true
+	other.Each(func(v T) bool {
+		isSuperset = t.contains(v)
+		return !isSuperset
+	})
+	return isSuperset
+}
+
+// Iter returns a channel of elements that you can range over.
+func (f FrozenSet[T]) Iter() <-chan T {
+	ch := make(chan T)
+	go func() {
+		f.Each(func(v T) bool {
+			ch <- v
+			return false
+		})
+		close(ch)
+	}()
+
+	return ch
+}
+
+// Iterator returns an Iterator object that you can use to range over
+// the set.
+func (f FrozenSet[T]) Iterator() *Iterator[T] {
+	iterator, ch, stopCh := newIterator[T]()
+
+	go func() {
+		f.Each(func(v T) bool {
+			select {
+			case <-stopCh:
+				return true
+			case ch <- v:
+				return false
+			}
+		})
+		close(ch)
+	}()
+
+	return iterator
+}
+
+// ToSlice returns the members of the set as a slice.
+func (f FrozenSet[T]) ToSlice() []T {
+	return f.table().elements()
+}
+
+// ToSet returns a mutable, thread-safe copy of the set.
+func (f FrozenSet[T]) ToSet() Set[T] {
+	return NewSet(f.ToSlice()...)
+}
+
+// String provides a convenient string representation of the set.
+func (f FrozenSet[T]) String() string {
+	items := make([]string, 0, f.Cardinality())
+	f.Each(func(v T) bool {
+		items = append(items, fmt.Sprintf("%v", v))
+		return false
+	})
+	return fmt.Sprintf("FrozenSet{%s}", strings.Join(items, ", "))
+}
+
+func (f FrozenSet[T]) toThreadUnsafeSet() *threadUnsafeSet[T] {
+	s := newThreadUnsafeSetWithSize[T](f.Cardinality())
+	f.Each(func(v T) bool {
+		s.add(v)
+		return false
+	})
+	return s
+}
+
+// Difference returns a new set with the elements of this set that
+// are not in other.
+func (f FrozenSet[T]) Difference(other Set[T]) Set[T] {
+	diff := f.toThreadUnsafeSet().Difference(toThreadUnsafeSet(other)).(*threadUnsafeSet[T])
+	return &threadSafeSet[T]{uss: diff}
+}
+
+// Intersect returns a new set with the elements in both this set
+// and other.
+func (f FrozenSet[T]) Intersect(other Set[T]) Set[T] {
+	t := f.table()
+	intersection := newThreadSafeSet[T]()
+	other.Each(func(v T) bool {
+		if t.contains(v) {
+			intersection.uss.add(v)
+		}
+		return false
+	})
+	return intersection
+}
+
+// SymmetricDifference returns a new set with the elements in exactly
+// one of this set and other.
+func (f FrozenSet[T]) SymmetricDifference(other Set[T]) Set[T] {
+	sd := f.toThreadUnsafeSet().SymmetricDifference(toThreadUnsafeSet(other)).(*threadUnsafeSet[T])
+	return &threadSafeSet[T]{uss: sd}
+}
+
+// Union returns a new set with the elements in either this set or other.
+func (f FrozenSet[T]) Union(other Set[T]) Set[T] {
+	union := f.toThreadUnsafeSet()
+	union.append(other.ToSlice()...)
+	return &threadSafeSet[T]{uss: union}
+}
+
+// MarshalJSON creates a JSON array from the set.
+func (f FrozenSet[T]) MarshalJSON() ([]byte, error) {
+	return json.Marshal(f.ToSlice())
+}
+
+// UnmarshalJSON replaces the set with a new FrozenSet of the elements
+// of a JSON array.
+func (f *FrozenSet[T]) UnmarshalJSON(b []byte) error {
+	var i []T
+	err := json.Unmarshal(b, &i)
+	if err != nil {
+		return err
+	}
+	*f = NewFrozenSet(i...)
+
+	return nil
+}
+
+// MarshalBSONValue creates a BSON array from the set.
+func (f FrozenSet[T]) MarshalBSONValue() (bsontype.Type, []byte, error) {
+	return bson.MarshalValue(f.ToSlice())
+}
+
+// UnmarshalBSONValue replaces the set with a new FrozenSet of the
+// elements of a BSON array.
+func (f *FrozenSet[T]) UnmarshalBSONValue(bt bsontype.Type, b []byte) error {
+	if bt != bson.TypeArray {
+		return fmt.Errorf("must use BSON Array to unmarshal Set")
+	}
+
+	var i []T
+	err := bson.UnmarshalValue(bt, b, &i)
+	if err != nil {
+		return err
+	}
+	*f = NewFrozenSet(i...)
+
+	return nil
+}
+
+type frozenElements[T comparable] struct {
+	Slots    []T `json:"slots"`
+	Overflow []T `json:"overflow,omitempty"`
+}
+
+var errInvalidFrozenSet = errors.New("mapset: invalid frozen set encoding")
+
+// MarshalBinary encodes the set together with its perfect hash function,
+// so that it can be loaded with UnmarshalBinary without being rebuilt.
+// Elements are encoded as JSON.
+func (f FrozenSet[T]) MarshalBinary() ([]byte, error) {
+	t := f.table()
+	elems, err := json.Marshal(frozenElements[T]{Slots: t.slots, Overflow: t.overflow})
+	if err != nil {
+		return nil, err
+	}
+
+	b := make([]byte, 0, 17+4*len(t.disp)+len(elems))
+	b = append(b, frozenMagic...)
+	b = append(b, frozenVersion)
+	b = appendUint64(b, t.salt)
+	b = appendUint32(b, uint32(len(t.disp)))
+	for _, d := range t.disp {
+		b = appendUint32(b, d)
+	}
+	return append(b, elems...), nil
+}
+
+// UnmarshalBinary replaces the set with one decoded from an encoding
+// produced by MarshalBinary. The encoding is verified against the
+// perfect hash function, which takes linear time but does not rebuild it.
+func (f *FrozenSet[T]) UnmarshalBinary(b []byte) error {
+	if len(b) < 17 || string(b[:4]) != frozenMagic {
+		return errInvalidFrozenSet
+	}
+	if b[4] != frozenVersion {
+		return fmt.Errorf("mapset: unsupported frozen set version %d", b[4])
+	}
+	t := &frozenTable[T]{
+		salt:   binary.LittleEndian.Uint64(b[5:]),
+		hasher: DefaultHasher[T](),
+	}
+	nd := binary.LittleEndian.Uint32(b[13:])
+	b = b[17:]
+	if uint64(len(b)) < 4*uint64(nd) {
+		return errInvalidFrozenSet
+	}
+	t.disp = make([]uint32, nd)
+	for i := range t.disp {
+		t.disp[i] = binary.LittleEndian.Uint32(b[4*i:])
+	}
+
+	var elems frozenElements[T]
+	if err := json.Unmarshal(b[4*nd:], &elems); err != nil {
+		return err
+	}
+	t.slots, t.overflow = elems.Slots, elems.Overflow
+	if len(t.slots) > 0 && len(t.disp) == 0 {
+		return errInvalidFrozenSet
+	}
+	for _, d := range t.disp {
+		if d&frozenDirect != 0 && int(d&^frozenDirect) >= len(t.slots) {
+			return errInvalidFrozenSet
+		}
+	}
+	for i, v := range t.slots {
+		if t.slot(t.hasher(v)) != i {
+			return errInvalidFrozenSet
+		}
+	}
+	if len(t.slots) == 0 {
+		f.t = nil
+		return nil
+	}
+	f.t = internFrozenTable(newFrozenKey(t.elements()), t)
+	return nil
+}
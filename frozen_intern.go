@@ -0,0 +1,71 @@
+//go:build go1.24
+
+/*
+Open Source Initiative OSI - The MIT License (MIT):Licensing
+
+The MIT License (MIT)
+Copyright (c) 2013 - 2026 Ralph Caraveo (deckarep@gmail.com)
+
+Permission is hereby granted, free of charge, to any person obtaining a copy of
+this software and associated documentation files (the "Software"), to deal in
+the Software without restriction, including without limitation the rights to
+use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies
+of the Software, and to permit persons to whom the Software is furnished to do
+so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+
+package mapset
+
+import (
+	"runtime"
+	"sync"
+	"weak"
+)
+
+// frozenTables interns the tables of frozen sets by content. Entries hold
+// weak pointers, so a table is collected once no FrozenSet refers to it
+// and its entry is then removed by a cleanup.
+var frozenTables sync.Map
+
+// loadFrozenTable returns the live table interned under key, if any.
+func loadFrozenTable[T comparable](key frozenKey) *frozenTable[T] {
+	v, ok := frozenTables.Load(key)
+	if !ok {
+		return nil
+	}
+	return v.(weak.Pointer[frozenTable[T]]).Value()
+}
+
+// storeFrozenTable interns t under key unless a live table is already
+// interned there, and returns the interned table.
+func storeFrozenTable[T comparable](key frozenKey, t *frozenTable[T]) *frozenTable[T] {
+	wp := weak.Make(t)
+	for {
+		v, loaded := frozenTables.LoadOrStore(key, wp)
+		if !loaded {
+			break
+		}
+		if existing := v.(weak.Pointer[frozenTable[T]]).Value(); existing != nil {
+			return existing
+		}
+		// The interned table was collected but its cleanup has not run.
+		if frozenTables.CompareAndSwap(key, v, wp) {
+			break
+		}
+	}
+	runtime.AddCleanup(t, func(key frozenKey) {
+		frozenTables.CompareAndDelete(key, wp)
+	}, key)
+	return t
+}
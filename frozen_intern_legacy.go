@@ -0,0 +1,50 @@
+//go:build !go1.24
+
+/*
+Open Source Initiative OSI - The MIT License (MIT):Licensing
+
+The MIT License (MIT)
+Copyright (c) 2013 - 2026 Ralph Caraveo (deckarep@gmail.com)
+
+Permission is hereby granted, free of charge, to any person obtaining a copy of
+this software and associated documentation files (the "Software"), to deal in
+the Software without restriction, including without limitation the rights to
+use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies
+of the Software, and to permit persons to whom the Software is furnished to do
+so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+
+package mapset
+
+import "sync"
+
+// frozenTables interns the tables of frozen sets by content. Without weak
+// pointers, which require Go 1.24, interned tables are never released.
+var frozenTables sync.Map
+
+// loadFrozenTable returns the table interned under key, if any.
+func loadFrozenTable[T comparable](key frozenKey) *frozenTable[T] {
+	v, ok := frozenTables.Load(key)
+	if !ok {
+		return nil
+	}
+	return v.(*frozenTable[T])
+}
+
+// storeFrozenTable interns t under key unless a table is already interned
+// there, and returns the interned table.
+func storeFrozenTable[T comparable](key frozenKey, t *frozenTable[T]) *frozenTable[T] {
+	v, _ := frozenTables.LoadOrStore(key, t)
+	return v.(*frozenTable[T])
+}
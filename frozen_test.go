@@ -0,0 +1,274 @@
+/*
+Open Source Initiative OSI - The MIT License (MIT):Licensing
+
+The MIT License (MIT)
+Copyright (c) 2013 - 2026 Ralph Caraveo (deckarep@gmail.com)
+
+Permission is hereby granted, free of charge, to any person obtaining a copy of
+this software and associated documentation files (the "Software"), to deal in
+the Software without restriction, including without limitation the rights to
+use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies
+of the Software, and to permit persons to whom the Software is furnished to do
+so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+
+package mapset
+
+import (
+	"encoding/json"
+	"fmt"
+	"runtime"
+	"sync"
+	"testing"
+)
+
+func Test_FreezeContains(t *testing.T) {
+	for _, n := range []int{0, 1, 2, 3, 10, 1000, 50000} {
+		s := NewThreadUnsafeSetWithSize[string](n)
+		for i := 0; i < n; i++ {
+			s.Add(fmt.Sprintf("key-%d", i))
+		}
+
+		f := Freeze(s)
+		if f.Cardinality() != n {
+			t.Fatalf("n=%d: expected cardinality %d, got %d", n, n, f.Cardinality())
+		}
+		for i := 0; i < n; i++ {
+			if !f.ContainsOne(fmt.Sprintf("key-%d", i)) {
+				t.Fatalf("n=%d: missing key-%d", n, i)
+			}
+			if f.ContainsOne(fmt.Sprintf("other-%d", i)) {
+				t.Fatalf("n=%d: unexpected other-%d", n, i)
+			}
+		}
+		if !f.Equal(s) {
+			t.Errorf("n=%d: frozen set should equal its source", n)
+		}
+	}
+}
+
+func Test_FrozenSetZeroValue(t *testing.T) {
+	var f FrozenSet[int]
+	if !f.IsEmpty() || f.ContainsOne(0) || len(f.ToSlice()) != 0 {
+		t.Error("The zero FrozenSet should be empty")
+	}
+	if f.String() != "FrozenSet{}" {
+		t.Errorf("Unexpected string %q", f.String())
+	}
+}
+
+func Test_FrozenSetIsolation(t *testing.T) {
+	s := NewSet(1, 2, 3)
+	f := Freeze(s)
+	s.Add(4)
+	if f.ContainsOne(4) || f.Cardinality() != 3 {
+		t.Error("A frozen set should not observe changes to its source")
+	}
+
+	m := f.ToSet()
+	m.Add(5)
+	if f.ContainsOne(5) {
+		t.Error("A set from ToSet should be independent of the frozen set")
+	}
+}
+
+func Test_FrozenSetOperations(t *testing.T) {
+	f := NewFrozenSet(1, 2, 3)
+	other := NewThreadUnsafeSet(3, 4)
+
+	assertEqual(f.Union(other), NewSet(1, 2, 3, 4), t)
+	assertEqual(f.Intersect(other), NewSet(3), t)
+	assertEqual(f.Difference(other), NewSet(1, 2), t)
+	assertEqual(f.SymmetricDifference(other), NewSet(1, 2, 4), t)
+
+	if !f.Contains(1, 2) || f.Contains(1, 4) || !f.ContainsAny(4, 3) || !f.ContainsAnyElement(other) {
+		t.Error("Unexpected membership results")
+	}
+	if !f.IsSubset(NewSet(1, 2, 3, 4)) || !f.IsSuperset(NewSet(1, 2)) || f.IsSubset(NewSet(1, 2)) {
+		t.Error("Unexpected subset results")
+	}
+
+	var n int
+	for range f.Iter() {
+		n++
+	}
+	it := f.Iterator()
+	<-it.C
+	it.Stop()
+	if n != 3 {
+		t.Errorf("Expected Iter to yield 3 elements, got %d", n)
+	}
+}
+
+func Test_FrozenSetMarshalBinary(t *testing.T) {
+	s := NewSet[int]()
+	for i := 0; i < 1000; i++ {
+		s.Add(i * 3)
+	}
+	f := Freeze(s)
+
+	b, err := f.MarshalBinary()
+	if err != nil {
+		t.Fatalf("Error should be nil: %v", err)
+	}
+	var g FrozenSet[int]
+	if err := g.UnmarshalBinary(b); err != nil {
+		t.Fatalf("Error should be nil: %v", err)
+	}
+	if !g.Equal(s) {
+		t.Error("Decoded frozen set should equal the original")
+	}
+
+	b[20] ^= 0xff
+	if err := g.UnmarshalBinary(b); err == nil {
+		t.Error("Decoding a corrupted frozen set should fail")
+	}
+
+	var empty FrozenSet[int]
+	if b, err = empty.MarshalBinary(); err != nil {
+		t.Fatalf("Error should be nil: %v", err)
+	}
+	if err := g.UnmarshalBinary(b); err != nil || !g.IsEmpty() {
+		t.Errorf("Expected an empty set, got %v (%v)", g, err)
+	}
+}
+
+func Test_FrozenSetMarshalJSON(t *testing.T) {
+	f := NewFrozenSet("a", "b")
+	b, err := json.Marshal(f)
+	if err != nil {
+		t.Fatalf("Error should be nil: %v", err)
+	}
+
+	var g FrozenSet[string]
+	if err := json.Unmarshal(b, &g); err != nil {
+		t.Fatalf("Error should be nil: %v", err)
+	}
+	if !g.Equal(NewSet("a", "b")) {
+		t.Errorf("Expected FrozenSet{a, b}, got %v", g)
+	}
+}
+
+func Test_FrozenSetHashCollisions(t *testing.T) {
+	s := NewThreadUnsafeSet(1, 2, 3, 4, 5)
+	f := FrozenSet[int]{t: newFrozenTable(s.ToSlice(), func(v int) uint64 {
+		return uint64(v % 2)
+	})}
+	for i := 1; i <= 5; i++ {
+		if !f.ContainsOne(i) {
+			t.Errorf("Missing %d", i)
+		}
+	}
+	if f.ContainsOne(6) || f.Cardinality() != 5 {
+		t.Error("Unexpected contents with colliding hashes")
+	}
+}
+
+func Test_FrozenSetConcurrent(t *testing.T) {
+	f := NewFrozenSet(1, 2, 3)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 1000; j++ {
+				f.ContainsOne(j % 5)
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+func Test_FrozenSetComparable(t *testing.T) {
+	a := NewFrozenSet("read", "write")
+	b := Freeze(NewThreadUnsafeSet("write", "read"))
+	c := NewFrozenSet("read")
+
+	if a != b {
+		t.Error("Frozen sets with equal contents should be ==")
+	}
+	if a == c {
+		t.Error("Frozen sets with different contents should not be ==")
+	}
+	if NewFrozenSet[string]() != (FrozenSet[string]{}) {
+		t.Error("An empty frozen set should equal the zero value")
+	}
+
+	groups := NewSet(a, b, c)
+	if groups.Cardinality() != 2 {
+		t.Errorf("Expected a set of 2 distinct groups, got %v", groups)
+	}
+	if !groups.ContainsOne(NewFrozenSet("write", "read")) {
+		t.Error("A set of frozen sets should find an equal frozen set")
+	}
+
+	perms := map[FrozenSet[string]]string{a: "editor"}
+	if perms[NewFrozenSet("write", "read")] != "editor" {
+		t.Error("Frozen sets should be usable as map keys")
+	}
+}
+
+func Test_FrozenSetComparableAfterDecoding(t *testing.T) {
+	a := NewFrozenSet(1, 2, 3)
+
+	b, err := a.MarshalBinary()
+	if err != nil {
+		t.Fatalf("Error should be nil: %v", err)
+	}
+	var fromBinary FrozenSet[int]
+	if err := fromBinary.UnmarshalBinary(b); err != nil {
+		t.Fatalf("Error should be nil: %v", err)
+	}
+
+	var fromJSON FrozenSet[int]
+	if err := json.Unmarshal([]byte(`[3, 2, 1]`), &fromJSON); err != nil {
+		t.Fatalf("Error should be nil: %v", err)
+	}
+
+	if fromBinary != a || fromJSON != a {
+		t.Error("Decoded frozen sets should be == to an equal frozen set")
+	}
+
+	var nested Set[FrozenSet[int]] = NewSet[FrozenSet[int]]()
+	if err := json.Unmarshal([]byte(`[[1, 2], [2, 1], [3]]`), nested); err != nil {
+		t.Fatalf("Error should be nil: %v", err)
+	}
+	if nested.Cardinality() != 2 || !nested.Contains(NewFrozenSet(1, 2), NewFrozenSet(3)) {
+		t.Errorf("Unexpected set of sets: %v", nested)
+	}
+}
+
+func Test_FrozenSetInterningReleased(t *testing.T) {
+	for i := 0; i < 100; i++ {
+		NewFrozenSet(i, i+1)
+	}
+	runtime.GC()
+
+	if NewFrozenSet(5, 6) != NewFrozenSet(6, 5) {
+		t.Error("Frozen sets should stay comparable after collection")
+	}
+}
+
+func Test_FrozenSetThaw(t *testing.T) {
+	a := NewFrozenSet("x", "y")
+	s := a.ToSet()
+	s.Add("z")
+	if !s.Equal(NewSet("x", "y", "z")) || a.Cardinality() != 2 {
+		t.Errorf("Expected an independent mutable copy, got %v and %v", s, a)
+	}
+	if Freeze(s) == a {
+		t.Error("Refreezing a modified copy should produce a different set")
+	}
+}
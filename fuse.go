@@ -0,0 +1,337 @@
+/*
+Open Source Initiative OSI - The MIT License (MIT):Licensing
+
+The MIT License (MIT)
+Copyright (c) 2013 - 2026 Ralph Caraveo (deckarep@gmail.com)
+
+Permission is hereby granted, free of charge, to any person obtaining a copy of
+this software and associated documentation files (the "Software"), to deal in
+the Software without restriction, including without limitation the rights to
+use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies
+of the Software, and to permit persons to whom the Software is furnished to do
+so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+
+package mapset
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+	"math/bits"
+	"sort"
+)
+
+// BinaryFuseFilter is an immutable approximate set built once from a known
+// set of elements, using the binary fuse construction of Graf and Lemire.
+// It uses about 9 bits per element, never reports a false negative for an
+// element it was built from and reports false positives for about 0.4% of
+// other elements.
+//
+// Its binary encoding is designed to be shipped alongside a program, for
+// example with go:embed, and loaded with LoadBinaryFuseFilter without
+// rebuilding. A BinaryFuseFilter is safe for concurrent use.
+type BinaryFuseFilter[T comparable] struct {
+	seed               uint64
+	segmentLength      uint32
+	segmentLengthMask  uint32
+	segmentCount       uint32
+	segmentCountLength uint32
+	n                  uint32
+	fingerprints       []uint8
+	hasher             Hasher[T]
+}
+
+const (
+	fuseMagic         = "MSFF"
+	fuseVersion       = 1
+	fuseHeaderLen     = 4 + 1 + 8 + 4 + 4 + 4
+	fuseMaxIterations = 100
+)
+
+// NewBinaryFuseFilter builds and returns a filter over the elements of s.
+// Elements are reduced to 64-bit keys with hasher; if hasher is nil,
+// DefaultHasher is used. Elements whose hashes collide are treated as one.
+//
+// Construction fails only with negligible probability, when no suitable
+// seed is found.
+func NewBinaryFuseFilter[T comparable](s Set[T], hasher Hasher[T]) (*BinaryFuseFilter[T], error) {
+	hasher = hasherOrDefault(hasher)
+
+	keys := make([]uint64, 0, s.Cardinality())
+	s.Each(func(v T) bool {
+		keys = append(keys, hasher(v))
+		return false
+	})
+	keys = sortedUniqueUint64(keys)
+
+	f := &BinaryFuseFilter[T]{hasher: hasher}
+	if err := f.populate(keys); err != nil {
+		return nil, err
+	}
+	return f, nil
+}
+
+// LoadBinaryFuseFilter decodes a filter encoded by MarshalBinary. The
+// returned filter shares memory with b, which must not be modified
+// afterwards. The filter must be loaded with the same Hasher it was built
+// with; if hasher is nil, DefaultHasher is used.
+func LoadBinaryFuseFilter[T comparable](b []byte, hasher Hasher[T]) (*BinaryFuseFilter[T], error) {
+	f := &BinaryFuseFilter[T]{hasher: hasher}
+	if err := f.decode(b, false); err != nil {
+		return nil, err
+	}
+	return f, nil
+}
+
+func sortedUniqueUint64(keys []uint64) []uint64 {
+	sort.Slice(keys, func(i, j int) bool { return keys[i] < keys[j] })
+	unique := keys[:0]
+	for i, k := range keys {
+		if i == 0 || k != keys[i-1] {
+			unique = append(unique, k)
+		}
+	}
+	return unique
+}
+
+// fuseMix derives the hash of a key under the filter's seed. It is a
+// bijection on keys, so distinct keys never collide.
+func fuseMix(key, seed uint64) uint64 {
+	h := key + seed
+	h ^= h >> 33
+	h *= 0xff51afd7ed558ccd
+	h ^= h >> 33
+	h *= 0xc4ceb9fe1a85ec53
+	h ^= h >> 33
+	return h
+}
+
+func fuseFingerprint(hash uint64) uint8 {
+	return uint8(hash ^ hash>>32)
+}
+
+func (f *BinaryFuseFilter[T]) initialize(size uint32) {
+	// These parameters come from the binary fuse filter paper and are
+	// sensitive; they keep construction fast with about 9 bits per key.
+	f.segmentLength = 4
+	capacity := uint32(0)
+	if size > 1 {
+		f.segmentLength = uint32(1) << int(math.Floor(math.Log(float64(size))/math.Log(3.33)+2.25))
+		if f.segmentLength > 262144 {
+			f.segmentLength = 262144
+		}
+		sizeFactor := math.Max(1.125, 0.875+0.25*math.Log(1000000)/math.Log(float64(size)))
+		capacity = uint32(math.Round(float64(size) * sizeFactor))
+	}
+	f.segmentLengthMask = f.segmentLength - 1
+
+	f.segmentCount = (capacity + f.segmentLength - 1) / f.segmentLength
+	if f.segmentCount <= 2 {
+		f.segmentCount = 1
+	} else {
+		f.segmentCount -= 2
+	}
+	f.segmentCountLength = f.segmentCount * f.segmentLength
+	f.n = size
+	f.fingerprints = make([]uint8, (f.segmentCount+2)*f.segmentLength)
+}
+
+// locations returns the three fingerprint slots for hash, one in each of
+// three consecutive segments.
+func (f *BinaryFuseFilter[T]) locations(hash uint64) (uint32, uint32, uint32) {
+	hi, _ := bits.Mul64(hash, uint64(f.segmentCountLength))
+	h0 := uint32(hi)
+	h1 := h0 + f.segmentLength
+	h2 := h1 + f.segmentLength
+	h1 ^= uint32(hash>>18) & f.segmentLengthMask
+	h2 ^= uint32(hash) & f.segmentLengthMask
+	return h0, h1, h2
+}
+
+// populate builds the filter from distinct keys by peeling the 3-uniform
+// hypergraph formed by each key's slots and then assigning fingerprints
+// in reverse peeling order.
+func (f *BinaryFuseFilter[T]) populate(keys []uint64) error {
+	size := uint32(len(keys))
+	f.initialize(size)
+	capacity := uint32(len(f.fingerprints))
+
+	// count holds, per slot, the number of keys mapped to it in the upper
+	// six bits and the xor of those keys' slot numbers (0, 1 or 2) in the
+	// lower two; xorHash holds the xor of their hashes. A slot with a
+	// count of one therefore identifies its only key.
+	count := make([]uint8, capacity)
+	xorHash := make([]uint64, capacity)
+	alone := make([]uint32, capacity)
+	order := make([]uint64, size)
+	slot := make([]uint8, size)
+
+	var h012 [5]uint32
+	rng := uint64(0x726b2b9d438b9d4d)
+	for iteration := 0; ; iteration++ {
+		if iteration == fuseMaxIterations {
+			return fmt.Errorf("mapset: failed to build binary fuse filter after %d attempts", fuseMaxIterations)
+		}
+		rng += 0x9e3779b97f4a7c15
+		f.seed = mix64(rng)
+
+		for i := range count {
+			count[i] = 0
+			xorHash[i] = 0
+		}
+
+		overflow := false
+		for _, key := range keys {
+			hash := fuseMix(key, f.seed)
+			i0, i1, i2 := f.locations(hash)
+			count[i0] += 4
+			xorHash[i0] ^= hash
+			count[i1] += 4
+			count[i1] ^= 1
+			xorHash[i1] ^= hash
+			count[i2] += 4
+			count[i2] ^= 2
+			xorHash[i2] ^= hash
+			if count[i0] < 4 || count[i1] < 4 || count[i2] < 4 {
+				overflow = true
+			}
+		}
+		if overflow {
+			continue
+		}
+
+		var queued uint32
+		for i := uint32(0); i < capacity; i++ {
+			alone[queued] = i
+			if count[i]>>2 == 1 {
+				queued++
+			}
+		}
+
+		var peeled uint32
+		for queued > 0 {
+			queued--
+			index := alone[queued]
+			if count[index]>>2 != 1 {
+				continue
+			}
+			hash := xorHash[index]
+			found := count[index] & 3
+			slot[peeled] = found
+			order[peeled] = hash
+			peeled++
+
+			h012[0], h012[1], h012[2] = f.locations(hash)
+			h012[3], h012[4] = h012[0], h012[1]
+			for j := uint8(1); j <= 2; j++ {
+				other := h012[found+j]
+				alone[queued] = other
+				if count[other]>>2 == 2 {
+					queued++
+				}
+				count[other] -= 4
+				count[other] ^= (found + j) % 3
+				xorHash[other] ^= hash
+			}
+		}
+
+		if peeled == size {
+			break
+		}
+	}
+
+	for i := int(size) - 1; i >= 0; i-- {
+		hash := order[i]
+		found := slot[i]
+		h012[0], h012[1], h012[2] = f.locations(hash)
+		h012[3], h012[4] = h012[0], h012[1]
+		f.fingerprints[h012[found]] = fuseFingerprint(hash) ^
+			f.fingerprints[h012[found+1]] ^ f.fingerprints[h012[found+2]]
+	}
+	return nil
+}
+
+// MayContain returns whether the element may be one the filter was built
+// from. A result of false is definitive.
+func (f *BinaryFuseFilter[T]) MayContain(val T) bool {
+	hash := fuseMix(f.hasher(val), f.seed)
+	i0, i1, i2 := f.locations(hash)
+	return fuseFingerprint(hash)^f.fingerprints[i0]^f.fingerprints[i1]^f.fingerprints[i2] == 0
+}
+
+// Len returns the number of distinct keys the filter was built from.
+func (f *BinaryFuseFilter[T]) Len() int {
+	return int(f.n)
+}
+
+// BitsPerElement returns the size of the filter's fingerprint table in
+// bits per key.
+func (f *BinaryFuseFilter[T]) BitsPerElement() float64 {
+	if f.n == 0 {
+		return 0
+	}
+	return float64(8*len(f.fingerprints)) / float64(f.n)
+}
+
+// MarshalBinary encodes the filter. The Hasher is not encoded.
+func (f *BinaryFuseFilter[T]) MarshalBinary() ([]byte, error) {
+	b := make([]byte, 0, fuseHeaderLen+len(f.fingerprints))
+	b = append(b, fuseMagic...)
+	b = append(b, fuseVersion)
+	b = appendUint64(b, f.seed)
+	b = appendUint32(b, f.segmentLength)
+	b = appendUint32(b, f.segmentCount)
+	b = appendUint32(b, f.n)
+	return append(b, f.fingerprints...), nil
+}
+
+// UnmarshalBinary decodes a filter encoded by MarshalBinary, replacing the
+// contents of f. Unlike LoadBinaryFuseFilter it copies b. The receiver's
+// Hasher is kept, or DefaultHasher is used if it has none.
+func (f *BinaryFuseFilter[T]) UnmarshalBinary(b []byte) error {
+	return f.decode(b, true)
+}
+
+func (f *BinaryFuseFilter[T]) decode(b []byte, copyData bool) error {
+	if len(b) < fuseHeaderLen || string(b[:4]) != fuseMagic {
+		return errors.New("mapset: invalid binary fuse filter encoding")
+	}
+	if b[4] != fuseVersion {
+		return fmt.Errorf("mapset: unsupported binary fuse filter version %d", b[4])
+	}
+	seed := binary.LittleEndian.Uint64(b[5:])
+	segmentLength := binary.LittleEndian.Uint32(b[13:])
+	segmentCount := binary.LittleEndian.Uint32(b[17:])
+	n := binary.LittleEndian.Uint32(b[21:])
+	fingerprints := b[fuseHeaderLen:]
+	if segmentLength == 0 || segmentLength&(segmentLength-1) != 0 || segmentCount == 0 ||
+		uint64(len(fingerprints)) != (uint64(segmentCount)+2)*uint64(segmentLength) {
+		return errors.New("mapset: invalid binary fuse filter encoding")
+	}
+	if copyData {
+		fingerprints = append([]uint8(nil), fingerprints...)
+	}
+
+	f.seed = seed
+	f.segmentLength = segmentLength
+	f.segmentLengthMask = segmentLength - 1
+	f.segmentCount = segmentCount
+	f.segmentCountLength = segmentCount * segmentLength
+	f.n = n
+	f.fingerprints = fingerprints
+	f.hasher = hasherOrDefault(f.hasher)
+	return nil
+}
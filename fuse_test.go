@@ -0,0 +1,120 @@
+/*
+Open Source Initiative OSI - The MIT License (MIT):Licensing
+
+The MIT License (MIT)
+Copyright (c) 2013 - 2026 Ralph Caraveo (deckarep@gmail.com)
+
+Permission is hereby granted, free of charge, to any person obtaining a copy of
+this software and associated documentation files (the "Software"), to deal in
+the Software without restriction, including without limitation the rights to
+use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies
+of the Software, and to permit persons to whom the Software is furnished to do
+so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+
+package mapset
+
+import (
+	"fmt"
+	"testing"
+)
+
+func Test_BinaryFuseFilter(t *testing.T) {
+	for _, n := range []int{0, 1, 2, 100, 100000} {
+		s := NewThreadUnsafeSetWithSize[uint64](n)
+		for i := 0; i < n; i++ {
+			s.Add(uint64(i) * 7919)
+		}
+
+		f, err := NewBinaryFuseFilter(s, nil)
+		if err != nil {
+			t.Fatalf("n=%d: error should be nil: %v", n, err)
+		}
+		if f.Len() != n {
+			t.Errorf("n=%d: expected Len %d, got %d", n, n, f.Len())
+		}
+		s.Each(func(v uint64) bool {
+			if !f.MayContain(v) {
+				t.Fatalf("n=%d: false negative for %d", n, v)
+			}
+			return false
+		})
+
+		if n < 10000 {
+			continue
+		}
+		var fp int
+		for i := 0; i < n; i++ {
+			if f.MayContain(uint64(i)*7919 + 1) {
+				fp++
+			}
+		}
+		if rate := float64(fp) / float64(n); rate > 0.006 {
+			t.Errorf("n=%d: false-positive rate %.4f is well above 1/256", n, rate)
+		}
+		if bpe := f.BitsPerElement(); bpe > 10 {
+			t.Errorf("n=%d: expected about 9 bits per element, got %.2f", n, bpe)
+		}
+	}
+}
+
+func Test_BinaryFuseFilterHashedSet(t *testing.T) {
+	s := NewSet[string]()
+	for i := 0; i < 5000; i++ {
+		s.Add(fmt.Sprintf("blocked-%d", i))
+	}
+	f, err := NewBinaryFuseFilter(s, nil)
+	if err != nil {
+		t.Fatalf("Error should be nil: %v", err)
+	}
+	for _, v := range s.ToSlice() {
+		if !f.MayContain(v) {
+			t.Fatalf("False negative for %s", v)
+		}
+	}
+}
+
+func Test_BinaryFuseFilterMarshalBinary(t *testing.T) {
+	s := NewSet[string]("a", "b", "c", "d")
+	f, err := NewBinaryFuseFilter(s, nil)
+	if err != nil {
+		t.Fatalf("Error should be nil: %v", err)
+	}
+
+	b, err := f.MarshalBinary()
+	if err != nil {
+		t.Fatalf("Error should be nil: %v", err)
+	}
+
+	loaded, err := LoadBinaryFuseFilter[string](b, nil)
+	if err != nil {
+		t.Fatalf("Error should be nil: %v", err)
+	}
+	var decoded BinaryFuseFilter[string]
+	if err := decoded.UnmarshalBinary(b); err != nil {
+		t.Fatalf("Error should be nil: %v", err)
+	}
+	for _, v := range s.ToSlice() {
+		if !loaded.MayContain(v) || !decoded.MayContain(v) {
+			t.Errorf("Decoded filters should contain %s", v)
+		}
+	}
+	if loaded.Len() != 4 || decoded.Len() != 4 {
+		t.Errorf("Expected Len 4, got %d and %d", loaded.Len(), decoded.Len())
+	}
+
+	if _, err := LoadBinaryFuseFilter[string](b[:len(b)-1], nil); err == nil {
+		t.Error("Loading a truncated filter should fail")
+	}
+}
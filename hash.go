@@ -0,0 +1,203 @@
+/*
+Open Source Initiative OSI - The MIT License (MIT):Licensing
+
+The MIT License (MIT)
+Copyright (c) 2013 - 2026 Ralph Caraveo (deckarep@gmail.com)
+
+Permission is hereby granted, free of charge, to any person obtaining a copy of
+this software and associated documentation files (the "Software"), to deal in
+the Software without restriction, including without limitation the rights to
+use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies
+of the Software, and to permit persons to whom the Software is furnished to do
+so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+
+package mapset
+
+import (
+	"encoding/binary"
+	"math"
+	"reflect"
+)
+
+// Hasher maps an element to a 64-bit hash. The probabilistic structures in
+// this package accept a Hasher so that callers can supply a faster or
+// domain-specific hash function. A Hasher must return the same hash for
+// equal elements, and structures that are serialized and loaded elsewhere
+// must be used with a Hasher that is stable across processes.
+type Hasher[T comparable] func(val T) uint64
+
+// DefaultHasher returns a Hasher for any comparable type. Hashes are
+// computed from the element's contents rather than its memory layout,
+// so they are stable across processes and platforms, with the exception
+// of pointers and channels, which hash by address.
+func DefaultHasher[T comparable]() Hasher[T] {
+	// Common element types skip the canonical encoding.
+	var zero T
+	switch any(zero).(type) {
+	case string:
+		return func(v T) uint64 { return hashString(any(v).(string)) }
+	case int:
+		return func(v T) uint64 { return mix64(uint64(any(v).(int))) }
+	case int64:
+		return func(v T) uint64 { return mix64(uint64(any(v).(int64))) }
+	case int32:
+		return func(v T) uint64 { return mix64(uint64(any(v).(int32))) }
+	case uint:
+		return func(v T) uint64 { return mix64(uint64(any(v).(uint))) }
+	case uint64:
+		return func(v T) uint64 { return mix64(any(v).(uint64)) }
+	case uint32:
+		return func(v T) uint64 { return mix64(uint64(any(v).(uint32))) }
+	}
+	return func(v T) uint64 {
+		var buf [64]byte
+		return hashBytes(appendElement(buf[:0], v))
+	}
+}
+
+// hasherOrDefault returns h, or DefaultHasher if h is nil.
+func hasherOrDefault[T comparable](h Hasher[T]) Hasher[T] {
+	if h == nil {
+		return DefaultHasher[T]()
+	}
+	return h
+}
+
+const (
+	fnvOffset64 = 14695981039346656037
+	fnvPrime64  = 1099511628211
+)
+
+// hashBytes hashes b with FNV-1a followed by a finalizer that spreads
+// entropy over all 64 bits.
+func hashBytes(b []byte) uint64 {
+	h := uint64(fnvOffset64)
+	for _, c := range b {
+		h ^= uint64(c)
+		h *= fnvPrime64
+	}
+	return mix64(h)
+}
+
+func hashString(s string) uint64 {
+	h := uint64(fnvOffset64)
+	for i := 0; i < len(s); i++ {
+		h ^= uint64(s[i])
+		h *= fnvPrime64
+	}
+	return mix64(h)
+}
+
+// mix64 is the splitmix64 finalizer. It is a bijection, so it can also
+// derive an independent-looking hash from an existing one.
+func mix64(h uint64) uint64 {
+	h ^= h >> 30
+	h *= 0xbf58476d1ce4e5b9
+	h ^= h >> 27
+	h *= 0x94d049bb133111eb
+	h ^= h >> 31
+	return h
+}
+
+// appendElement appends a canonical encoding of v to b. Equal elements
+// have equal encodings and, for elements of the same type T, distinct
+// elements have distinct encodings.
+func appendElement[T comparable](b []byte, v T) []byte {
+	switch x := any(v).(type) {
+	case string:
+		return appendString(b, x)
+	case int:
+		return appendUint64(b, uint64(x))
+	case int64:
+		return appendUint64(b, uint64(x))
+	case int32:
+		return appendUint32(b, uint32(x))
+	case uint:
+		return appendUint64(b, uint64(x))
+	case uint64:
+		return appendUint64(b, x)
+	case uint32:
+		return appendUint32(b, x)
+	}
+	return appendValue(b, reflect.ValueOf(&v).Elem())
+}
+
+func appendUint64(b []byte, v uint64) []byte {
+	var buf [8]byte
+	binary.LittleEndian.PutUint64(buf[:], v)
+	return append(b, buf[:]...)
+}
+
+func appendUint32(b []byte, v uint32) []byte {
+	var buf [4]byte
+	binary.LittleEndian.PutUint32(buf[:], v)
+	return append(b, buf[:]...)
+}
+
+func appendString(b []byte, s string) []byte {
+	var buf [binary.MaxVarintLen64]byte
+	n := binary.PutUvarint(buf[:], uint64(len(s)))
+	return append(append(b, buf[:n]...), s...)
+}
+
+func appendFloat(b []byte, f float64) []byte {
+	if f == 0 {
+		// +0 and -0 are equal, so they must encode identically.
+		f = 0
+	}
+	return appendUint64(b, math.Float64bits(f))
+}
+
+func appendValue(b []byte, v reflect.Value) []byte {
+	switch v.Kind() {
+	case reflect.Bool:
+		if v.Bool() {
+			return append(b, 1)
+		}
+		return append(b, 0)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return appendUint64(b, uint64(v.Int()))
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return appendUint64(b, v.Uint())
+	case reflect.Float32, reflect.Float64:
+		return appendFloat(b, v.Float())
+	case reflect.Complex64, reflect.Complex128:
+		c := v.Complex()
+		return appendFloat(appendFloat(b, real(c)), imag(c))
+	case reflect.String:
+		return appendString(b, v.String())
+	case reflect.Array:
+		for i := 0; i < v.Len(); i++ {
+			b = appendValue(b, v.Index(i))
+		}
+		return b
+	case reflect.Struct:
+		for i := 0; i < v.NumField(); i++ {
+			b = appendValue(b, v.Field(i))
+		}
+		return b
+	case reflect.Interface:
+		if v.IsNil() {
+			return append(b, 0)
+		}
+		e := v.Elem()
+		b = appendString(append(b, 1), e.Type().PkgPath())
+		b = appendString(b, e.Type().String())
+		return appendValue(b, e)
+	case reflect.Pointer, reflect.Chan, reflect.UnsafePointer:
+		return appendUint64(b, uint64(v.Pointer()))
+	}
+	panic("mapset: cannot encode element of type " + v.Type().String())
+}
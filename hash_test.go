@@ -0,0 +1,65 @@
+/*
+Open Source Initiative OSI - The MIT License (MIT):Licensing
+
+The MIT License (MIT)
+Copyright (c) 2013 - 2026 Ralph Caraveo (deckarep@gmail.com)
+
+Permission is hereby granted, free of charge, to any person obtaining a copy of
+this software and associated documentation files (the "Software"), to deal in
+the Software without restriction, including without limitation the rights to
+use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies
+of the Software, and to permit persons to whom the Software is furnished to do
+so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+
+package mapset
+
+import (
+	"math"
+	"reflect"
+	"testing"
+)
+
+func Test_DefaultHasher(t *testing.T) {
+	type point struct {
+		X, Y float64
+		Name string
+	}
+
+	ph := DefaultHasher[point]()
+	if ph(point{1, 2, "a"}) != ph(point{1, 2, "a"}) {
+		t.Error("Equal structs should hash equally")
+	}
+	if ph(point{1, 2, "a"}) == ph(point{2, 1, "a"}) {
+		t.Error("Distinct structs should hash differently")
+	}
+	if ph(point{0, 0, ""}) != ph(point{math.Copysign(0, -1), 0, ""}) {
+		t.Error("+0 and -0 are equal and should hash equally")
+	}
+
+	ah := func(v any) uint64 {
+		return hashBytes(appendValue(nil, reflect.ValueOf(&v).Elem()))
+	}
+	if ah(1) == ah("1") || ah(1) == ah(int8(1)) {
+		t.Error("Interface values of different dynamic types should hash differently")
+	}
+	if ah(nil) != ah(nil) {
+		t.Error("nil should hash consistently")
+	}
+
+	sh := DefaultHasher[[2]string]()
+	if sh([2]string{"ab", "c"}) == sh([2]string{"a", "bc"}) {
+		t.Error("String boundaries should be part of the encoding")
+	}
+}
@@ -0,0 +1,297 @@
+/*
+Open Source Initiative OSI - The MIT License (MIT):Licensing
+
+The MIT License (MIT)
+Copyright (c) 2013 - 2026 Ralph Caraveo (deckarep@gmail.com)
+
+Permission is hereby granted, free of charge, to any person obtaining a copy of
+this software and associated documentation files (the "Software"), to deal in
+the Software without restriction, including without limitation the rights to
+use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies
+of the Software, and to permit persons to whom the Software is furnished to do
+so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+
+package mapset
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+	"math/bits"
+	"sort"
+)
+
+// ErrIncompatibleSketches is returned when combining sketches whose
+// parameters differ.
+var ErrIncompatibleSketches = errors.New("mapset: incompatible sketches")
+
+var errInvalidHyperLogLog = errors.New("mapset: invalid HyperLogLog encoding")
+
+const (
+	// MinHyperLogLogPrecision and MaxHyperLogLogPrecision bound the
+	// precision of a HyperLogLog.
+	MinHyperLogLogPrecision = 4
+	MaxHyperLogLogPrecision = 18
+
+	hllMagic   = "MSHL"
+	hllVersion = 1
+)
+
+// HyperLogLog estimates the number of distinct elements added to it using
+// a fixed amount of memory. With precision p it uses 2^p registers and has
+// a standard error of about 1.04/sqrt(2^p), e.g. 0.8% for p = 14.
+//
+// Small sketches store only non-zero registers and switch to a dense
+// array of registers once that becomes smaller.
+//
+// A HyperLogLog is not safe for concurrent use.
+type HyperLogLog[T comparable] struct {
+	p      uint8
+	sparse map[uint32]uint8
+	dense  []uint8
+	hasher Hasher[T]
+}
+
+// NewHyperLogLog creates and returns an empty HyperLogLog with the given
+// precision. If hasher is nil, DefaultHasher is used. NewHyperLogLog
+// panics if precision is outside [MinHyperLogLogPrecision,
+// MaxHyperLogLogPrecision].
+func NewHyperLogLog[T comparable](precision uint8, hasher Hasher[T]) *HyperLogLog[T] {
+	if precision < MinHyperLogLogPrecision || precision > MaxHyperLogLogPrecision {
+		panic(fmt.Sprintf("mapset: HyperLogLog precision must be in [%d, %d], got %d",
+			MinHyperLogLogPrecision, MaxHyperLogLogPrecision, precision))
+	}
+	return &HyperLogLog[T]{
+		p:      precision,
+		sparse: make(map[uint32]uint8),
+		hasher: hasherOrDefault(hasher),
+	}
+}
+
+// ToHyperLogLog creates and returns a HyperLogLog with the given precision
+// seeded with the elements of s. If hasher is nil, DefaultHasher is used.
+func ToHyperLogLog[T comparable](s Set[T], precision uint8, hasher Hasher[T]) *HyperLogLog[T] {
+	h := NewHyperLogLog(precision, hasher)
+	h.AddSet(s)
+	return h
+}
+
+// Precision returns the precision of the sketch.
+func (h *HyperLogLog[T]) Precision() uint8 {
+	return h.p
+}
+
+// IsSparse returns whether the sketch currently uses the sparse
+// representation.
+func (h *HyperLogLog[T]) IsSparse() bool {
+	return h.dense == nil
+}
+
+func (h *HyperLogLog[T]) registers() int {
+	return 1 << h.p
+}
+
+// Add adds an element to the sketch.
+func (h *HyperLogLog[T]) Add(val T) {
+	x := h.hasher(val)
+	idx := uint32(x >> (64 - h.p))
+	rank := uint8(bits.LeadingZeros64(x<<h.p|1<<(h.p-1))) + 1
+	h.set(idx, rank)
+}
+
+// Append adds multiple elements to the sketch.
+func (h *HyperLogLog[T]) Append(val ...T) {
+	for _, v := range val {
+		h.Add(v)
+	}
+}
+
+// AddSet adds every element of s to the sketch, so that an exact set can
+// be combined with approximate counts.
+func (h *HyperLogLog[T]) AddSet(s Set[T]) {
+	s.Each(func(v T) bool {
+		h.Add(v)
+		return false
+	})
+}
+
+// set raises register idx to rank if it is lower.
+func (h *HyperLogLog[T]) set(idx uint32, rank uint8) {
+	if h.dense != nil {
+		if rank > h.dense[idx] {
+			h.dense[idx] = rank
+		}
+		return
+	}
+	if rank > h.sparse[idx] {
+		h.sparse[idx] = rank
+		// A sparse entry costs several times a dense register.
+		if len(h.sparse) > h.registers()/8 {
+			h.toDense()
+		}
+	}
+}
+
+func (h *HyperLogLog[T]) toDense() {
+	h.dense = make([]uint8, h.registers())
+	for idx, rank := range h.sparse {
+		h.dense[idx] = rank
+	}
+	h.sparse = nil
+}
+
+// Estimate returns the estimated number of distinct elements added.
+func (h *HyperLogLog[T]) Estimate() uint64 {
+	m := float64(h.registers())
+
+	var sum float64
+	var zeros int
+	if h.dense != nil {
+		for _, r := range h.dense {
+			if r == 0 {
+				zeros++
+			}
+			sum += math.Ldexp(1, -int(r))
+		}
+	} else {
+		zeros = h.registers() - len(h.sparse)
+		sum = float64(zeros)
+		for _, r := range h.sparse {
+			sum += math.Ldexp(1, -int(r))
+		}
+	}
+
+	e := hllAlpha(m) * m * m / sum
+	if e <= 2.5*m && zeros > 0 {
+		// Linear counting is more accurate for small cardinalities.
+		e = m * math.Log(m/float64(zeros))
+	}
+	return uint64(math.Round(e))
+}
+
+func hllAlpha(m float64) float64 {
+	switch m {
+	case 16:
+		return 0.673
+	case 32:
+		return 0.697
+	case 64:
+		return 0.709
+	}
+	return 0.7213 / (1 + 1.079/m)
+}
+
+// Merge folds other into h, so that h estimates the number of distinct
+// elements added to either sketch. Both sketches must have the same
+// precision and must use the same Hasher.
+func (h *HyperLogLog[T]) Merge(other *HyperLogLog[T]) error {
+	if h.p != other.p {
+		return fmt.Errorf("%w: precision %d and %d", ErrIncompatibleSketches, h.p, other.p)
+	}
+	if other.dense != nil {
+		if h.dense == nil {
+			h.toDense()
+		}
+		for idx, rank := range other.dense {
+			if rank > h.dense[idx] {
+				h.dense[idx] = rank
+			}
+		}
+		return nil
+	}
+	for idx, rank := range other.sparse {
+		h.set(idx, rank)
+	}
+	return nil
+}
+
+// Clear resets the sketch to empty.
+func (h *HyperLogLog[T]) Clear() {
+	h.sparse = make(map[uint32]uint8)
+	h.dense = nil
+}
+
+// MarshalBinary encodes the sketch in its current representation. The
+// Hasher is not encoded; the sketch must be decoded with the same Hasher
+// it was built with.
+func (h *HyperLogLog[T]) MarshalBinary() ([]byte, error) {
+	b := append([]byte(hllMagic), hllVersion, h.p)
+	if h.dense != nil {
+		b = append(b, 1)
+		return append(b, h.dense...), nil
+	}
+
+	idxs := make([]uint32, 0, len(h.sparse))
+	for idx := range h.sparse {
+		idxs = append(idxs, idx)
+	}
+	sort.Slice(idxs, func(i, j int) bool { return idxs[i] < idxs[j] })
+
+	b = append(b, 0)
+	b = appendUint32(b, uint32(len(idxs)))
+	for _, idx := range idxs {
+		b = append(appendUint32(b, idx), h.sparse[idx])
+	}
+	return b, nil
+}
+
+// UnmarshalBinary decodes a sketch encoded by MarshalBinary, replacing the
+// contents of h. The receiver's Hasher is kept, or DefaultHasher is used
+// if it has none.
+func (h *HyperLogLog[T]) UnmarshalBinary(b []byte) error {
+	if len(b) < 7 || string(b[:4]) != hllMagic {
+		return errInvalidHyperLogLog
+	}
+	if b[4] != hllVersion {
+		return fmt.Errorf("mapset: unsupported HyperLogLog version %d", b[4])
+	}
+	p, dense := b[5], b[6] == 1
+	if p < MinHyperLogLogPrecision || p > MaxHyperLogLogPrecision {
+		return errInvalidHyperLogLog
+	}
+	b = b[7:]
+	m := 1 << p
+
+	if dense {
+		if len(b) != m {
+			return errInvalidHyperLogLog
+		}
+		h.p, h.sparse, h.dense = p, nil, append([]uint8(nil), b...)
+		h.hasher = hasherOrDefault(h.hasher)
+		return nil
+	}
+
+	if len(b) < 4 {
+		return errInvalidHyperLogLog
+	}
+	n := binary.LittleEndian.Uint32(b)
+	b = b[4:]
+	if uint64(len(b)) != uint64(n)*5 {
+		return errInvalidHyperLogLog
+	}
+	sparse := make(map[uint32]uint8, n)
+	for i := uint32(0); i < n; i++ {
+		idx := binary.LittleEndian.Uint32(b)
+		if idx >= uint32(m) {
+			return errInvalidHyperLogLog
+		}
+		sparse[idx] = b[4]
+		b = b[5:]
+	}
+	h.p, h.sparse, h.dense = p, sparse, nil
+	h.hasher = hasherOrDefault(h.hasher)
+	return nil
+}
@@ -0,0 +1,132 @@
+/*
+Open Source Initiative OSI - The MIT License (MIT):Licensing
+
+The MIT License (MIT)
+Copyright (c) 2013 - 2026 Ralph Caraveo (deckarep@gmail.com)
+
+Permission is hereby granted, free of charge, to any person obtaining a copy of
+this software and associated documentation files (the "Software"), to deal in
+the Software without restriction, including without limitation the rights to
+use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies
+of the Software, and to permit persons to whom the Software is furnished to do
+so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+
+package mapset
+
+import (
+	"errors"
+	"testing"
+)
+
+func assertEstimate(t *testing.T, got uint64, want int, tolerance float64) {
+	t.Helper()
+	if diff := float64(got) - float64(want); diff > tolerance*float64(want) || -diff > tolerance*float64(want) {
+		t.Errorf("Expected an estimate within %.1f%% of %d, got %d", tolerance*100, want, got)
+	}
+}
+
+func Test_HyperLogLogEstimate(t *testing.T) {
+	for _, n := range []int{0, 10, 1000, 100000} {
+		h := NewHyperLogLog[int](14, nil)
+		for i := 0; i < n; i++ {
+			h.Add(i)
+			h.Add(i)
+		}
+		if n == 0 {
+			if h.Estimate() != 0 {
+				t.Errorf("Expected an empty sketch to estimate 0, got %d", h.Estimate())
+			}
+			continue
+		}
+		assertEstimate(t, h.Estimate(), n, 0.03)
+	}
+}
+
+func Test_HyperLogLogSparseToDense(t *testing.T) {
+	h := NewHyperLogLog[int](10, nil)
+	h.Append(1, 2, 3)
+	if !h.IsSparse() {
+		t.Error("A small sketch should be sparse")
+	}
+	for i := 0; i < 1000; i++ {
+		h.Add(i)
+	}
+	if h.IsSparse() {
+		t.Error("A large sketch should be dense")
+	}
+	assertEstimate(t, h.Estimate(), 1000, 0.1)
+}
+
+func Test_HyperLogLogMerge(t *testing.T) {
+	shards := []Set[int]{NewSet[int](), NewThreadUnsafeSet[int](), NewSet[int]()}
+	for i := 0; i < 30000; i++ {
+		shards[i%3].Add(i % 20000)
+	}
+
+	total := NewHyperLogLog[int](14, nil)
+	for _, s := range shards {
+		if err := total.Merge(ToHyperLogLog(s, 14, nil)); err != nil {
+			t.Fatalf("Error should be nil: %v", err)
+		}
+	}
+	assertEstimate(t, total.Estimate(), 20000, 0.03)
+
+	small := NewHyperLogLog[int](14, nil)
+	small.Add(-1)
+	if err := total.Merge(small); err != nil {
+		t.Fatalf("Error should be nil: %v", err)
+	}
+	if err := small.Merge(total); err != nil || small.IsSparse() {
+		t.Errorf("Merging a dense sketch into a sparse one should make it dense, err=%v", err)
+	}
+
+	if err := total.Merge(NewHyperLogLog[int](12, nil)); !errors.Is(err, ErrIncompatibleSketches) {
+		t.Errorf("Expected ErrIncompatibleSketches, got %v", err)
+	}
+}
+
+func Test_HyperLogLogMarshalBinary(t *testing.T) {
+	for _, n := range []int{5, 5000} {
+		h := NewHyperLogLog[int](12, nil)
+		for i := 0; i < n; i++ {
+			h.Add(i)
+		}
+
+		b, err := h.MarshalBinary()
+		if err != nil {
+			t.Fatalf("Error should be nil: %v", err)
+		}
+		var g HyperLogLog[int]
+		if err := g.UnmarshalBinary(b); err != nil {
+			t.Fatalf("Error should be nil: %v", err)
+		}
+		if g.Estimate() != h.Estimate() || g.IsSparse() != h.IsSparse() || g.Precision() != 12 {
+			t.Errorf("Decoded sketch differs: %d vs %d", g.Estimate(), h.Estimate())
+		}
+		g.Add(n)
+		if err := g.UnmarshalBinary(b[:len(b)-1]); err == nil {
+			t.Error("Decoding a truncated sketch should fail")
+		}
+	}
+}
+
+func Test_NewHyperLogLogPanics(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Error("NewHyperLogLog should panic on an invalid precision")
+		}
+	}()
+	NewHyperLogLog[int](MaxHyperLogLogPrecision+1, nil)
+}
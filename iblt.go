@@ -0,0 +1,341 @@
+/*
+Open Source Initiative OSI - The MIT License (MIT):Licensing
+
+The MIT License (MIT)
+Copyright (c) 2013 - 2026 Ralph Caraveo (deckarep@gmail.com)
+
+Permission is hereby granted, free of charge, to any person obtaining a copy of
+this software and associated documentation files (the "Software"), to deal in
+the Software without restriction, including without limitation the rights to
+use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies
+of the Software, and to permit persons to whom the Software is furnished to do
+so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+
+package mapset
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+)
+
+// ErrIBLTUndecodable is returned by Decode when the table holds more
+// differences than it can recover. Retry with a larger table.
+var ErrIBLTUndecodable = errors.New("mapset: IBLT could not be decoded")
+
+// IBLT is an invertible Bloom lookup table, a fixed-size sketch of a set
+// used to reconcile two sets that differ in a few elements. Each side
+// encodes its set into a table of the same size; subtracting one table
+// from the other cancels the elements they share, and Decode recovers the
+// hashes of the remaining elements provided the table has comfortably
+// more cells than there are differences.
+//
+// Elements are identified by their 64-bit hash, so both sides must use
+// the same Hasher and it must be stable across processes. Distinct
+// elements whose hashes collide cannot be told apart.
+//
+// An IBLT is not safe for concurrent use.
+type IBLT[T comparable] struct {
+	cells  []ibltCell
+	hasher Hasher[T]
+}
+
+type ibltCell struct {
+	count   int64
+	keySum  uint64
+	hashSum uint64
+}
+
+// ibltHashes is the number of cells each element is stored in. The cells
+// are split into that many equal subtables so an element's cells are
+// always distinct.
+const ibltHashes = 3
+
+// NewIBLT creates and returns an empty table sized to decode up to about
+// expectedDiff differences, counting the elements missing from either
+// side. If hasher is nil, DefaultHasher is used.
+func NewIBLT[T comparable](expectedDiff int, hasher Hasher[T]) *IBLT[T] {
+	if expectedDiff < 0 {
+		expectedDiff = 0
+	}
+	// Small tables need proportionally more slack to decode reliably.
+	return NewIBLTWithSize(expectedDiff+expectedDiff/2+30, hasher)
+}
+
+// NewIBLTWithSize creates and returns an empty table with the given
+// number of cells, rounded up to a multiple of three. If hasher is nil,
+// DefaultHasher is used.
+func NewIBLTWithSize[T comparable](cells int, hasher Hasher[T]) *IBLT[T] {
+	if cells < ibltHashes {
+		cells = ibltHashes
+	}
+	cells = (cells + ibltHashes - 1) / ibltHashes * ibltHashes
+	return &IBLT[T]{
+		cells:  make([]ibltCell, cells),
+		hasher: hasherOrDefault(hasher),
+	}
+}
+
+// ToIBLT creates and returns a table sized to decode up to about
+// expectedDiff differences, and adds the elements of s to it. If hasher
+// is nil, DefaultHasher is used.
+func ToIBLT[T comparable](s Set[T], expectedDiff int, hasher Hasher[T]) *IBLT[T] {
+	f := NewIBLT(expectedDiff, hasher)
+	s.Each(func(v T) bool {
+		f.Add(v)
+		return false
+	})
+	return f
+}
+
+// ibltCheck derives the checksum stored alongside each hash, used to
+// recognize cells holding exactly one element.
+func ibltCheck(h uint64) uint64 {
+	return mix64(h ^ 0x9e3779b97f4a7c15)
+}
+
+// update adds count copies of the element with hash h to each of its
+// cells.
+func (f *IBLT[T]) update(h uint64, count int64) {
+	w := len(f.cells) / ibltHashes
+	check := ibltCheck(h)
+	for i := 0; i < ibltHashes; i++ {
+		c := &f.cells[i*w+fastrange(mix64(h+uint64(i)), w)]
+		c.count += count
+		c.keySum ^= h
+		c.hashSum ^= check
+	}
+}
+
+// Add adds an element to the table. Each element should be added once.
+func (f *IBLT[T]) Add(val T) {
+	f.update(f.hasher(val), 1)
+}
+
+// Append adds multiple elements to the table.
+func (f *IBLT[T]) Append(val ...T) {
+	for _, v := range val {
+		f.Add(v)
+	}
+}
+
+// Remove removes an element previously added to the table.
+func (f *IBLT[T]) Remove(val T) {
+	f.update(f.hasher(val), -1)
+}
+
+// Cells returns the number of cells in the table.
+func (f *IBLT[T]) Cells() int {
+	return len(f.cells)
+}
+
+// Clear removes all elements from the table.
+func (f *IBLT[T]) Clear() {
+	for i := range f.cells {
+		f.cells[i] = ibltCell{}
+	}
+}
+
+// Subtract returns a new table holding the elements of f that are not in
+// other, and, with a negative count, the elements of other that are not
+// in f. Both tables must have the same number of cells and must use the
+// same Hasher.
+func (f *IBLT[T]) Subtract(other *IBLT[T]) (*IBLT[T], error) {
+	if len(f.cells) != len(other.cells) {
+		return nil, fmt.Errorf("%w: %d cells and %d cells",
+			ErrIncompatibleSketches, len(f.cells), len(other.cells))
+	}
+	d := &IBLT[T]{cells: make([]ibltCell, len(f.cells)), hasher: f.hasher}
+	for i, c := range f.cells {
+		o := other.cells[i]
+		d.cells[i] = ibltCell{
+			count:   c.count - o.count,
+			keySum:  c.keySum ^ o.keySum,
+			hashSum: c.hashSum ^ o.hashSum,
+		}
+	}
+	return d, nil
+}
+
+// pure reports whether c holds exactly one element, added or removed.
+func (c ibltCell) pure() bool {
+	return (c.count == 1 || c.count == -1) && c.hashSum == ibltCheck(c.keySum)
+}
+
+// Decode lists the hashes of the elements in the table: those with a
+// positive count in added, and those with a negative count, such as the
+// elements of the other table after Subtract, in removed. The table is
+// not modified. If the table cannot be decoded completely, Decode returns
+// ErrIBLTUndecodable.
+func (f *IBLT[T]) Decode() (added, removed []uint64, err error) {
+	d := &IBLT[T]{cells: make([]ibltCell, len(f.cells))}
+	copy(d.cells, f.cells)
+
+	var queue []int
+	for i, c := range d.cells {
+		if c.pure() {
+			queue = append(queue, i)
+		}
+	}
+
+	w := len(d.cells) / ibltHashes
+	for len(queue) > 0 {
+		i := queue[len(queue)-1]
+		queue = queue[:len(queue)-1]
+		c := d.cells[i]
+		if !c.pure() {
+			// Already peeled through another of the element's cells.
+			continue
+		}
+
+		// Each peel empties the pure cell it came from, so a table
+		// holds at most one element per cell. More peels mean the
+		// table is damaged, and its cells could otherwise keep
+		// peeling each other forever.
+		if len(added)+len(removed) == len(d.cells) {
+			return nil, nil, ErrIBLTUndecodable
+		}
+		h := c.keySum
+		if c.count > 0 {
+			added = append(added, h)
+		} else {
+			removed = append(removed, h)
+		}
+		d.update(h, -c.count)
+		for j := 0; j < ibltHashes; j++ {
+			k := j*w + fastrange(mix64(h+uint64(j)), w)
+			if d.cells[k].pure() {
+				queue = append(queue, k)
+			}
+		}
+	}
+
+	for _, c := range d.cells {
+		if c != (ibltCell{}) {
+			return nil, nil, ErrIBLTUndecodable
+		}
+	}
+	return added, removed, nil
+}
+
+const (
+	ibltMagic   = "MSIB"
+	ibltVersion = 1
+)
+
+// MarshalBinary encodes the table. The Hasher is not encoded; the table
+// must be decoded with the same Hasher it was built with.
+func (f *IBLT[T]) MarshalBinary() ([]byte, error) {
+	b := make([]byte, 0, 9+24*len(f.cells))
+	b = append(b, ibltMagic...)
+	b = append(b, ibltVersion)
+	b = appendUint32(b, uint32(len(f.cells)))
+	for _, c := range f.cells {
+		b = appendUint64(b, uint64(c.count))
+		b = appendUint64(b, c.keySum)
+		b = appendUint64(b, c.hashSum)
+	}
+	return b, nil
+}
+
+// UnmarshalBinary decodes a table encoded by MarshalBinary, replacing the
+// contents of f. The receiver's Hasher is kept, or DefaultHasher is used
+// if it has none.
+func (f *IBLT[T]) UnmarshalBinary(b []byte) error {
+	if len(b) < 9 || string(b[:4]) != ibltMagic {
+		return errors.New("mapset: invalid IBLT encoding")
+	}
+	if b[4] != ibltVersion {
+		return fmt.Errorf("mapset: unsupported IBLT version %d", b[4])
+	}
+	n := uint64(binary.LittleEndian.Uint32(b[5:]))
+	b = b[9:]
+	if n == 0 || n%ibltHashes != 0 || uint64(len(b)) != 24*n {
+		return errors.New("mapset: invalid IBLT encoding")
+	}
+
+	cells := make([]ibltCell, n)
+	for i := range cells {
+		cells[i] = ibltCell{
+			count:   int64(binary.LittleEndian.Uint64(b[24*i:])),
+			keySum:  binary.LittleEndian.Uint64(b[24*i+8:]),
+			hashSum: binary.LittleEndian.Uint64(b[24*i+16:]),
+		}
+	}
+	f.cells = cells
+	f.hasher = hasherOrDefault(f.hasher)
+	return nil
+}
+
+// Reconciliation is the symmetric difference between a local set and a
+// peer's set, as computed by Reconcile.
+type Reconciliation[T comparable] struct {
+	// LocalOnly holds the elements of the local set that the peer's
+	// set lacks, to be sent to the peer.
+	LocalOnly []T
+
+	// RemoteOnly holds the hashes of the elements of the peer's set
+	// that the local set lacks, to be requested from the peer, which
+	// can look them up with ElementsByHash.
+	RemoteOnly []uint64
+}
+
+// Reconcile compares s against remote, a table encoding the peer's set,
+// by encoding s into a table of the same size and decoding the
+// difference. It returns ErrIBLTUndecodable if the sets differ in too many
+// elements for the size of remote, in which case the peer should send a
+// larger table.
+func Reconcile[T comparable](s Set[T], remote *IBLT[T]) (Reconciliation[T], error) {
+	local := NewIBLTWithSize(len(remote.cells), remote.hasher)
+	s.Each(func(v T) bool {
+		local.Add(v)
+		return false
+	})
+
+	d, err := local.Subtract(remote)
+	if err != nil {
+		return Reconciliation[T]{}, err
+	}
+	added, removed, err := d.Decode()
+	if err != nil {
+		return Reconciliation[T]{}, err
+	}
+	return Reconciliation[T]{
+		LocalOnly:  ElementsByHash(s, added, remote.hasher),
+		RemoteOnly: removed,
+	}, nil
+}
+
+// ElementsByHash returns the elements of s whose hash is one of hashes.
+// If hasher is nil, DefaultHasher is used.
+func ElementsByHash[T comparable](s Set[T], hashes []uint64, hasher Hasher[T]) []T {
+	if len(hashes) == 0 {
+		return nil
+	}
+	hasher = hasherOrDefault(hasher)
+	want := make(map[uint64]struct{}, len(hashes))
+	for _, h := range hashes {
+		want[h] = struct{}{}
+	}
+
+	var found []T
+	s.Each(func(v T) bool {
+		if _, ok := want[hasher(v)]; ok {
+			found = append(found, v)
+		}
+		return false
+	})
+	return found
+}
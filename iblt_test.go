@@ -0,0 +1,234 @@
+/*
+Open Source Initiative OSI - The MIT License (MIT):Licensing
+
+The MIT License (MIT)
+Copyright (c) 2013 - 2026 Ralph Caraveo (deckarep@gmail.com)
+
+Permission is hereby granted, free of charge, to any person obtaining a copy of
+this software and associated documentation files (the "Software"), to deal in
+the Software without restriction, including without limitation the rights to
+use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies
+of the Software, and to permit persons to whom the Software is furnished to do
+so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+
+package mapset
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"testing"
+)
+
+func sortedInts(vs []int) []int {
+	sort.Ints(vs)
+	return vs
+}
+
+func Test_IBLTDecode(t *testing.T) {
+	for _, d := range []int{0, 1, 10, 100, 1000} {
+		a := rangeSet(0, 20000)
+		b := rangeSet(d, 20000+d) // a has 0..d-1, b has 20000..20000+d-1
+
+		fa, fb := ToIBLT(a, 2*d, nil), ToIBLT(b, 2*d, nil)
+		diff, err := fa.Subtract(fb)
+		if err != nil {
+			t.Fatal(err)
+		}
+		added, removed, err := diff.Decode()
+		if err != nil {
+			t.Fatalf("d=%d: %v", d, err)
+		}
+		if len(added) != d || len(removed) != d {
+			t.Fatalf("d=%d: expected %d added and removed, got %d and %d", d, d, len(added), len(removed))
+		}
+
+		h := DefaultHasher[int]()
+		want := make(map[uint64]bool)
+		for i := 0; i < d; i++ {
+			want[h(i)] = true
+		}
+		for _, x := range added {
+			if !want[x] {
+				t.Fatalf("d=%d: unexpected added hash %x", d, x)
+			}
+		}
+	}
+}
+
+func Test_IBLTUndecodable(t *testing.T) {
+	a, b := rangeSet(0, 1000), rangeSet(500, 1500)
+	fa, fb := ToIBLT(a, 10, nil), ToIBLT(b, 10, nil)
+	diff, _ := fa.Subtract(fb)
+	if _, _, err := diff.Decode(); !errors.Is(err, ErrIBLTUndecodable) {
+		t.Errorf("Expected ErrIBLTUndecodable, got %v", err)
+	}
+	if _, err := Reconcile(a, fb); !errors.Is(err, ErrIBLTUndecodable) {
+		t.Errorf("Expected ErrIBLTUndecodable, got %v", err)
+	}
+}
+
+func Test_IBLTCorruptTable(t *testing.T) {
+	// With one cell of an element cleared, its remaining pure cells
+	// and the cleared one would peel each other back and forth.
+	f := NewIBLTWithSize[int](3, nil)
+	f.Add(7)
+	f.cells[0] = ibltCell{}
+	b, err := f.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var g IBLT[int]
+	if err := g.UnmarshalBinary(b); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := g.Decode(); !errors.Is(err, ErrIBLTUndecodable) {
+		t.Errorf("Expected ErrIBLTUndecodable, got %v", err)
+	}
+	if _, err := Reconcile(NewSet(1, 2), &g); !errors.Is(err, ErrIBLTUndecodable) {
+		t.Errorf("Expected ErrIBLTUndecodable, got %v", err)
+	}
+}
+
+func Test_IBLTAddRemove(t *testing.T) {
+	f := NewIBLT[string](10, nil)
+	f.Append("a", "b", "c")
+	f.Remove("b")
+	added, removed, err := f.Decode()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(added) != 2 || len(removed) != 0 {
+		t.Errorf("Expected 2 added elements, got %d added and %d removed", len(added), len(removed))
+	}
+
+	f.Clear()
+	if added, _, _ := f.Decode(); len(added) != 0 {
+		t.Error("Clear should remove all elements")
+	}
+}
+
+func Test_IBLTIncompatible(t *testing.T) {
+	a := NewIBLTWithSize[int](30, nil)
+	b := NewIBLTWithSize[int](31, nil)
+	if b.Cells() != 33 {
+		t.Errorf("Expected cells to round up to 33, got %d", b.Cells())
+	}
+	if _, err := a.Subtract(b); !errors.Is(err, ErrIncompatibleSketches) {
+		t.Errorf("Expected ErrIncompatibleSketches, got %v", err)
+	}
+}
+
+func Test_IBLTMarshalBinary(t *testing.T) {
+	f := ToIBLT(rangeSet(0, 100), 20, nil)
+	b, err := f.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var g IBLT[int]
+	if err := g.UnmarshalBinary(b); err != nil {
+		t.Fatal(err)
+	}
+	if g.Cells() != f.Cells() {
+		t.Fatalf("Expected %d cells, got %d", f.Cells(), g.Cells())
+	}
+	for i := 0; i < 100; i++ {
+		g.Remove(i)
+	}
+	if added, removed, err := g.Decode(); err != nil || len(added)+len(removed) != 0 {
+		t.Error("A decoded table should hold the encoded elements")
+	}
+
+	for _, bad := range [][]byte{nil, b[:8], b[:len(b)-1], append([]byte("XXXX"), b[4:]...)} {
+		if err := g.UnmarshalBinary(bad); err == nil {
+			t.Errorf("Expected an error decoding %d bytes", len(bad))
+		}
+	}
+}
+
+// ibltPeer is a replica that reconciles its set with another replica by
+// exchanging encoded messages.
+type ibltPeer struct {
+	set Set[string]
+}
+
+func (p *ibltPeer) sketch(expectedDiff int) []byte {
+	b, _ := ToIBLT(p.set, expectedDiff, nil).MarshalBinary()
+	return b
+}
+
+func (p *ibltPeer) lookup(hashes []uint64) []string {
+	return ElementsByHash(p.set, hashes, nil)
+}
+
+// syncWith exchanges the elements that only one of p and remote holds,
+// returning the number sent to and received from remote.
+func (p *ibltPeer) syncWith(remote *ibltPeer, expectedDiff int) (sent, received int, err error) {
+	var f IBLT[string]
+	if err := f.UnmarshalBinary(remote.sketch(expectedDiff)); err != nil {
+		return 0, 0, err
+	}
+	r, err := Reconcile(p.set, &f)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	remote.set.Append(r.LocalOnly...)
+	missing := remote.lookup(r.RemoteOnly)
+	p.set.Append(missing...)
+	return len(r.LocalOnly), len(missing), nil
+}
+
+func Test_IBLTPeers(t *testing.T) {
+	a := &ibltPeer{set: NewSet[string]()}
+	b := &ibltPeer{set: NewSet[string]()}
+	for i := 0; i < 10000; i++ {
+		id := fmt.Sprintf("id-%d", i)
+		a.set.Add(id)
+		b.set.Add(id)
+	}
+	a.set.Append("only-a-1", "only-a-2", "only-a-3")
+	b.set.Append("only-b-1", "only-b-2")
+	b.set.Remove("id-7")
+
+	sent, received, err := a.syncWith(b, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sent != 4 || received != 2 {
+		t.Errorf("Expected to send 4 and receive 2 elements, got %d and %d", sent, received)
+	}
+	if !a.set.Equal(b.set) {
+		t.Error("Peers should hold equal sets after reconciling")
+	}
+
+	if sent, received, err := b.syncWith(a, 10); err != nil || sent+received != 0 {
+		t.Errorf("Reconciled peers should have nothing to exchange, got %d, %d, %v", sent, received, err)
+	}
+}
+
+func Test_ElementsByHash(t *testing.T) {
+	s := NewSet(1, 2, 3, 4)
+	h := DefaultHasher[int]()
+	got := sortedInts(ElementsByHash(s, []uint64{h(2), h(4), h(5)}, nil))
+	if fmt.Sprint(got) != "[2 4]" {
+		t.Errorf("Expected [2 4], got %v", got)
+	}
+	if ElementsByHash(s, nil, nil) != nil {
+		t.Error("Expected no elements for no hashes")
+	}
+}
@@ -0,0 +1,218 @@
+/*
+Open Source Initiative OSI - The MIT License (MIT):Licensing
+
+The MIT License (MIT)
+Copyright (c) 2013 - 2026 Ralph Caraveo (deckarep@gmail.com)
+
+Permission is hereby granted, free of charge, to any person obtaining a copy of
+this software and associated documentation files (the "Software"), to deal in
+the Software without restriction, including without limitation the rights to
+use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies
+of the Software, and to permit persons to whom the Software is furnished to do
+so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+
+package mapset
+
+import (
+	"errors"
+	"fmt"
+)
+
+var (
+	// ErrSavepointNotFound is returned when rolling back to a savepoint
+	// that was never created, was released, or recorded a state whose
+	// changes were undone and then replaced by new changes.
+	ErrSavepointNotFound = errors.New("mapset: savepoint not found")
+
+	// ErrSavepointExpired is returned when rolling back to a savepoint
+	// older than the history retained by a JournaledSet.
+	ErrSavepointExpired = errors.New("mapset: savepoint is older than the retained history")
+)
+
+// JournaledSetOptions configures a JournaledSet. The zero value is valid.
+type JournaledSetOptions struct {
+	// MaxHistory is the maximum number of changes that can be undone.
+	// Older changes are forgotten. If it is not positive the history
+	// is unbounded.
+	MaxHistory int
+}
+
+// JournaledSet is a Set that records every change made through it, so that
+// changes can be undone and redone and the set can be rolled back to named
+// savepoints. Each call that changes the set is one change in the
+// history, and the history stores only the elements each change added and
+// removed, so undoing a change costs time proportional to its size.
+//
+// Changes made directly to the wrapped set are not recorded, and undoing
+// changes after such changes may not restore an earlier state. Clone and
+// the methods that return a new set return sets without a history.
+//
+// Operations on a JournaledSet are thread-safe if the wrapped set is.
+type JournaledSet[T comparable] interface {
+	Set[T]
+
+	// Undo reverts the most recent change that has not been undone.
+	// Returns whether there was a change to undo.
+	Undo() bool
+
+	// Redo reapplies the most recently undone change. Making a new
+	// change discards the changes that could be redone. Returns
+	// whether there was a change to redo.
+	Redo() bool
+
+	// Savepoint records the current state of the set under name,
+	// replacing any savepoint with the same name.
+	Savepoint(name string)
+
+	// RollbackTo restores the state recorded by the named savepoint
+	// by undoing, or redoing, the changes made since. The savepoint
+	// is kept, and changes undone by RollbackTo can be redone.
+	RollbackTo(name string) error
+
+	// ReleaseSavepoint forgets the named savepoint.
+	ReleaseSavepoint(name string)
+}
+
+// NewJournaledSet returns a JournaledSet that wraps s, with an empty
+// history.
+func NewJournaledSet[T comparable](s Set[T], opts JournaledSetOptions) JournaledSet[T] {
+	j := &journaledSet[T]{
+		maxHistory: opts.MaxHistory,
+		savepoints: make(map[string]int),
+	}
+	j.Set = s
+	j.onChange = j.record
+	return j
+}
+
+// journalEntry is a change to the set.
+type journalEntry[T comparable] struct {
+	added   []T
+	removed []T
+}
+
+type journaledSet[T comparable] struct {
+	recordingSet[T]
+	maxHistory int
+
+	// undo holds the applied changes, oldest first, and redo the
+	// undone changes, most recently undone last. base counts the
+	// changes dropped from the front of undo, so that base+len(undo)
+	// identifies the current state.
+	undo []journalEntry[T]
+	redo []journalEntry[T]
+	base int
+
+	// savepoints maps names to states.
+	savepoints map[string]int
+}
+
+// Assert concrete type:journaledSet adheres to JournaledSet interface.
+var _ JournaledSet[string] = (*journaledSet[string])(nil)
+
+// position identifies the current state. It is called with s.mu held.
+func (s *journaledSet[T]) position() int {
+	return s.base + len(s.undo)
+}
+
+// record adds a change to the history. It is called with s.mu held.
+func (s *journaledSet[T]) record(added, removed []T) {
+	// The undone changes, and savepoints taken among them, can no
+	// longer be reached.
+	if len(s.redo) > 0 {
+		pos := s.position()
+		for name, p := range s.savepoints {
+			if p > pos {
+				delete(s.savepoints, name)
+			}
+		}
+		s.redo = nil
+	}
+
+	s.undo = append(s.undo, journalEntry[T]{added: added, removed: removed})
+	if s.maxHistory > 0 && len(s.undo) > s.maxHistory {
+		s.undo[0] = journalEntry[T]{}
+		s.undo = s.undo[1:]
+		s.base++
+	}
+}
+
+// private version of Undo which expects s.mu to be held
+func (s *journaledSet[T]) undoOne() bool {
+	if len(s.undo) == 0 {
+		return false
+	}
+	e := s.undo[len(s.undo)-1]
+	s.undo = s.undo[:len(s.undo)-1]
+	s.Set.RemoveAll(e.added...)
+	s.Set.Append(e.removed...)
+	s.redo = append(s.redo, e)
+	return true
+}
+
+// private version of Redo which expects s.mu to be held
+func (s *journaledSet[T]) redoOne() bool {
+	if len(s.redo) == 0 {
+		return false
+	}
+	e := s.redo[len(s.redo)-1]
+	s.redo = s.redo[:len(s.redo)-1]
+	s.Set.RemoveAll(e.removed...)
+	s.Set.Append(e.added...)
+	s.undo = append(s.undo, e)
+	return true
+}
+
+func (s *journaledSet[T]) Undo() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.undoOne()
+}
+
+func (s *journaledSet[T]) Redo() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.redoOne()
+}
+
+func (s *journaledSet[T]) Savepoint(name string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.savepoints[name] = s.position()
+}
+
+func (s *journaledSet[T]) RollbackTo(name string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	p, ok := s.savepoints[name]
+	if !ok {
+		return fmt.Errorf("%w: %q", ErrSavepointNotFound, name)
+	}
+	if p < s.base {
+		return fmt.Errorf("%w: %q", ErrSavepointExpired, name)
+	}
+	for s.position() > p && s.undoOne() {
+	}
+	for s.position() < p && s.redoOne() {
+	}
+	return nil
+}
+
+func (s *journaledSet[T]) ReleaseSavepoint(name string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.savepoints, name)
+}
@@ -0,0 +1,149 @@
+/*
+Open Source Initiative OSI - The MIT License (MIT):Licensing
+
+The MIT License (MIT)
+Copyright (c) 2013 - 2026 Ralph Caraveo (deckarep@gmail.com)
+
+Permission is hereby granted, free of charge, to any person obtaining a copy of
+this software and associated documentation files (the "Software"), to deal in
+the Software without restriction, including without limitation the rights to
+use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies
+of the Software, and to permit persons to whom the Software is furnished to do
+so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+
+package mapset
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+)
+
+func Test_JournaledSetUndoRedo(t *testing.T) {
+	for _, newSet := range []func(vs ...int) Set[int]{NewSet[int], NewThreadUnsafeSet[int]} {
+		s := NewJournaledSet(newSet(1), JournaledSetOptions{})
+		s.Add(2)
+		s.Append(3, 4)
+		s.Remove(1)
+		s.Add(2) // no change, not recorded
+
+		states := []Set[int]{newSet(1, 2, 3, 4), newSet(1, 2), newSet(1)}
+		for _, want := range states {
+			if !s.Undo() {
+				t.Fatal("Expected a change to undo")
+			}
+			if !s.Equal(want) {
+				t.Fatalf("Expected %v after Undo, got %v", want, s)
+			}
+		}
+		if s.Undo() {
+			t.Error("Expected no change to undo")
+		}
+
+		s.Redo()
+		s.Redo()
+		if !s.Equal(newSet(1, 2, 3, 4)) {
+			t.Errorf("Expected {1, 2, 3, 4} after Redo, got %v", s)
+		}
+
+		s.Add(5)
+		if s.Redo() {
+			t.Error("A new change should discard the changes that could be redone")
+		}
+	}
+}
+
+func Test_JournaledSetMutations(t *testing.T) {
+	s := NewJournaledSet(NewSet(1, 2, 3), JournaledSetOptions{})
+	s.Clear()
+	s.AppendFrom(NewSet(7, 8))
+	s.Pop()
+	s.PopN(5)
+	_ = json.Unmarshal([]byte(`[9]`), s)
+	s.RemoveAll(9)
+
+	for i := 0; i < 6; i++ {
+		if !s.Undo() {
+			t.Fatalf("Expected change %d to be undoable", i)
+		}
+	}
+	if !s.Equal(NewSet(1, 2, 3)) {
+		t.Errorf("Expected {1, 2, 3}, got %v", s)
+	}
+}
+
+func Test_JournaledSetSavepoints(t *testing.T) {
+	s := NewJournaledSet(NewSet[string](), JournaledSetOptions{})
+	s.Add("a")
+	s.Savepoint("one")
+	s.Append("b", "c")
+	s.Savepoint("two")
+	s.Remove("a")
+
+	if err := s.RollbackTo("one"); err != nil {
+		t.Fatal(err)
+	}
+	if !s.Equal(NewSet("a")) {
+		t.Errorf("Expected {a}, got %v", s)
+	}
+	if err := s.RollbackTo("two"); err != nil {
+		t.Fatal(err)
+	}
+	if !s.Equal(NewSet("a", "b", "c")) {
+		t.Errorf("Rolling forward should redo changes, got %v", s)
+	}
+
+	s.RollbackTo("one")
+	s.Add("d")
+	if err := s.RollbackTo("two"); !errors.Is(err, ErrSavepointNotFound) {
+		t.Errorf("A savepoint among discarded changes should be forgotten, got %v", err)
+	}
+
+	s.ReleaseSavepoint("one")
+	if err := s.RollbackTo("one"); !errors.Is(err, ErrSavepointNotFound) {
+		t.Errorf("Expected ErrSavepointNotFound, got %v", err)
+	}
+}
+
+func Test_JournaledSetMaxHistory(t *testing.T) {
+	s := NewJournaledSet(NewSet[int](), JournaledSetOptions{MaxHistory: 3})
+	s.Savepoint("empty")
+	for i := 0; i < 5; i++ {
+		s.Add(i)
+	}
+	s.Savepoint("full")
+
+	var n int
+	for s.Undo() {
+		n++
+	}
+	if n != 3 || !s.Equal(NewSet(0, 1)) {
+		t.Errorf("Expected 3 undoable changes leaving {0, 1}, got %d: %v", n, s)
+	}
+	if err := s.RollbackTo("empty"); !errors.Is(err, ErrSavepointExpired) {
+		t.Errorf("Expected ErrSavepointExpired, got %v", err)
+	}
+	if err := s.RollbackTo("full"); err != nil || s.Cardinality() != 5 {
+		t.Errorf("Expected to roll forward to 5 elements, got %v: %v", err, s)
+	}
+}
+
+func Test_JournaledSetWrapped(t *testing.T) {
+	s := NewJournaledSet(NewSet(1, 2), JournaledSetOptions{})
+	o := NewObservableSet(NewSet(2, 3))
+	if !s.Intersect(o).Equal(NewSet(2)) || !o.Union(s).Equal(NewSet(1, 2, 3)) {
+		t.Error("Wrapped sets should accept each other")
+	}
+}
@@ -0,0 +1,491 @@
+/*
+Open Source Initiative OSI - The MIT License (MIT):Licensing
+
+The MIT License (MIT)
+Copyright (c) 2013 - 2026 Ralph Caraveo (deckarep@gmail.com)
+
+Permission is hereby granted, free of charge, to any person obtaining a copy of
+this software and associated documentation files (the "Software"), to deal in
+the Software without restriction, including without limitation the rights to
+use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies
+of the Software, and to permit persons to whom the Software is furnished to do
+so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+
+package mapset
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// ErrCorruptMappedSet is returned when opening a file that is not a valid
+// mapped set.
+var ErrCorruptMappedSet = errors.New("mapset: corrupt mapped set")
+
+// MappedSet is an immutable set stored in a file written by
+// WriteMappedSet and memory-mapped by OpenMappedSet, so that opening a set
+// of any size takes constant time and only the pages touched by lookups
+// are read. Lookups encode the element and compare encodings in place;
+// only iteration decodes elements. On platforms without mmap support the
+// file is read into memory instead.
+//
+// MappedSet provides the read-only subset of the Set interface. Methods
+// that combine a MappedSet with another set accept any Set implementation
+// and return a new thread-safe Set. A MappedSet is safe for concurrent
+// use, if its Codec is, until it is closed. A Codec must encode equal
+// elements identically; JSONCodec does.
+//
+// OpenMappedSet checks only the size of the file, so that opening is
+// fast. A MappedSet panics with ErrCorruptMappedSet if it later finds the
+// file damaged, and panics if an element cannot be decoded, which means
+// the file was written with a different Codec.
+type MappedSet[T comparable] struct {
+	data    []byte
+	buckets []byte
+	entries []byte
+	elems   []byte
+	n       int
+	nb      int
+	codec   Codec[T]
+}
+
+// A mapped set file is a header, a bucket index, an entry for each
+// element and the encoded elements. All integers are little-endian.
+//
+// The header is the magic string, the version, three bytes of padding and
+// the number of elements, buckets and bytes of encoded elements as
+// 64-bit integers. Elements are placed in buckets by the hash of their
+// encoding, and the index holds, for each bucket and one past the last,
+// the position of the bucket's first entry. Each entry holds the hash
+// of an element and the offset just past its encoding.
+const (
+	mappedMagic      = "MSMP"
+	mappedVersion    = 1
+	mappedHeaderSize = 32
+	mappedEntrySize  = 16
+	mappedBucketSize = 4
+)
+
+// WriteMappedSet writes the elements of s to w in the format read by
+// OpenMappedSet, encoding them with codec, or JSONCodec if codec is nil.
+func WriteMappedSet[T comparable](w io.Writer, s Set[T], codec Codec[T]) error {
+	if codec == nil {
+		codec = JSONCodec[T]()
+	}
+	vs := s.ToSlice()
+	nb := (len(vs) + mappedBucketSize - 1) / mappedBucketSize
+	if nb == 0 {
+		nb = 1
+	}
+
+	encoded := make([][]byte, len(vs))
+	hashes := make([]uint64, len(vs))
+	counts := make([]uint64, nb+1)
+	var dataLen uint64
+	for i, v := range vs {
+		b, err := codec.Encode(v)
+		if err != nil {
+			return err
+		}
+		encoded[i] = b
+		hashes[i] = hashBytes(b)
+		counts[fastrange(hashes[i], nb)+1]++
+		dataLen += uint64(len(b))
+	}
+
+	// Order elements by bucket with a counting sort.
+	for k := 1; k <= nb; k++ {
+		counts[k] += counts[k-1]
+	}
+	order := make([]int, len(vs))
+	next := append([]uint64(nil), counts[:nb]...)
+	for i, h := range hashes {
+		k := fastrange(h, nb)
+		order[next[k]] = i
+		next[k]++
+	}
+
+	bw := bufio.NewWriter(w)
+	header := make([]byte, 0, mappedHeaderSize)
+	header = append(append(header, mappedMagic...), mappedVersion, 0, 0, 0)
+	header = appendUint64(header, uint64(len(vs)))
+	header = appendUint64(header, uint64(nb))
+	header = appendUint64(header, dataLen)
+	bw.Write(header)
+
+	var buf []byte
+	for _, c := range counts {
+		buf = appendUint64(buf[:0], c)
+		bw.Write(buf)
+	}
+	var end uint64
+	for _, i := range order {
+		end += uint64(len(encoded[i]))
+		buf = appendUint64(appendUint64(buf[:0], hashes[i]), end)
+		bw.Write(buf)
+	}
+	for _, i := range order {
+		bw.Write(encoded[i])
+	}
+	return bw.Flush()
+}
+
+// WriteMappedSetFile writes the elements of s to the named file in the
+// format read by OpenMappedSet. The file is written to a temporary file
+// that is synced and then renamed, so readers never see a partly written
+// set, even after a crash.
+func WriteMappedSetFile[T comparable](name string, s Set[T], codec Codec[T]) error {
+	tmp := name + ".tmp"
+	f, err := os.Create(tmp)
+	if err != nil {
+		return err
+	}
+	err = WriteMappedSet(f, s, codec)
+	if err == nil {
+		err = f.Sync()
+	}
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	if err := os.Rename(tmp, name); err != nil {
+		return err
+	}
+	syncDir(filepath.Dir(name))
+	return nil
+}
+
+// OpenMappedSet opens the named file written by WriteMappedSet, decoding
+// elements with codec, or JSONCodec if codec is nil. The returned set
+// must be closed to release the mapping.
+func OpenMappedSet[T comparable](name string, codec Codec[T]) (*MappedSet[T], error) {
+	if codec == nil {
+		codec = JSONCodec[T]()
+	}
+	f, err := os.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	info, err := f.Stat()
+	if err != nil {
+		return nil, err
+	}
+	size := info.Size()
+	if size < mappedHeaderSize || int64(int(size)) != size {
+		return nil, fmt.Errorf("%w: %s", ErrCorruptMappedSet, name)
+	}
+	data, err := mapFile(f, int(size))
+	if err != nil {
+		return nil, err
+	}
+
+	m, ok := newMappedSet(data, codec)
+	if !ok {
+		unmapFile(data)
+		return nil, fmt.Errorf("%w: %s", ErrCorruptMappedSet, name)
+	}
+	return m, nil
+}
+
+// newMappedSet checks the header of data and returns the set it holds.
+func newMappedSet[T comparable](data []byte, codec Codec[T]) (*MappedSet[T], bool) {
+	if string(data[:len(mappedMagic)]) != mappedMagic || data[len(mappedMagic)] != mappedVersion {
+		return nil, false
+	}
+	n := binary.LittleEndian.Uint64(data[8:])
+	nb := binary.LittleEndian.Uint64(data[16:])
+	dataLen := binary.LittleEndian.Uint64(data[24:])
+
+	// Check each size against the remaining length before multiplying,
+	// so that the products cannot overflow.
+	rest := uint64(len(data) - mappedHeaderSize)
+	if nb == 0 || nb >= rest/8 || n > (rest-(nb+1)*8)/mappedEntrySize ||
+		dataLen != rest-(nb+1)*8-n*mappedEntrySize {
+		return nil, false
+	}
+
+	m := &MappedSet[T]{data: data, n: int(n), nb: int(nb), codec: codec}
+	rem := data[mappedHeaderSize:]
+	m.buckets, rem = rem[:(nb+1)*8], rem[(nb+1)*8:]
+	m.entries, m.elems = rem[:n*mappedEntrySize], rem[n*mappedEntrySize:]
+	// Read the index directly, as bucket panics on damage found
+	// after opening.
+	if binary.LittleEndian.Uint64(m.buckets) != 0 || binary.LittleEndian.Uint64(m.buckets[nb*8:]) != n {
+		return nil, false
+	}
+	return m, true
+}
+
+// Close releases the mapping. The set must not be used afterwards.
+func (m *MappedSet[T]) Close() error {
+	data := m.data
+	*m = MappedSet[T]{}
+	if data == nil {
+		return nil
+	}
+	return unmapFile(data)
+}
+
+// corrupt reports damage found after the set was opened.
+func (m *MappedSet[T]) corrupt() {
+	panic(ErrCorruptMappedSet)
+}
+
+// bucket returns the position of the first entry in bucket k.
+func (m *MappedSet[T]) bucket(k int) int {
+	i := binary.LittleEndian.Uint64(m.buckets[k*8:])
+	if i > uint64(m.n) {
+		m.corrupt()
+	}
+	return int(i)
+}
+
+func (m *MappedSet[T]) hash(i int) uint64 {
+	return binary.LittleEndian.Uint64(m.entries[i*mappedEntrySize:])
+}
+
+// element returns the encoding of the i'th element, which aliases the
+// mapping.
+func (m *MappedSet[T]) element(i int) []byte {
+	var start uint64
+	if i > 0 {
+		start = binary.LittleEndian.Uint64(m.entries[(i-1)*mappedEntrySize+8:])
+	}
+	end := binary.LittleEndian.Uint64(m.entries[i*mappedEntrySize+8:])
+	if start > end || end > uint64(len(m.elems)) {
+		m.corrupt()
+	}
+	return m.elems[start:end]
+}
+
+func (m *MappedSet[T]) contains(v T) bool {
+	b, err := m.codec.Encode(v)
+	if err != nil {
+		// Every element was encoded when the set was written.
+		return false
+	}
+	h := hashBytes(b)
+	k := fastrange(h, m.nb)
+	for i, end := m.bucket(k), m.bucket(k+1); i < end; i++ {
+		if m.hash(i) == h && bytes.Equal(m.element(i), b) {
+			return true
+		}
+	}
+	return false
+}
+
+// Cardinality returns the number of elements in the set.
+func (m *MappedSet[T]) Cardinality() int {
+	return m.n
+}
+
+// IsEmpty determines if there are elements in the set.
+func (m *MappedSet[T]) IsEmpty() bool {
+	return m.n == 0
+}
+
+// ContainsOne returns whether the given item is in the set.
+func (m *MappedSet[T]) ContainsOne(val T) bool {
+	return m.contains(val)
+}
+
+// Contains returns whether the given items are all in the set.
+func (m *MappedSet[T]) Contains(val ...T) bool {
+	for _, v := range val {
+		if !m.contains(v) {
+			return false
+		}
+	}
+	return true
+}
+
+// ContainsAny returns whether at least one of the given items
+// are in the set.
+func (m *MappedSet[T]) ContainsAny(val ...T) bool {
+	for _, v := range val {
+		if m.contains(v) {
+			return true
+		}
+	}
+	return false
+}
+
+// ContainsAnyElement returns whether at least one of the elements of
+// other is in the set.
+func (m *MappedSet[T]) ContainsAnyElement(other Set[T]) bool {
+	var found bool
+	other.Each(func(v T) bool {
+		found = m.contains(v)
+		return found
+	})
+	return found
+}
+
+// Each iterates over elements and executes the passed func against each
+// element. If passed func returns true, stop iteration at the time.
+func (m *MappedSet[T]) Each(cb func(T) bool) {
+	for i := 0; i < m.n; i++ {
+		v, err := m.codec.Decode(m.element(i))
+		if err != nil {
+			panic(fmt.Sprintf("mapset: cannot decode mapped element: %v", err))
+		}
+		if cb(v) {
+			break
+		}
+	}
+}
+
+// Equal determines if the set and other contain the same elements.
+func (m *MappedSet[T]) Equal(other Set[T]) bool {
+	return m.n == other.Cardinality() && m.IsSuperset(other)
+}
+
+// IsSubset determines if every element in this set is in other.
+func (m *MappedSet[T]) IsSubset(other Set[T]) bool {
+	o := toThreadUnsafeSet(other)
+	if m.n > o.Cardinality() {
+		return false
+	}
+	isSubset := true
+	m.Each(func(v T) bool {
+		isSubset = o.contains(v)
+		return !isSubset
+	})
+	return isSubset
+}
+
+// IsSuperset determines if every element in other is in this set.
+func (m *MappedSet[T]) IsSuperset(other Set[T]) bool {
+	isSuperset := true
+	other.Each(func(v T) bool {
+		isSuperset = m.contains(v)
+		return !isSuperset
+	})
+	return isSuperset
+}
+
+// Iter returns a channel of elements that you can range over.
+func (m *MappedSet[T]) Iter() <-chan T {
+	ch := make(chan T)
+	go func() {
+		m.Each(func(v T) bool {
+			ch <- v
+			return false
+		})
+		close(ch)
+	}()
+
+	return ch
+}
+
+// Iterator returns an Iterator object that you can use to range over
+// the set.
+func (m *MappedSet[T]) Iterator() *Iterator[T] {
+	iterator, ch, stopCh := newIterator[T]()
+
+	go func() {
+		m.Each(func(v T) bool {
+			select {
+			case <-stopCh:
+				return true
+			case ch <- v:
+				return false
+			}
+		})
+		close(ch)
+	}()
+
+	return iterator
+}
+
+// ToSlice returns the members of the set as a slice.
+func (m *MappedSet[T]) ToSlice() []T {
+	vs := make([]T, 0, m.n)
+	m.Each(func(v T) bool {
+		vs = append(vs, v)
+		return false
+	})
+	return vs
+}
+
+// ToSet returns a mutable, thread-safe copy of the set.
+func (m *MappedSet[T]) ToSet() Set[T] {
+	return NewSet(m.ToSlice()...)
+}
+
+// String provides a convenient string representation of the set.
+func (m *MappedSet[T]) String() string {
+	items := make([]string, 0, m.n)
+	m.Each(func(v T) bool {
+		items = append(items, fmt.Sprintf("%v", v))
+		return false
+	})
+	return fmt.Sprintf("MappedSet{%s}", strings.Join(items, ", "))
+}
+
+func (m *MappedSet[T]) toThreadUnsafeSet() *threadUnsafeSet[T] {
+	s := newThreadUnsafeSetWithSize[T](m.n)
+	m.Each(func(v T) bool {
+		s.add(v)
+		return false
+	})
+	return s
+}
+
+// Difference returns a new set with the elements of this set that
+// are not in other.
+func (m *MappedSet[T]) Difference(other Set[T]) Set[T] {
+	diff := m.toThreadUnsafeSet().Difference(toThreadUnsafeSet(other)).(*threadUnsafeSet[T])
+	return &threadSafeSet[T]{uss: diff}
+}
+
+// Intersect returns a new set with the elements in both this set
+// and other.
+func (m *MappedSet[T]) Intersect(other Set[T]) Set[T] {
+	intersection := newThreadSafeSet[T]()
+	other.Each(func(v T) bool {
+		if m.contains(v) {
+			intersection.uss.add(v)
+		}
+		return false
+	})
+	return intersection
+}
+
+// SymmetricDifference returns a new set with the elements in exactly
+// one of this set and other.
+func (m *MappedSet[T]) SymmetricDifference(other Set[T]) Set[T] {
+	sd := m.toThreadUnsafeSet().SymmetricDifference(toThreadUnsafeSet(other)).(*threadUnsafeSet[T])
+	return &threadSafeSet[T]{uss: sd}
+}
+
+// Union returns a new set with the elements in either this set or other.
+func (m *MappedSet[T]) Union(other Set[T]) Set[T] {
+	union := m.toThreadUnsafeSet()
+	union.append(other.ToSlice()...)
+	return &threadSafeSet[T]{uss: union}
+}
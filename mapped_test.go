@@ -0,0 +1,156 @@
+/*
+Open Source Initiative OSI - The MIT License (MIT):Licensing
+
+The MIT License (MIT)
+Copyright (c) 2013 - 2026 Ralph Caraveo (deckarep@gmail.com)
+
+Permission is hereby granted, free of charge, to any person obtaining a copy of
+this software and associated documentation files (the "Software"), to deal in
+the Software without restriction, including without limitation the rights to
+use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies
+of the Software, and to permit persons to whom the Software is furnished to do
+so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+
+package mapset
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func writeMapped[T comparable](t *testing.T, s Set[T]) *MappedSet[T] {
+	t.Helper()
+	name := filepath.Join(t.TempDir(), "set")
+	if err := WriteMappedSetFile(name, s, nil); err != nil {
+		t.Fatal(err)
+	}
+	m, err := OpenMappedSet[T](name, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { m.Close() })
+	return m
+}
+
+func TestMappedSetRoundTrip(t *testing.T) {
+	s := rangeSet(0, 10000)
+	m := writeMapped(t, s)
+
+	if m.Cardinality() != 10000 {
+		t.Fatalf("expected 10000 elements, got %d", m.Cardinality())
+	}
+	for i := -100; i < 10100; i++ {
+		if m.ContainsOne(i) != s.ContainsOne(i) {
+			t.Fatalf("ContainsOne(%d) disagreed with Set", i)
+		}
+	}
+	if !m.Equal(s) || !s.Equal(NewThreadUnsafeSet(m.ToSlice()...)) {
+		t.Error("expected mapped set to equal the original")
+	}
+}
+
+func TestMappedSetEmpty(t *testing.T) {
+	m := writeMapped(t, NewSet[string]())
+	if !m.IsEmpty() || m.ContainsOne("") || len(m.ToSlice()) != 0 {
+		t.Errorf("expected empty set, got %v", m)
+	}
+	if m.String() != "MappedSet{}" {
+		t.Errorf("unexpected string %q", m.String())
+	}
+}
+
+func TestMappedSetStructElements(t *testing.T) {
+	s := NewSet(point{1, 2}, point{3, 4})
+	m := writeMapped[point](t, s)
+	if !m.Contains(point{1, 2}, point{3, 4}) || m.ContainsOne(point{2, 1}) {
+		t.Errorf("unexpected membership: %v", m)
+	}
+}
+
+func TestMappedSetOperations(t *testing.T) {
+	m := writeMapped(t, NewSet(1, 2, 3))
+	other := NewSet(2, 3, 4)
+
+	if !m.Union(other).Equal(NewSet(1, 2, 3, 4)) {
+		t.Error("unexpected union")
+	}
+	if !m.Intersect(other).Equal(NewSet(2, 3)) {
+		t.Error("unexpected intersection")
+	}
+	if !m.Difference(other).Equal(NewSet(1)) {
+		t.Error("unexpected difference")
+	}
+	if !m.SymmetricDifference(other).Equal(NewSet(1, 4)) {
+		t.Error("unexpected symmetric difference")
+	}
+	if !m.IsSubset(NewSet(1, 2, 3, 5)) || !m.IsSuperset(NewSet(1, 3)) || m.IsSuperset(other) {
+		t.Error("unexpected subset result")
+	}
+	if !m.ContainsAnyElement(other) || !m.ContainsAny(9, 3) || m.Contains(1, 9) {
+		t.Error("unexpected containment result")
+	}
+
+	var n int
+	for range m.Iter() {
+		n++
+	}
+	if n != 3 {
+		t.Errorf("expected to iterate over 3 elements, got %d", n)
+	}
+	it := m.Iterator()
+	<-it.C
+	it.Stop()
+}
+
+func TestMappedSetCorrupt(t *testing.T) {
+	var buf bytes.Buffer
+	if err := WriteMappedSet(&buf, NewSet("a", "b", "c"), nil); err != nil {
+		t.Fatal(err)
+	}
+	data := buf.Bytes()
+
+	badMagic := append([]byte("XXXX"), data[4:]...)
+	badIndex := append([]byte(nil), data...)
+	binary.LittleEndian.PutUint64(badIndex[mappedHeaderSize:], 1<<40)
+	for name, b := range map[string][]byte{
+		"truncated": data[:len(data)-1],
+		"extended":  append(append([]byte(nil), data...), 0),
+		"magic":     badMagic,
+		"header":    data[:mappedHeaderSize-1],
+		"index":     badIndex,
+	} {
+		path := filepath.Join(t.TempDir(), "set")
+		if err := os.WriteFile(path, b, 0o644); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := OpenMappedSet[string](path, nil); !errors.Is(err, ErrCorruptMappedSet) {
+			t.Errorf("%s: expected ErrCorruptMappedSet, got %v", name, err)
+		}
+	}
+}
+
+func TestMappedSetClose(t *testing.T) {
+	m := writeMapped(t, NewSet(1))
+	if err := m.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := m.Close(); err != nil {
+		t.Errorf("expected second Close to succeed, got %v", err)
+	}
+}
@@ -0,0 +1,254 @@
+/*
+Open Source Initiative OSI - The MIT License (MIT):Licensing
+
+The MIT License (MIT)
+Copyright (c) 2013 - 2026 Ralph Caraveo (deckarep@gmail.com)
+
+Permission is hereby granted, free of charge, to any person obtaining a copy of
+this software and associated documentation files (the "Software"), to deal in
+the Software without restriction, including without limitation the rights to
+use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies
+of the Software, and to permit persons to whom the Software is furnished to do
+so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+
+package mapset
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math/bits"
+)
+
+// MaxMerkleDepth is the maximum depth of a MerkleTree.
+const MaxMerkleDepth = 24
+
+// MerkleTree summarizes a set as a binary tree of digests, used to locate
+// the differences between two large replicas by exchanging only digests
+// for the parts of the tree that differ.
+//
+// Elements are partitioned into 2^depth leaf buckets by the leading bits
+// of their hash. The digest of a node at level l, covering the buckets
+// with a common l-bit hash prefix, is an order-independent digest of the
+// elements under it, so it does not depend on the depth of the tree and
+// summaries of different depths can be compared at the levels they share.
+//
+// Both sides must use the same Hasher and it must be stable across
+// processes. A MerkleTree is a snapshot; it does not observe later changes
+// to the set it was built from.
+type MerkleTree[T comparable] struct {
+	depth   int
+	levels  [][]uint64
+	buckets [][]T
+	hasher  Hasher[T]
+}
+
+// MerkleSummary builds and returns a MerkleTree for s, with a depth chosen
+// to leave a few elements in each leaf bucket. If hasher is nil,
+// DefaultHasher is used.
+func MerkleSummary[T comparable](s Set[T], hasher Hasher[T]) *MerkleTree[T] {
+	return MerkleSummaryWithDepth(s, bits.Len(uint(s.Cardinality()/8)), hasher)
+}
+
+// MerkleSummaryWithDepth builds and returns a MerkleTree for s with 2^depth
+// leaf buckets. It panics if depth is negative or greater than
+// MaxMerkleDepth. If hasher is nil, DefaultHasher is used.
+func MerkleSummaryWithDepth[T comparable](s Set[T], depth int, hasher Hasher[T]) *MerkleTree[T] {
+	if depth < 0 || depth > MaxMerkleDepth {
+		panic(fmt.Sprintf("mapset: Merkle tree depth %d out of range [0, %d]", depth, MaxMerkleDepth))
+	}
+	t := newMerkleTree(depth, hasherOrDefault(hasher))
+	t.buckets = make([][]T, 1<<depth)
+	leaves := t.levels[depth]
+	s.Each(func(v T) bool {
+		h := t.hasher(v)
+		i := merkleBucket(h, depth)
+		t.buckets[i] = append(t.buckets[i], v)
+		leaves[i] += merkleDigest(h)
+		return false
+	})
+	t.sumLevels()
+	return t
+}
+
+func newMerkleTree[T comparable](depth int, hasher Hasher[T]) *MerkleTree[T] {
+	levels := make([][]uint64, depth+1)
+	for l := range levels {
+		levels[l] = make([]uint64, 1<<l)
+	}
+	return &MerkleTree[T]{depth: depth, levels: levels, hasher: hasher}
+}
+
+// sumLevels computes the interior digests from the leaves.
+func (t *MerkleTree[T]) sumLevels() {
+	for l := t.depth - 1; l >= 0; l-- {
+		for i := range t.levels[l] {
+			t.levels[l][i] = t.levels[l+1][2*i] + t.levels[l+1][2*i+1]
+		}
+	}
+}
+
+// merkleBucket returns the leaf bucket of hash h in a tree of the given
+// depth.
+func merkleBucket(h uint64, depth int) int {
+	if depth == 0 {
+		return 0
+	}
+	return int(h >> (64 - depth))
+}
+
+// merkleDigest returns the contribution of an element with hash h to the
+// digests of its nodes. It is decorrelated from the bucket bits of h.
+func merkleDigest(h uint64) uint64 {
+	return mix64(h ^ 0x632be59bd9b4e019)
+}
+
+// Depth returns the depth of the tree; it has 2^depth leaf buckets.
+func (t *MerkleTree[T]) Depth() int {
+	return t.depth
+}
+
+// Root returns the digest of the whole set. Summaries of equal sets have
+// equal roots.
+func (t *MerkleTree[T]) Root() uint64 {
+	return t.levels[0][0]
+}
+
+// Digests returns the digests of the given nodes at level, where node i
+// covers the elements whose hash begins with the level-bit prefix i. It
+// panics if level is greater than the depth of the tree.
+func (t *MerkleTree[T]) Digests(level int, nodes []int) []uint64 {
+	ds := make([]uint64, len(nodes))
+	for i, n := range nodes {
+		ds[i] = t.levels[level][n]
+	}
+	return ds
+}
+
+// Elements returns the elements of the tree's set under the given nodes
+// at level. A tree decoded with UnmarshalBinary holds only digests, so it
+// has no elements.
+func (t *MerkleTree[T]) Elements(level int, nodes []int) []T {
+	if t.buckets == nil {
+		return nil
+	}
+	span := 1 << (t.depth - level)
+	var vs []T
+	for _, n := range nodes {
+		for _, b := range t.buckets[n*span : (n+1)*span] {
+			vs = append(vs, b...)
+		}
+	}
+	return vs
+}
+
+// DiffNodes compares the tree with a remote one level by level, down to
+// the given depth, and returns the nodes at that depth whose digests
+// differ. fetch returns the remote digests of the given nodes at a level,
+// typically by calling Digests on the remote tree over the network; it is
+// called once per level, and only for the children of nodes that differ.
+func (t *MerkleTree[T]) DiffNodes(depth int, fetch func(level int, nodes []int) ([]uint64, error)) ([]int, error) {
+	if depth < 0 || depth > t.depth {
+		return nil, fmt.Errorf("%w: depth %d is outside tree depth %d", ErrIncompatibleSketches, depth, t.depth)
+	}
+	nodes := []int{0}
+	for level := 0; ; level++ {
+		remote, err := fetch(level, nodes)
+		if err != nil {
+			return nil, err
+		}
+		if len(remote) != len(nodes) {
+			return nil, fmt.Errorf("mapset: expected %d digests, got %d", len(nodes), len(remote))
+		}
+
+		var differ []int
+		for i, n := range nodes {
+			if t.levels[level][n] != remote[i] {
+				differ = append(differ, n)
+			}
+		}
+		if level == depth || len(differ) == 0 {
+			return differ, nil
+		}
+
+		// fetch may keep the slice it was given, so each level gets
+		// a new one.
+		nodes = make([]int, 0, 2*len(differ))
+		for _, n := range differ {
+			nodes = append(nodes, 2*n, 2*n+1)
+		}
+	}
+}
+
+// Diff compares the tree with a remote one and returns the local elements
+// in the buckets whose digests differ, which are the elements to send to
+// the remote replica. Buckets are compared at the lesser of the two
+// trees' depths.
+//
+// For replicas to converge on the union of their sets, each side sends
+// the result of Diff to the other. Elements the other side already holds
+// may be included.
+func (t *MerkleTree[T]) Diff(remote *MerkleTree[T]) []T {
+	depth := t.depth
+	if remote.depth < depth {
+		depth = remote.depth
+	}
+	nodes, _ := t.DiffNodes(depth, func(level int, nodes []int) ([]uint64, error) {
+		return remote.Digests(level, nodes), nil
+	})
+	return t.Elements(depth, nodes)
+}
+
+const (
+	merkleMagic   = "MSMK"
+	merkleVersion = 1
+)
+
+// MarshalBinary encodes the digests of the tree. Elements are not encoded.
+func (t *MerkleTree[T]) MarshalBinary() ([]byte, error) {
+	leaves := t.levels[t.depth]
+	b := make([]byte, 0, 6+8*len(leaves))
+	b = append(b, merkleMagic...)
+	b = append(b, merkleVersion, byte(t.depth))
+	for _, d := range leaves {
+		b = appendUint64(b, d)
+	}
+	return b, nil
+}
+
+// UnmarshalBinary decodes a tree encoded by MarshalBinary, replacing the
+// contents of t. The decoded tree holds only digests, for comparison with
+// a local tree. The receiver's Hasher is kept, or DefaultHasher is used if
+// it has none.
+func (t *MerkleTree[T]) UnmarshalBinary(b []byte) error {
+	if len(b) < 6 || string(b[:4]) != merkleMagic {
+		return errors.New("mapset: invalid Merkle tree encoding")
+	}
+	if b[4] != merkleVersion {
+		return fmt.Errorf("mapset: unsupported Merkle tree version %d", b[4])
+	}
+	depth := int(b[5])
+	b = b[6:]
+	if depth > MaxMerkleDepth || len(b) != 8<<depth {
+		return errors.New("mapset: invalid Merkle tree encoding")
+	}
+
+	*t = *newMerkleTree(depth, hasherOrDefault(t.hasher))
+	for i := range t.levels[depth] {
+		t.levels[depth][i] = binary.LittleEndian.Uint64(b[8*i:])
+	}
+	t.sumLevels()
+	return nil
+}
@@ -0,0 +1,197 @@
+/*
+Open Source Initiative OSI - The MIT License (MIT):Licensing
+
+The MIT License (MIT)
+Copyright (c) 2013 - 2026 Ralph Caraveo (deckarep@gmail.com)
+
+Permission is hereby granted, free of charge, to any person obtaining a copy of
+this software and associated documentation files (the "Software"), to deal in
+the Software without restriction, including without limitation the rights to
+use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies
+of the Software, and to permit persons to whom the Software is furnished to do
+so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+
+package mapset
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"testing"
+)
+
+func Test_MerkleSummaryRoot(t *testing.T) {
+	a := MerkleSummary(rangeSet(0, 1000), nil)
+	b := MerkleSummary(NewSet(sortedInts(rangeSet(0, 1000).ToSlice())...), nil)
+	if a.Root() != b.Root() {
+		t.Error("Summaries of equal sets should have equal roots")
+	}
+	if a.Depth() != 7 {
+		t.Errorf("Expected depth 7 for 1000 elements, got %d", a.Depth())
+	}
+	if a.Root() == MerkleSummary(rangeSet(0, 1001), nil).Root() {
+		t.Error("Summaries of different sets should have different roots")
+	}
+	if MerkleSummary(NewSet[int](), nil).Root() != 0 {
+		t.Error("The empty set should have a zero root")
+	}
+}
+
+func Test_MerkleSummaryDepthIndependent(t *testing.T) {
+	s := rangeSet(0, 500)
+	a := MerkleSummaryWithDepth(s, 3, nil)
+	b := MerkleSummaryWithDepth(s, 10, nil)
+	for l := 0; l <= 3; l++ {
+		nodes := make([]int, 1<<l)
+		for i := range nodes {
+			nodes[i] = i
+		}
+		if fmt.Sprint(a.Digests(l, nodes)) != fmt.Sprint(b.Digests(l, nodes)) {
+			t.Errorf("Level %d digests should not depend on depth", l)
+		}
+	}
+	if len(b.Diff(a)) != 0 {
+		t.Error("Summaries of the same set should not differ")
+	}
+}
+
+func Test_MerkleSummaryWithDepthPanics(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Error("Expected a panic for an out of range depth")
+		}
+	}()
+	MerkleSummaryWithDepth(NewSet[int](), MaxMerkleDepth+1, nil)
+}
+
+func Test_MerkleDiffNodes(t *testing.T) {
+	a := rangeSet(0, 10000)
+	b := rangeSet(0, 10000)
+	b.Remove(42)
+	b.Add(-1)
+
+	ta, tb := MerkleSummary(a, nil), MerkleSummary(b, nil)
+	var fetched int
+	var kept, copies [][]int
+	nodes, err := ta.DiffNodes(ta.Depth(), func(level int, nodes []int) ([]uint64, error) {
+		fetched += len(nodes)
+		kept = append(kept, nodes)
+		copies = append(copies, append([]int(nil), nodes...))
+		return tb.Digests(level, nodes), nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(nodes) == 0 || len(nodes) > 2 {
+		t.Fatalf("Expected 1 or 2 differing buckets, got %d", len(nodes))
+	}
+	if fetched > 4*(ta.Depth()+1) {
+		t.Errorf("Expected to fetch only digests along differing paths, fetched %d", fetched)
+	}
+	if !reflect.DeepEqual(kept, copies) {
+		t.Error("Expected slices passed to fetch not to be modified afterwards")
+	}
+
+	got := NewSet(ta.Elements(ta.Depth(), nodes)...)
+	if !got.Contains(42) || got.Cardinality() > 40 {
+		t.Errorf("Expected a few elements including 42, got %d", got.Cardinality())
+	}
+
+	if _, err := ta.DiffNodes(ta.Depth()+1, nil); !errors.Is(err, ErrIncompatibleSketches) {
+		t.Errorf("Expected ErrIncompatibleSketches comparing below the tree's depth, got %v", err)
+	}
+	if _, err := ta.DiffNodes(-1, nil); !errors.Is(err, ErrIncompatibleSketches) {
+		t.Errorf("Expected ErrIncompatibleSketches comparing at a negative depth, got %v", err)
+	}
+}
+
+// merklePeer is a replica that syncs with another by exchanging encoded
+// summaries and the elements of differing buckets.
+type merklePeer struct {
+	set Set[string]
+}
+
+func (p *merklePeer) summary() []byte {
+	b, _ := MerkleSummary(p.set, nil).MarshalBinary()
+	return b
+}
+
+// send returns the elements of p in the buckets where p differs from the
+// encoded summary remote.
+func (p *merklePeer) send(remote []byte) ([]string, error) {
+	var r MerkleTree[string]
+	if err := r.UnmarshalBinary(remote); err != nil {
+		return nil, err
+	}
+	return MerkleSummary(p.set, nil).Diff(&r), nil
+}
+
+func Test_MerklePeers(t *testing.T) {
+	a := &merklePeer{set: NewSet[string]()}
+	b := &merklePeer{set: NewSet[string]()}
+	for i := 0; i < 20000; i++ {
+		id := fmt.Sprintf("id-%d", i)
+		a.set.Add(id)
+		b.set.Add(id)
+	}
+	a.set.Append("only-a-1", "only-a-2")
+	b.set.Add("only-b-1")
+
+	toB, err := a.send(b.summary())
+	if err != nil {
+		t.Fatal(err)
+	}
+	toA, err := b.send(a.summary())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(toA)+len(toB) > 100 {
+		t.Errorf("Expected to transfer a few buckets, transferred %d elements", len(toA)+len(toB))
+	}
+
+	a.set.Append(toA...)
+	b.set.Append(toB...)
+	if !a.set.Equal(b.set) || a.set.Cardinality() != 20003 {
+		t.Error("Peers should hold the union of their sets after syncing")
+	}
+	if toB, _ := a.send(b.summary()); len(toB) != 0 {
+		t.Error("Synced peers should have nothing to transfer")
+	}
+}
+
+func Test_MerkleUnmarshalBinary(t *testing.T) {
+	tr := MerkleSummary(rangeSet(0, 100), nil)
+	b, err := tr.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var r MerkleTree[int]
+	if err := r.UnmarshalBinary(b); err != nil {
+		t.Fatal(err)
+	}
+	if r.Root() != tr.Root() || r.Depth() != tr.Depth() {
+		t.Error("A decoded tree should have the same digests")
+	}
+	if r.Elements(0, []int{0}) != nil {
+		t.Error("A decoded tree should hold no elements")
+	}
+
+	for _, bad := range [][]byte{nil, b[:5], b[:len(b)-1], append([]byte("XXXX"), b[4:]...)} {
+		if err := r.UnmarshalBinary(bad); err == nil {
+			t.Errorf("Expected an error decoding %d bytes", len(bad))
+		}
+	}
+}
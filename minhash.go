@@ -0,0 +1,273 @@
+/*
+Open Source Initiative OSI - The MIT License (MIT):Licensing
+
+The MIT License (MIT)
+Copyright (c) 2013 - 2026 Ralph Caraveo (deckarep@gmail.com)
+
+Permission is hereby granted, free of charge, to any person obtaining a copy of
+this software and associated documentation files (the "Software"), to deal in
+the Software without restriction, including without limitation the rights to
+use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies
+of the Software, and to permit persons to whom the Software is furnished to do
+so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+
+package mapset
+
+import (
+	"fmt"
+	"math"
+	"sort"
+)
+
+// MinHasher computes MinHash signatures of sets. The fraction of positions
+// at which the signatures of two sets agree is an unbiased estimate of
+// their Jaccard similarity, so similar sets can be found by comparing
+// short, fixed-size signatures instead of the sets themselves.
+//
+// Signatures are only comparable if computed by MinHashers with the same
+// number of hashes and the same Hasher. A MinHasher is safe for
+// concurrent use.
+type MinHasher[T comparable] struct {
+	seeds  []uint64
+	hasher Hasher[T]
+}
+
+// MinHashSignature is the MinHash signature of a set.
+type MinHashSignature []uint64
+
+// NewMinHasher creates and returns a MinHasher producing signatures of
+// numHashes values. The standard error of similarity estimates is about
+// 1/sqrt(numHashes). If hasher is nil, DefaultHasher is used.
+// NewMinHasher panics if numHashes is not positive.
+func NewMinHasher[T comparable](numHashes int, hasher Hasher[T]) *MinHasher[T] {
+	if numHashes <= 0 {
+		panic(fmt.Sprintf("mapset: MinHash size must be positive, got %d", numHashes))
+	}
+	// Seeds are derived deterministically so that signatures computed
+	// in different processes can be compared.
+	seeds := make([]uint64, numHashes)
+	for i := range seeds {
+		seeds[i] = mix64(uint64(i) + 0x9e3779b97f4a7c15)
+	}
+	return &MinHasher[T]{
+		seeds:  seeds,
+		hasher: hasherOrDefault(hasher),
+	}
+}
+
+// Size returns the number of values in the signatures produced.
+func (m *MinHasher[T]) Size() int {
+	return len(m.seeds)
+}
+
+// Signature returns the MinHash signature of s.
+func (m *MinHasher[T]) Signature(s Set[T]) MinHashSignature {
+	sig := make(MinHashSignature, len(m.seeds))
+	for i := range sig {
+		sig[i] = math.MaxUint64
+	}
+	s.Each(func(v T) bool {
+		h := m.hasher(v)
+		for i, seed := range m.seeds {
+			if x := mix64(h ^ seed); x < sig[i] {
+				sig[i] = x
+			}
+		}
+		return false
+	})
+	return sig
+}
+
+// Jaccard returns the estimated Jaccard similarity of the sets whose
+// signatures are sig and other. It panics if the signatures differ in
+// size.
+func (sig MinHashSignature) Jaccard(other MinHashSignature) float64 {
+	if len(sig) != len(other) {
+		panic(fmt.Sprintf("mapset: cannot compare MinHash signatures of size %d and %d", len(sig), len(other)))
+	}
+	if len(sig) == 0 {
+		return 0
+	}
+	var same int
+	for i := range sig {
+		if sig[i] == other[i] {
+			same++
+		}
+	}
+	return float64(same) / float64(len(sig))
+}
+
+// LSHMatch is a result of an LSHIndex query.
+type LSHMatch[K comparable] struct {
+	Key        K
+	Similarity float64
+}
+
+// LSHIndex is a locality-sensitive hashing index over MinHash signatures.
+// It splits each signature into bands and only compares a query against
+// indexed signatures that agree with it on at least one whole band, which
+// makes finding similar sets sublinear in the size of the index.
+//
+// An LSHIndex is not safe for concurrent use.
+type LSHIndex[K comparable] struct {
+	size      int
+	bands     int
+	rows      int
+	threshold float64
+	buckets   []map[uint64][]K
+	sigs      map[K]MinHashSignature
+}
+
+// NewLSHIndex creates and returns an index for signatures of size
+// numHashes that finds sets whose estimated Jaccard similarity to a query
+// is at least threshold. The number of bands is chosen so that the
+// probability of a pair becoming a candidate rises steeply around the
+// threshold. NewLSHIndex panics if numHashes is not positive.
+func NewLSHIndex[K comparable](numHashes int, threshold float64) *LSHIndex[K] {
+	if numHashes <= 0 {
+		panic(fmt.Sprintf("mapset: MinHash size must be positive, got %d", numHashes))
+	}
+	bands, rows := lshParameters(numHashes, threshold)
+	return NewLSHIndexWithBands[K](numHashes, bands, rows, threshold)
+}
+
+// NewLSHIndexWithBands creates and returns an index for signatures of size
+// numHashes that hashes bands bands of rows values each. NewLSHIndexWithBands
+// panics if bands or rows is not positive or bands*rows exceeds numHashes.
+func NewLSHIndexWithBands[K comparable](numHashes, bands, rows int, threshold float64) *LSHIndex[K] {
+	if bands <= 0 || rows <= 0 || bands*rows > numHashes {
+		panic(fmt.Sprintf("mapset: invalid LSH bands %d x %d for MinHash size %d", bands, rows, numHashes))
+	}
+	buckets := make([]map[uint64][]K, bands)
+	for i := range buckets {
+		buckets[i] = make(map[uint64][]K)
+	}
+	return &LSHIndex[K]{
+		size:      numHashes,
+		bands:     bands,
+		rows:      rows,
+		threshold: threshold,
+		buckets:   buckets,
+		sigs:      make(map[K]MinHashSignature),
+	}
+}
+
+// lshParameters returns the bands and rows for n hashes whose candidate
+// threshold, approximately (1/bands)^(1/rows), is closest to threshold.
+func lshParameters(n int, threshold float64) (bands, rows int) {
+	best := math.Inf(1)
+	for r := 1; r <= n; r++ {
+		b := n / r
+		if d := math.Abs(math.Pow(1/float64(b), 1/float64(r)) - threshold); d < best {
+			best, bands, rows = d, b, r
+		}
+	}
+	return bands, rows
+}
+
+// Bands returns the number of bands and rows per band of the index.
+func (idx *LSHIndex[K]) Bands() (bands, rows int) {
+	return idx.bands, idx.rows
+}
+
+// Len returns the number of signatures in the index.
+func (idx *LSHIndex[K]) Len() int {
+	return len(idx.sigs)
+}
+
+func (idx *LSHIndex[K]) bandHash(sig MinHashSignature, band int) uint64 {
+	h := mix64(uint64(band))
+	for _, v := range sig[band*idx.rows : (band+1)*idx.rows] {
+		h = mix64(h ^ v)
+	}
+	return h
+}
+
+func (idx *LSHIndex[K]) checkSize(sig MinHashSignature) {
+	if len(sig) != idx.size {
+		panic(fmt.Sprintf("mapset: MinHash signature of size %d used with LSH index of size %d", len(sig), idx.size))
+	}
+}
+
+// Insert adds the signature of the set identified by key to the index,
+// replacing any signature previously inserted for key. It panics if the
+// signature has the wrong size.
+func (idx *LSHIndex[K]) Insert(key K, sig MinHashSignature) {
+	idx.checkSize(sig)
+	idx.Remove(key)
+
+	sig = append(MinHashSignature(nil), sig...)
+	idx.sigs[key] = sig
+	for band := 0; band < idx.bands; band++ {
+		h := idx.bandHash(sig, band)
+		idx.buckets[band][h] = append(idx.buckets[band][h], key)
+	}
+}
+
+// Remove removes the signature inserted for key. Returns whether there
+// was one.
+func (idx *LSHIndex[K]) Remove(key K) bool {
+	sig, ok := idx.sigs[key]
+	if !ok {
+		return false
+	}
+	delete(idx.sigs, key)
+	for band := 0; band < idx.bands; band++ {
+		h := idx.bandHash(sig, band)
+		keys := idx.buckets[band][h]
+		for i, k := range keys {
+			if k == key {
+				keys[i] = keys[len(keys)-1]
+				keys = keys[:len(keys)-1]
+				break
+			}
+		}
+		if len(keys) == 0 {
+			delete(idx.buckets[band], h)
+		} else {
+			idx.buckets[band][h] = keys
+		}
+	}
+	return true
+}
+
+// Candidates returns the keys of indexed signatures that share at least
+// one band with sig, without checking their similarity.
+func (idx *LSHIndex[K]) Candidates(sig MinHashSignature) Set[K] {
+	idx.checkSize(sig)
+	candidates := NewThreadUnsafeSet[K]()
+	for band := 0; band < idx.bands; band++ {
+		candidates.Append(idx.buckets[band][idx.bandHash(sig, band)]...)
+	}
+	return candidates
+}
+
+// Query returns the indexed sets whose estimated similarity to the set
+// with signature sig is at least the index threshold, most similar first.
+// Sets that share no band with sig are not considered, so a small fraction
+// of sets above the threshold may be missed.
+func (idx *LSHIndex[K]) Query(sig MinHashSignature) []LSHMatch[K] {
+	var matches []LSHMatch[K]
+	idx.Candidates(sig).Each(func(k K) bool {
+		if sim := sig.Jaccard(idx.sigs[k]); sim >= idx.threshold {
+			matches = append(matches, LSHMatch[K]{Key: k, Similarity: sim})
+		}
+		return false
+	})
+	sort.SliceStable(matches, func(i, j int) bool {
+		return matches[i].Similarity > matches[j].Similarity
+	})
+	return matches
+}
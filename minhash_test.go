@@ -0,0 +1,108 @@
+/*
+Open Source Initiative OSI - The MIT License (MIT):Licensing
+
+The MIT License (MIT)
+Copyright (c) 2013 - 2026 Ralph Caraveo (deckarep@gmail.com)
+
+Permission is hereby granted, free of charge, to any person obtaining a copy of
+this software and associated documentation files (the "Software"), to deal in
+the Software without restriction, including without limitation the rights to
+use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies
+of the Software, and to permit persons to whom the Software is furnished to do
+so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+
+package mapset
+
+import (
+	"fmt"
+	"testing"
+)
+
+func rangeSet(from, to int) Set[int] {
+	s := NewThreadUnsafeSet[int]()
+	for i := from; i < to; i++ {
+		s.Add(i)
+	}
+	return s
+}
+
+func Test_MinHashJaccard(t *testing.T) {
+	mh := NewMinHasher[int](256, nil)
+
+	a := rangeSet(0, 1000)
+	b := rangeSet(500, 1500) // Jaccard 500/1500
+
+	sim := mh.Signature(a).Jaccard(mh.Signature(b))
+	if sim < 0.25 || sim > 0.42 {
+		t.Errorf("Expected an estimate near 0.33, got %.3f", sim)
+	}
+	if sim := mh.Signature(a).Jaccard(mh.Signature(a.Clone())); sim != 1 {
+		t.Errorf("Equal sets should have identical signatures, got %.3f", sim)
+	}
+	if sim := mh.Signature(a).Jaccard(mh.Signature(rangeSet(2000, 3000))); sim > 0.05 {
+		t.Errorf("Disjoint sets should have a similarity near 0, got %.3f", sim)
+	}
+}
+
+func Test_MinHashDeterministic(t *testing.T) {
+	a := NewSet("x", "y", "z")
+	sig1 := NewMinHasher[string](16, nil).Signature(a)
+	sig2 := NewMinHasher[string](16, nil).Signature(NewThreadUnsafeSet("z", "y", "x"))
+	if sig1.Jaccard(sig2) != 1 {
+		t.Error("Signatures from separate MinHashers should be comparable")
+	}
+}
+
+func Test_LSHIndexQuery(t *testing.T) {
+	mh := NewMinHasher[int](128, nil)
+	idx := NewLSHIndex[string](128, 0.7)
+
+	base := rangeSet(0, 1000)
+	for i := 0; i < 50; i++ {
+		// Documents far from the query.
+		idx.Insert(fmt.Sprintf("doc-%d", i), mh.Signature(rangeSet(i*1000+5000, i*1000+6000)))
+	}
+	idx.Insert("near", mh.Signature(rangeSet(50, 1000)))
+	idx.Insert("mid", mh.Signature(rangeSet(400, 1400)))
+
+	matches := idx.Query(mh.Signature(base))
+	if len(matches) != 1 || matches[0].Key != "near" {
+		t.Fatalf("Expected only near to match, got %v", matches)
+	}
+	if matches[0].Similarity < 0.85 {
+		t.Errorf("Expected a similarity near 0.95, got %.3f", matches[0].Similarity)
+	}
+
+	if !idx.Remove("near") || idx.Remove("near") {
+		t.Error("Remove should report whether the key was indexed")
+	}
+	if matches := idx.Query(mh.Signature(base)); len(matches) != 0 {
+		t.Errorf("Expected no matches after removal, got %v", matches)
+	}
+	if idx.Len() != 51 {
+		t.Errorf("Expected 51 indexed signatures, got %d", idx.Len())
+	}
+}
+
+func Test_LSHIndexBands(t *testing.T) {
+	bands, rows := NewLSHIndex[int](128, 0.8).Bands()
+	if bands*rows > 128 {
+		t.Errorf("Bands %d x %d exceed the signature size", bands, rows)
+	}
+	lowBands, lowRows := NewLSHIndex[int](128, 0.3).Bands()
+	if float64(lowRows)/float64(lowBands) >= float64(rows)/float64(bands) {
+		t.Error("A lower threshold should use more, shorter bands")
+	}
+}
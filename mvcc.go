@@ -0,0 +1,669 @@
+/*
+Open Source Initiative OSI - The MIT License (MIT):Licensing
+
+The MIT License (MIT)
+Copyright (c) 2013 - 2026 Ralph Caraveo (deckarep@gmail.com)
+
+Permission is hereby granted, free of charge, to any person obtaining a copy of
+this software and associated documentation files (the "Software"), to deal in
+the Software without restriction, including without limitation the rights to
+use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies
+of the Software, and to permit persons to whom the Software is furnished to do
+so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+
+package mapset
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/bits"
+	"strings"
+	"sync"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/bsontype"
+)
+
+// VersionedSet is a thread-safe Set whose every state is an immutable
+// version. Snapshot returns the current version in constant time, and
+// readers of a snapshot never block or are blocked by writers. Versions
+// share unchanged structure, so each change copies only a few small
+// nodes, and old versions are reclaimed by the garbage collector once no
+// snapshot references them.
+//
+// Clone is also constant time. Methods that return a new set return a
+// VersionedSet. Unlike the other Set implementations, the argument to
+// methods that take another set may be any Set.
+type VersionedSet[T comparable] interface {
+	Set[T]
+
+	// Snapshot returns the current version of the set.
+	Snapshot() SetSnapshot[T]
+
+	// Version returns the number of changes made to the set.
+	Version() uint64
+}
+
+// NewVersionedSet creates and returns a new VersionedSet with the given
+// elements.
+func NewVersionedSet[T comparable](vs ...T) VersionedSet[T] {
+	s := &versionedSet[T]{cur: SetSnapshot[T]{hasher: DefaultHasher[T]()}}
+	s.Append(vs...)
+	return s
+}
+
+// SetSnapshot is an immutable version of a VersionedSet. Its methods are
+// safe for concurrent use and never block. The zero value is an empty
+// snapshot.
+type SetSnapshot[T comparable] struct {
+	root    *hamtNode[T]
+	size    int
+	version uint64
+	hasher  Hasher[T]
+}
+
+// Version returns the number of changes made to the set before the
+// snapshot was taken.
+func (s SetSnapshot[T]) Version() uint64 {
+	return s.version
+}
+
+// Cardinality returns the number of elements in the snapshot.
+func (s SetSnapshot[T]) Cardinality() int {
+	return s.size
+}
+
+// IsEmpty returns whether the snapshot has no elements.
+func (s SetSnapshot[T]) IsEmpty() bool {
+	return s.size == 0
+}
+
+// ContainsOne returns whether the given item is in the snapshot.
+func (s SetSnapshot[T]) ContainsOne(v T) bool {
+	if s.root == nil {
+		// The zero value has no hasher.
+		return false
+	}
+	return s.root.contains(s.hasher(v), v)
+}
+
+// Contains returns whether the given items are all in the snapshot.
+func (s SetSnapshot[T]) Contains(vs ...T) bool {
+	for _, v := range vs {
+		if !s.ContainsOne(v) {
+			return false
+		}
+	}
+	return true
+}
+
+// ContainsAny returns whether at least one of the given items is in the
+// snapshot.
+func (s SetSnapshot[T]) ContainsAny(vs ...T) bool {
+	for _, v := range vs {
+		if s.ContainsOne(v) {
+			return true
+		}
+	}
+	return false
+}
+
+// Each iterates over elements and executes the passed func against each
+// element. If passed func returns true, stop iteration at the time.
+func (s SetSnapshot[T]) Each(cb func(T) bool) {
+	s.root.each(cb)
+}
+
+// ToSlice returns the elements of the snapshot as a slice.
+func (s SetSnapshot[T]) ToSlice() []T {
+	keys := make([]T, 0, s.size)
+	s.Each(func(v T) bool {
+		keys = append(keys, v)
+		return false
+	})
+	return keys
+}
+
+// ToSet returns the elements of the snapshot as a thread-safe Set.
+func (s SetSnapshot[T]) ToSet() Set[T] {
+	return NewSet(s.ToSlice()...)
+}
+
+// String provides a convenient string representation of the snapshot.
+func (s SetSnapshot[T]) String() string {
+	items := make([]string, 0, s.size)
+	s.Each(func(v T) bool {
+		items = append(items, fmt.Sprintf("%v", v))
+		return false
+	})
+	return fmt.Sprintf("Set{%s}", strings.Join(items, ", "))
+}
+
+// with returns the snapshot with v added, and whether it was added.
+func (s SetSnapshot[T]) with(v T) (SetSnapshot[T], bool) {
+	root, added := s.root.insert(s.hasher(v), v, 0)
+	if !added {
+		return s, false
+	}
+	return SetSnapshot[T]{root: root, size: s.size + 1, version: s.version + 1, hasher: s.hasher}, true
+}
+
+// without returns the snapshot with v removed, and whether it was
+// removed.
+func (s SetSnapshot[T]) without(v T) (SetSnapshot[T], bool) {
+	root, removed := s.root.remove(s.hasher(v), v, 0)
+	if !removed {
+		return s, false
+	}
+	return SetSnapshot[T]{root: root, size: s.size - 1, version: s.version + 1, hasher: s.hasher}, true
+}
+
+// The nodes of a hash array mapped trie each consume hamtBits bits of an
+// element's hash. Nodes are never modified once published; changes copy
+// the path from the root to the changed node.
+const (
+	hamtBits  = 5
+	hamtWidth = 1 << hamtBits
+)
+
+type hamtNode[T comparable] struct {
+	bitmap  uint32
+	entries []hamtEntry[T]
+}
+
+// hamtEntry is either a child node or a leaf holding the elements with a
+// given hash, of which there is almost always one.
+type hamtEntry[T comparable] struct {
+	child *hamtNode[T]
+	hash  uint64
+	vals  []T
+}
+
+func hamtBit(hash uint64, shift uint) uint32 {
+	return 1 << ((hash >> shift) & (hamtWidth - 1))
+}
+
+// index returns the position in n.entries of the entry for bit.
+func (n *hamtNode[T]) index(bit uint32) int {
+	return bits.OnesCount32(n.bitmap & (bit - 1))
+}
+
+func (n *hamtNode[T]) contains(hash uint64, v T) bool {
+	for shift := uint(0); n != nil; shift += hamtBits {
+		bit := hamtBit(hash, shift)
+		if n.bitmap&bit == 0 {
+			return false
+		}
+		e := &n.entries[n.index(bit)]
+		if e.child != nil {
+			n = e.child
+			continue
+		}
+		if e.hash != hash {
+			return false
+		}
+		for _, x := range e.vals {
+			if x == v {
+				return true
+			}
+		}
+		return false
+	}
+	return false
+}
+
+// replace returns a copy of n with the entry at i replaced by e.
+func (n *hamtNode[T]) replace(i int, e hamtEntry[T]) *hamtNode[T] {
+	entries := make([]hamtEntry[T], len(n.entries))
+	copy(entries, n.entries)
+	entries[i] = e
+	return &hamtNode[T]{bitmap: n.bitmap, entries: entries}
+}
+
+// insert returns a copy of n with v added, and whether it was added. n
+// may be nil.
+func (n *hamtNode[T]) insert(hash uint64, v T, shift uint) (*hamtNode[T], bool) {
+	bit := hamtBit(hash, shift)
+	leaf := hamtEntry[T]{hash: hash, vals: []T{v}}
+	if n == nil {
+		return &hamtNode[T]{bitmap: bit, entries: []hamtEntry[T]{leaf}}, true
+	}
+
+	i := n.index(bit)
+	if n.bitmap&bit == 0 {
+		entries := make([]hamtEntry[T], len(n.entries)+1)
+		copy(entries, n.entries[:i])
+		entries[i] = leaf
+		copy(entries[i+1:], n.entries[i:])
+		return &hamtNode[T]{bitmap: n.bitmap | bit, entries: entries}, true
+	}
+
+	e := n.entries[i]
+	switch {
+	case e.child != nil:
+		child, added := e.child.insert(hash, v, shift+hamtBits)
+		if !added {
+			return n, false
+		}
+		return n.replace(i, hamtEntry[T]{child: child}), true
+	case e.hash == hash:
+		for _, x := range e.vals {
+			if x == v {
+				return n, false
+			}
+		}
+		vals := make([]T, len(e.vals), len(e.vals)+1)
+		copy(vals, e.vals)
+		return n.replace(i, hamtEntry[T]{hash: hash, vals: append(vals, v)}), true
+	}
+
+	// Push the existing leaf down a level, where the hashes may differ.
+	child := &hamtNode[T]{bitmap: hamtBit(e.hash, shift+hamtBits), entries: []hamtEntry[T]{e}}
+	child, _ = child.insert(hash, v, shift+hamtBits)
+	return n.replace(i, hamtEntry[T]{child: child}), true
+}
+
+// remove returns a copy of n with v removed, and whether it was removed.
+// The result is nil if it would be empty.
+func (n *hamtNode[T]) remove(hash uint64, v T, shift uint) (*hamtNode[T], bool) {
+	if n == nil {
+		return nil, false
+	}
+	bit := hamtBit(hash, shift)
+	if n.bitmap&bit == 0 {
+		return n, false
+	}
+
+	i := n.index(bit)
+	e := n.entries[i]
+	if e.child != nil {
+		child, removed := e.child.remove(hash, v, shift+hamtBits)
+		switch {
+		case !removed:
+			return n, false
+		case child == nil:
+			return n.drop(i, bit), true
+		case len(child.entries) == 1 && child.entries[0].child == nil:
+			// Pull a lone leaf up, keeping the trie as shallow as
+			// if the removed element had never been added.
+			return n.replace(i, child.entries[0]), true
+		}
+		return n.replace(i, hamtEntry[T]{child: child}), true
+	}
+
+	if e.hash != hash {
+		return n, false
+	}
+	for j, x := range e.vals {
+		if x != v {
+			continue
+		}
+		if len(e.vals) == 1 {
+			return n.drop(i, bit), true
+		}
+		vals := make([]T, 0, len(e.vals)-1)
+		vals = append(append(vals, e.vals[:j]...), e.vals[j+1:]...)
+		return n.replace(i, hamtEntry[T]{hash: hash, vals: vals}), true
+	}
+	return n, false
+}
+
+// drop returns a copy of n without the entry at i, or nil if it would be
+// empty.
+func (n *hamtNode[T]) drop(i int, bit uint32) *hamtNode[T] {
+	if len(n.entries) == 1 {
+		return nil
+	}
+	entries := make([]hamtEntry[T], 0, len(n.entries)-1)
+	entries = append(append(entries, n.entries[:i]...), n.entries[i+1:]...)
+	return &hamtNode[T]{bitmap: n.bitmap &^ bit, entries: entries}
+}
+
+// each calls cb with every element under n, returning true if cb
+// stopped the iteration.
+func (n *hamtNode[T]) each(cb func(T) bool) bool {
+	if n == nil {
+		return false
+	}
+	for _, e := range n.entries {
+		if e.child != nil {
+			if e.child.each(cb) {
+				return true
+			}
+			continue
+		}
+		for _, v := range e.vals {
+			if cb(v) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+type versionedSet[T comparable] struct {
+	sync.RWMutex
+	cur SetSnapshot[T]
+}
+
+// Assert concrete type:versionedSet adheres to VersionedSet interface.
+var _ VersionedSet[string] = (*versionedSet[string])(nil)
+
+func (s *versionedSet[T]) Snapshot() SetSnapshot[T] {
+	s.RLock()
+	defer s.RUnlock()
+	return s.cur
+}
+
+func (s *versionedSet[T]) Version() uint64 {
+	return s.Snapshot().version
+}
+
+// fromSnapshot returns a new set whose first version holds the elements of
+// snap.
+func fromSnapshot[T comparable](snap SetSnapshot[T]) *versionedSet[T] {
+	snap.version = 0
+	return &versionedSet[T]{cur: snap}
+}
+
+// build returns a new set holding the elements of vs.
+func (s *versionedSet[T]) build(vs []T) *versionedSet[T] {
+	snap := SetSnapshot[T]{hasher: s.cur.hasher}
+	for _, v := range vs {
+		snap, _ = snap.with(v)
+	}
+	return fromSnapshot(snap)
+}
+
+func (s *versionedSet[T]) Add(v T) bool {
+	s.Lock()
+	defer s.Unlock()
+	var added bool
+	s.cur, added = s.cur.with(v)
+	return added
+}
+
+func (s *versionedSet[T]) Append(vs ...T) int {
+	s.Lock()
+	defer s.Unlock()
+	prevLen := s.cur.size
+	for _, v := range vs {
+		s.cur, _ = s.cur.with(v)
+	}
+	return s.cur.size - prevLen
+}
+
+func (s *versionedSet[T]) AppendFrom(other Set[T]) int {
+	return s.Append(other.ToSlice()...)
+}
+
+func (s *versionedSet[T]) Cardinality() int {
+	return s.Snapshot().Cardinality()
+}
+
+func (s *versionedSet[T]) Clear() {
+	s.Lock()
+	defer s.Unlock()
+	if s.cur.size > 0 {
+		s.cur = SetSnapshot[T]{version: s.cur.version + 1, hasher: s.cur.hasher}
+	}
+}
+
+func (s *versionedSet[T]) Clone() Set[T] {
+	return fromSnapshot(s.Snapshot())
+}
+
+func (s *versionedSet[T]) Contains(vs ...T) bool {
+	return s.Snapshot().Contains(vs...)
+}
+
+func (s *versionedSet[T]) ContainsOne(v T) bool {
+	return s.Snapshot().ContainsOne(v)
+}
+
+func (s *versionedSet[T]) ContainsAny(vs ...T) bool {
+	return s.Snapshot().ContainsAny(vs...)
+}
+
+func (s *versionedSet[T]) ContainsAnyElement(other Set[T]) bool {
+	snap := s.Snapshot()
+	var found bool
+	other.Each(func(v T) bool {
+		found = snap.ContainsOne(v)
+		return found
+	})
+	return found
+}
+
+func (s *versionedSet[T]) Difference(other Set[T]) Set[T] {
+	snap := s.Snapshot()
+	for _, v := range other.ToSlice() {
+		snap, _ = snap.without(v)
+	}
+	return fromSnapshot(snap)
+}
+
+func (s *versionedSet[T]) Each(cb func(T) bool) {
+	s.Snapshot().Each(cb)
+}
+
+func (s *versionedSet[T]) Equal(other Set[T]) bool {
+	snap := s.Snapshot()
+	vs := other.ToSlice()
+	return len(vs) == snap.size && snap.Contains(vs...)
+}
+
+func (s *versionedSet[T]) Filter(cb func(T) bool) Set[T] {
+	var vs []T
+	s.Each(func(v T) bool {
+		if cb(v) {
+			vs = append(vs, v)
+		}
+		return false
+	})
+	return s.build(vs)
+}
+
+func (s *versionedSet[T]) Intersect(other Set[T]) Set[T] {
+	snap := s.Snapshot()
+	var vs []T
+	for _, v := range other.ToSlice() {
+		if snap.ContainsOne(v) {
+			vs = append(vs, v)
+		}
+	}
+	return s.build(vs)
+}
+
+func (s *versionedSet[T]) IsEmpty() bool {
+	return s.Cardinality() == 0
+}
+
+func (s *versionedSet[T]) IsProperSubset(other Set[T]) bool {
+	snap := s.Snapshot()
+	return snap.size < other.Cardinality() && s.isSubset(snap, other)
+}
+
+func (s *versionedSet[T]) IsProperSuperset(other Set[T]) bool {
+	snap := s.Snapshot()
+	vs := other.ToSlice()
+	return snap.size > len(vs) && snap.Contains(vs...)
+}
+
+// isSubset returns whether snap is a subset of other.
+func (s *versionedSet[T]) isSubset(snap SetSnapshot[T], other Set[T]) bool {
+	subset := true
+	snap.Each(func(v T) bool {
+		subset = other.ContainsOne(v)
+		return !subset
+	})
+	return subset
+}
+
+func (s *versionedSet[T]) IsSubset(other Set[T]) bool {
+	snap := s.Snapshot()
+	return snap.size <= other.Cardinality() && s.isSubset(snap, other)
+}
+
+func (s *versionedSet[T]) IsSuperset(other Set[T]) bool {
+	return s.Snapshot().Contains(other.ToSlice()...)
+}
+
+func (s *versionedSet[T]) Iter() <-chan T {
+	snap := s.Snapshot()
+	ch := make(chan T)
+	go func() {
+		snap.Each(func(v T) bool {
+			ch <- v
+			return false
+		})
+		close(ch)
+	}()
+
+	return ch
+}
+
+func (s *versionedSet[T]) Iterator() *Iterator[T] {
+	snap := s.Snapshot()
+	iterator, ch, stopCh := newIterator[T]()
+
+	go func() {
+		snap.Each(func(v T) bool {
+			select {
+			case <-stopCh:
+				return true
+			case ch <- v:
+				return false
+			}
+		})
+		close(ch)
+	}()
+
+	return iterator
+}
+
+func (s *versionedSet[T]) Pop() (v T, ok bool) {
+	s.Lock()
+	defer s.Unlock()
+	s.cur.Each(func(x T) bool {
+		v, ok = x, true
+		return true
+	})
+	if ok {
+		s.cur, _ = s.cur.without(v)
+	}
+	return v, ok
+}
+
+func (s *versionedSet[T]) PopN(n int) ([]T, int) {
+	s.Lock()
+	defer s.Unlock()
+	if n <= 0 || s.cur.size == 0 {
+		return make([]T, 0), 0
+	}
+	if n > s.cur.size {
+		n = s.cur.size
+	}
+
+	items := make([]T, 0, n)
+	s.cur.Each(func(v T) bool {
+		items = append(items, v)
+		return len(items) == n
+	})
+	for _, v := range items {
+		s.cur, _ = s.cur.without(v)
+	}
+	return items, len(items)
+}
+
+func (s *versionedSet[T]) Remove(v T) {
+	s.Lock()
+	defer s.Unlock()
+	s.cur, _ = s.cur.without(v)
+}
+
+func (s *versionedSet[T]) RemoveAll(vs ...T) {
+	s.Lock()
+	defer s.Unlock()
+	for _, v := range vs {
+		s.cur, _ = s.cur.without(v)
+	}
+}
+
+func (s *versionedSet[T]) String() string {
+	return s.Snapshot().String()
+}
+
+func (s *versionedSet[T]) SymmetricDifference(other Set[T]) Set[T] {
+	snap := s.Snapshot()
+	sd := SetSnapshot[T]{hasher: snap.hasher}
+	for _, v := range other.ToSlice() {
+		var removed bool
+		if snap, removed = snap.without(v); !removed {
+			sd, _ = sd.with(v)
+		}
+	}
+	snap.Each(func(v T) bool {
+		sd, _ = sd.with(v)
+		return false
+	})
+	return fromSnapshot(sd)
+}
+
+func (s *versionedSet[T]) ToSlice() []T {
+	return s.Snapshot().ToSlice()
+}
+
+func (s *versionedSet[T]) Union(other Set[T]) Set[T] {
+	snap := s.Snapshot()
+	for _, v := range other.ToSlice() {
+		snap, _ = snap.with(v)
+	}
+	return fromSnapshot(snap)
+}
+
+// MarshalJSON creates a JSON array from the set.
+func (s *versionedSet[T]) MarshalJSON() ([]byte, error) {
+	return json.Marshal(s.ToSlice())
+}
+
+// UnmarshalJSON adds the elements of a JSON array to the set.
+func (s *versionedSet[T]) UnmarshalJSON(b []byte) error {
+	var i []T
+	if err := json.Unmarshal(b, &i); err != nil {
+		return err
+	}
+	s.Append(i...)
+	return nil
+}
+
+// MarshalBSONValue creates a BSON array from the set.
+func (s *versionedSet[T]) MarshalBSONValue() (bsontype.Type, []byte, error) {
+	return bson.MarshalValue(s.ToSlice())
+}
+
+// UnmarshalBSONValue adds the elements of a BSON array to the set.
+func (s *versionedSet[T]) UnmarshalBSONValue(bt bsontype.Type, b []byte) error {
+	if bt != bson.TypeArray {
+		return fmt.Errorf("must use BSON Array to unmarshal Set")
+	}
+
+	var i []T
+	if err := bson.UnmarshalValue(bt, b, &i); err != nil {
+		return err
+	}
+	s.Append(i...)
+	return nil
+}
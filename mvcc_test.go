@@ -0,0 +1,222 @@
+/*
+Open Source Initiative OSI - The MIT License (MIT):Licensing
+
+The MIT License (MIT)
+Copyright (c) 2013 - 2026 Ralph Caraveo (deckarep@gmail.com)
+
+Permission is hereby granted, free of charge, to any person obtaining a copy of
+this software and associated documentation files (the "Software"), to deal in
+the Software without restriction, including without limitation the rights to
+use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies
+of the Software, and to permit persons to whom the Software is furnished to do
+so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+
+package mapset
+
+import (
+	"encoding/json"
+	"sync"
+	"testing"
+)
+
+func TestVersionedSetSnapshotIsolation(t *testing.T) {
+	s := NewVersionedSet(1, 2, 3)
+	snap := s.Snapshot()
+
+	s.Add(4)
+	s.Remove(1)
+	s.Clear()
+	s.Append(7, 8)
+
+	if !snap.Contains(1, 2, 3) || snap.ContainsOne(4) || snap.Cardinality() != 3 {
+		t.Errorf("snapshot changed after writes: %v", snap)
+	}
+	if !s.Equal(NewSet(7, 8)) {
+		t.Errorf("expected {7, 8}, got %v", s)
+	}
+	if snap.Version() >= s.Version() {
+		t.Errorf("expected snapshot version %d to be older than %d", snap.Version(), s.Version())
+	}
+}
+
+func TestSetSnapshotZeroValue(t *testing.T) {
+	var snap SetSnapshot[int]
+	if !snap.IsEmpty() || snap.ContainsOne(1) || snap.ContainsAny(1, 2) || len(snap.ToSlice()) != 0 {
+		t.Errorf("expected zero value to be empty, got %v", snap)
+	}
+	if !snap.Contains() || snap.String() != "Set{}" {
+		t.Errorf("unexpected zero value %v", snap)
+	}
+}
+
+func TestVersionedSetVersion(t *testing.T) {
+	s := NewVersionedSet[string]()
+	if s.Version() != 0 {
+		t.Errorf("expected version 0, got %d", s.Version())
+	}
+
+	s.Add("a")
+	s.Add("a")
+	s.Remove("b")
+	if s.Version() != 1 {
+		t.Errorf("expected no-op changes to keep version 1, got %d", s.Version())
+	}
+
+	s.Append("b", "c")
+	s.Clear()
+	s.Clear()
+	if s.Version() != 4 {
+		t.Errorf("expected version 4, got %d", s.Version())
+	}
+}
+
+func TestVersionedSetMatchesSet(t *testing.T) {
+	s := NewVersionedSet[int]()
+	want := NewThreadUnsafeSet[int]()
+	for i := 0; i < 5000; i++ {
+		v := int(mix64(uint64(i)) % 1000)
+		if i%3 == 0 {
+			s.Remove(v)
+			want.Remove(v)
+		} else if s.Add(v) != want.Add(v) {
+			t.Fatalf("Add(%d) disagreed with Set", v)
+		}
+		if s.Cardinality() != want.Cardinality() {
+			t.Fatalf("expected cardinality %d, got %d", want.Cardinality(), s.Cardinality())
+		}
+	}
+	if !want.Equal(NewThreadUnsafeSet(s.ToSlice()...)) {
+		t.Errorf("expected %v, got %v", want, s)
+	}
+}
+
+func TestSetSnapshotHashCollisions(t *testing.T) {
+	snap := SetSnapshot[string]{hasher: func(string) uint64 { return 42 }}
+	for _, v := range []string{"a", "b", "c"} {
+		snap, _ = snap.with(v)
+	}
+	if _, added := snap.with("b"); added {
+		t.Error("expected duplicate colliding element not to be added")
+	}
+	if !snap.Contains("a", "b", "c") || snap.ContainsOne("d") {
+		t.Errorf("unexpected membership: %v", snap)
+	}
+
+	snap, _ = snap.without("b")
+	if snap.ContainsOne("b") || !snap.Contains("a", "c") || snap.Cardinality() != 2 {
+		t.Errorf("unexpected membership after removal: %v", snap)
+	}
+	snap, _ = snap.without("a")
+	snap, _ = snap.without("c")
+	if snap.root != nil || !snap.IsEmpty() {
+		t.Errorf("expected empty trie, got %v", snap)
+	}
+}
+
+func TestVersionedSetOperations(t *testing.T) {
+	a := NewVersionedSet(1, 2, 3)
+	b := NewSet(2, 3, 4)
+
+	if !a.Union(b).Equal(NewSet(1, 2, 3, 4)) {
+		t.Error("unexpected union")
+	}
+	if !a.Intersect(b).Equal(NewSet(2, 3)) {
+		t.Error("unexpected intersection")
+	}
+	if !a.Difference(b).Equal(NewSet(1)) {
+		t.Error("unexpected difference")
+	}
+	if !a.SymmetricDifference(b).Equal(NewSet(1, 4)) {
+		t.Error("unexpected symmetric difference")
+	}
+	if !a.Filter(func(v int) bool { return v%2 == 1 }).Equal(NewSet(1, 3)) {
+		t.Error("unexpected filter")
+	}
+	if !a.IsSubset(NewSet(1, 2, 3)) || a.IsProperSubset(NewSet(1, 2, 3)) {
+		t.Error("unexpected subset result")
+	}
+	if !a.IsProperSuperset(NewSet(1, 3)) || !a.ContainsAnyElement(b) {
+		t.Error("unexpected superset result")
+	}
+	if _, ok := a.Union(b).(VersionedSet[int]); !ok {
+		t.Error("expected derived sets to be versioned")
+	}
+
+	c := a.Clone()
+	a.Add(9)
+	if c.ContainsOne(9) {
+		t.Error("expected clone to be independent")
+	}
+}
+
+func TestVersionedSetPop(t *testing.T) {
+	s := NewVersionedSet(1, 2, 3, 4)
+	items, n := s.PopN(3)
+	if n != 3 || len(items) != 3 || s.Cardinality() != 1 {
+		t.Fatalf("unexpected PopN result %v, remaining %v", items, s)
+	}
+	v, ok := s.Pop()
+	if !ok || s.Cardinality() != 0 {
+		t.Fatalf("unexpected Pop result %v, remaining %v", v, s)
+	}
+	if !NewSet(append(items, v)...).Equal(NewSet(1, 2, 3, 4)) {
+		t.Errorf("expected to pop every element, got %v and %v", items, v)
+	}
+	if _, ok := s.Pop(); ok {
+		t.Error("expected Pop on empty set to fail")
+	}
+}
+
+func TestVersionedSetJSON(t *testing.T) {
+	s := NewVersionedSet("a", "b")
+	b, err := json.Marshal(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got := NewVersionedSet[string]()
+	if err := json.Unmarshal(b, got); err != nil {
+		t.Fatal(err)
+	}
+	if !got.Equal(s) {
+		t.Errorf("expected %v, got %v", s, got)
+	}
+}
+
+func TestVersionedSetConcurrentSnapshots(t *testing.T) {
+	s := NewVersionedSet[int]()
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 1000; i++ {
+			s.Add(i)
+		}
+	}()
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 100; i++ {
+			snap := s.Snapshot()
+			// Elements are added in order, so every snapshot is a prefix.
+			if n := snap.Cardinality(); n > 0 && !snap.ContainsOne(n-1) {
+				t.Errorf("snapshot of %d elements is missing %d", n, n-1)
+			}
+			if len(snap.ToSlice()) != snap.Cardinality() {
+				t.Error("snapshot changed while being read")
+			}
+		}
+	}()
+	wg.Wait()
+}
@@ -0,0 +1,231 @@
+/*
+Open Source Initiative OSI - The MIT License (MIT):Licensing
+
+The MIT License (MIT)
+Copyright (c) 2013 - 2026 Ralph Caraveo (deckarep@gmail.com)
+
+Permission is hereby granted, free of charge, to any person obtaining a copy of
+this software and associated documentation files (the "Software"), to deal in
+the Software without restriction, including without limitation the rights to
+use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies
+of the Software, and to permit persons to whom the Software is furnished to do
+so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+
+package mapset
+
+import (
+	"fmt"
+	"sync"
+)
+
+// ChangeEvent describes a single change to an ObservableSet: the elements
+// added to it and the elements removed from it.
+type ChangeEvent[T comparable] struct {
+	Added   []T
+	Removed []T
+}
+
+// DeliveryMode selects how change events are delivered to a subscriber.
+type DeliveryMode int
+
+const (
+	// DeliverSync delivers each event from the goroutine that
+	// changed the set, before the mutating method returns. The set
+	// cannot be changed until the subscriber returns, so it must not
+	// modify the set itself, but it may read it.
+	DeliverSync DeliveryMode = iota
+
+	// DeliverAsync delivers events from a goroutine owned by the
+	// subscription, in order, without blocking the mutating method.
+	// Events not yet delivered when the subscription is canceled are
+	// dropped.
+	DeliverAsync
+)
+
+func (m DeliveryMode) String() string {
+	switch m {
+	case DeliverSync:
+		return "Sync"
+	case DeliverAsync:
+		return "Async"
+	}
+	return fmt.Sprintf("DeliveryMode(%d)", int(m))
+}
+
+// ObservableSet is a Set that notifies subscribers of changes made through
+// it. Add, Append, AppendFrom, Remove, RemoveAll, Clear, Pop, PopN,
+// UnmarshalJSON and UnmarshalBSONValue each emit at most one ChangeEvent
+// listing the elements they actually added or removed; calls that change
+// nothing emit no event. Changes made directly to the wrapped set are not
+// observed.
+//
+// Events are emitted in the order the changes were made. Clone and the
+// methods that return a new set return sets that are not observable.
+//
+// Operations on an ObservableSet are thread-safe if the wrapped set is.
+type ObservableSet[T comparable] interface {
+	Set[T]
+
+	// Subscribe registers fn to be called with every subsequent
+	// change event, delivered according to mode. It returns a
+	// function that cancels the subscription; events emitted after
+	// it returns are not delivered to fn, but a delivery already
+	// under way may still call fn after it returns. It may be called
+	// from fn.
+	Subscribe(fn func(ChangeEvent[T]), mode DeliveryMode) (unsubscribe func())
+
+	// SubscribeChan registers ch to receive every subsequent change
+	// event, delivered according to mode. With DeliverSync, changes
+	// to the set block until ch receives the event. It returns a
+	// function that cancels the subscription.
+	SubscribeChan(ch chan<- ChangeEvent[T], mode DeliveryMode) (unsubscribe func())
+}
+
+// NewObservableSet returns an ObservableSet that wraps s.
+func NewObservableSet[T comparable](s Set[T]) ObservableSet[T] {
+	o := &observableSet[T]{subs: make(map[uint64]*subscription[T])}
+	o.Set = s
+	o.onChange = o.emit
+	return o
+}
+
+type observableSet[T comparable] struct {
+	recordingSet[T]
+
+	subsMu sync.RWMutex
+	subs   map[uint64]*subscription[T]
+	nextID uint64
+}
+
+// Assert concrete type:observableSet adheres to ObservableSet interface.
+var _ ObservableSet[string] = (*observableSet[string])(nil)
+
+type subscription[T comparable] struct {
+	fn   func(ChangeEvent[T])
+	mode DeliveryMode
+	done chan struct{}
+
+	// Events pending asynchronous delivery.
+	mu      sync.Mutex
+	queue   []ChangeEvent[T]
+	pending chan struct{}
+}
+
+func (sub *subscription[T]) deliver(e ChangeEvent[T]) {
+	if sub.mode != DeliverAsync {
+		select {
+		case <-sub.done:
+		default:
+			sub.fn(e)
+		}
+		return
+	}
+
+	sub.mu.Lock()
+	sub.queue = append(sub.queue, e)
+	sub.mu.Unlock()
+	select {
+	case sub.pending <- struct{}{}:
+	default:
+	}
+}
+
+// run delivers queued events until the subscription is canceled.
+func (sub *subscription[T]) run() {
+	for {
+		select {
+		case <-sub.done:
+			return
+		case <-sub.pending:
+		}
+
+		sub.mu.Lock()
+		queue := sub.queue
+		sub.queue = nil
+		sub.mu.Unlock()
+
+		for _, e := range queue {
+			select {
+			case <-sub.done:
+				return
+			default:
+				sub.fn(e)
+			}
+		}
+	}
+}
+
+func (s *observableSet[T]) Subscribe(fn func(ChangeEvent[T]), mode DeliveryMode) func() {
+	sub := &subscription[T]{fn: fn, mode: mode, done: make(chan struct{})}
+	if mode == DeliverAsync {
+		sub.pending = make(chan struct{}, 1)
+		go sub.run()
+	}
+
+	s.subsMu.Lock()
+	id := s.nextID
+	s.nextID++
+	s.subs[id] = sub
+	s.subsMu.Unlock()
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			s.subsMu.Lock()
+			delete(s.subs, id)
+			s.subsMu.Unlock()
+			close(sub.done)
+		})
+	}
+}
+
+func (s *observableSet[T]) SubscribeChan(ch chan<- ChangeEvent[T], mode DeliveryMode) func() {
+	done := make(chan struct{})
+	unsubscribe := s.Subscribe(func(e ChangeEvent[T]) {
+		select {
+		case ch <- e:
+		case <-done:
+		}
+	}, mode)
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			// Release a send blocked on ch before taking subsMu to
+			// remove the subscription. emit copies the subscribers
+			// under subsMu and delivers after releasing it, so a
+			// delivery may still be under way after unsubscribe
+			// returns, and done keeps it from blocking.
+			close(done)
+			unsubscribe()
+		})
+	}
+}
+
+// emit delivers an event to every subscriber. It is called with s.mu held.
+func (s *observableSet[T]) emit(added, removed []T) {
+	e := ChangeEvent[T]{Added: added, Removed: removed}
+
+	s.subsMu.RLock()
+	subs := make([]*subscription[T], 0, len(s.subs))
+	for _, sub := range s.subs {
+		subs = append(subs, sub)
+	}
+	s.subsMu.RUnlock()
+
+	for _, sub := range subs {
+		sub.deliver(e)
+	}
+}
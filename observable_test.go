@@ -0,0 +1,279 @@
+/*
+Open Source Initiative OSI - The MIT License (MIT):Licensing
+
+The MIT License (MIT)
+Copyright (c) 2013 - 2026 Ralph Caraveo (deckarep@gmail.com)
+
+Permission is hereby granted, free of charge, to any person obtaining a copy of
+this software and associated documentation files (the "Software"), to deal in
+the Software without restriction, including without limitation the rights to
+use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies
+of the Software, and to permit persons to whom the Software is furnished to do
+so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+
+package mapset
+
+import (
+	"encoding/json"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// recorder collects change events as sorted "+v" and "-v" strings.
+type recorder struct {
+	sync.Mutex
+	events [][]string
+}
+
+func (r *recorder) record(e ChangeEvent[string]) {
+	var items []string
+	for _, v := range e.Added {
+		items = append(items, "+"+v)
+	}
+	for _, v := range e.Removed {
+		items = append(items, "-"+v)
+	}
+	sort.Strings(items)
+
+	r.Lock()
+	r.events = append(r.events, items)
+	r.Unlock()
+}
+
+func (r *recorder) get() [][]string {
+	r.Lock()
+	defer r.Unlock()
+	return append([][]string(nil), r.events...)
+}
+
+func Test_ObservableSetEvents(t *testing.T) {
+	for _, newSet := range []func(vs ...string) Set[string]{NewSet[string], NewThreadUnsafeSet[string]} {
+		s := NewObservableSet(newSet("a"))
+		var r recorder
+		s.Subscribe(r.record, DeliverSync)
+
+		s.Add("a") // no change
+		s.Add("b")
+		s.Append("b", "c", "d")
+		s.Remove("x") // no change
+		s.Remove("a")
+		s.RemoveAll("b", "x")
+		s.AppendFrom(NewSet("e"))
+		v, _ := s.Pop()
+		s.Add(v)
+		s.PopN(0) // no change
+		_ = json.Unmarshal([]byte(`["f", "c"]`), s)
+		s.Clear()
+
+		want := [][]string{
+			{"+b"},
+			{"+c", "+d"},
+			{"-a"},
+			{"-b"},
+			{"+e"},
+			{"-" + v},
+			{"+" + v},
+			{"+f"},
+			{"-c", "-d", "-e", "-f"},
+		}
+		if got := r.get(); !equalEvents(got, want) {
+			t.Errorf("Expected events %v, got %v", want, got)
+		}
+	}
+}
+
+func equalEvents(a, b [][]string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if len(a[i]) != len(b[i]) {
+			return false
+		}
+		for j := range a[i] {
+			if a[i][j] != b[i][j] {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+func Test_ObservableSetUnsubscribe(t *testing.T) {
+	s := NewObservableSet(NewSet[string]())
+	var r recorder
+	unsubscribe := s.Subscribe(r.record, DeliverSync)
+
+	s.Add("a")
+	unsubscribe()
+	unsubscribe()
+	s.Add("b")
+	if got := r.get(); len(got) != 1 {
+		t.Errorf("Expected 1 event before unsubscribing, got %v", got)
+	}
+
+	// A subscriber may unsubscribe itself.
+	var calls int
+	var self func()
+	self = s.Subscribe(func(ChangeEvent[string]) {
+		calls++
+		self()
+	}, DeliverSync)
+	s.Add("c")
+	s.Add("d")
+	if calls != 1 {
+		t.Errorf("Expected 1 call, got %d", calls)
+	}
+}
+
+func Test_ObservableSetAsync(t *testing.T) {
+	s := NewObservableSet(NewSet[int]())
+	ch := make(chan ChangeEvent[int], 100)
+	unsubscribe := s.Subscribe(func(e ChangeEvent[int]) { ch <- e }, DeliverAsync)
+	defer unsubscribe()
+
+	for i := 0; i < 50; i++ {
+		s.Add(i)
+	}
+	for i := 0; i < 50; i++ {
+		select {
+		case e := <-ch:
+			if len(e.Added) != 1 || e.Added[0] != i {
+				t.Fatalf("Expected event %d in order, got %v", i, e)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatalf("Timed out waiting for event %d", i)
+		}
+	}
+}
+
+func Test_ObservableSetChan(t *testing.T) {
+	s := NewObservableSet(NewSet[int]())
+
+	ch := make(chan ChangeEvent[int])
+	unsubscribe := s.SubscribeChan(ch, DeliverSync)
+	go s.Add(1)
+	if e := <-ch; len(e.Added) != 1 || e.Added[0] != 1 {
+		t.Errorf("Unexpected event %v", e)
+	}
+
+	// Unsubscribing releases a change blocked on an unread channel.
+	done := make(chan struct{})
+	go func() {
+		s.Add(2)
+		close(done)
+	}()
+	time.Sleep(10 * time.Millisecond)
+	unsubscribe()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Unsubscribing should release a blocked change")
+	}
+
+	async := make(chan ChangeEvent[int], 1)
+	unsubscribe = s.SubscribeChan(async, DeliverAsync)
+	defer unsubscribe()
+	s.Remove(1)
+	select {
+	case e := <-async:
+		if len(e.Removed) != 1 || e.Removed[0] != 1 {
+			t.Errorf("Unexpected event %v", e)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Timed out waiting for an event")
+	}
+}
+
+func Test_ObservableSetConcurrent(t *testing.T) {
+	s := NewObservableSet(NewSet[int]())
+	var mu sync.Mutex
+	count := make(map[int]int)
+	s.Subscribe(func(e ChangeEvent[int]) {
+		mu.Lock()
+		for _, v := range e.Added {
+			count[v]++
+		}
+		for _, v := range e.Removed {
+			count[v]--
+		}
+		mu.Unlock()
+	}, DeliverSync)
+
+	var wg sync.WaitGroup
+	for g := 0; g < 8; g++ {
+		wg.Add(1)
+		go func(g int) {
+			defer wg.Done()
+			for i := 0; i < 200; i++ {
+				s.Add(i % 20)
+				s.Remove((i + g) % 20)
+			}
+		}(g)
+	}
+	wg.Wait()
+
+	// The net count of each element must match its membership.
+	for v := 0; v < 20; v++ {
+		want := 0
+		if s.ContainsOne(v) {
+			want = 1
+		}
+		if count[v] != want {
+			t.Errorf("Element %d: net event count %d, membership %d", v, count[v], want)
+		}
+	}
+}
+
+func Test_ObservableSetBinaryOperations(t *testing.T) {
+	a := NewObservableSet(NewSet(1, 2, 3))
+	b := NewObservableSet(NewSet(2, 3, 4))
+	if !a.Intersect(b).Equal(NewSet(2, 3)) || !a.Union(b).Equal(NewSet(1, 2, 3, 4)) {
+		t.Error("Binary operations should accept observable sets")
+	}
+	if !a.Equal(NewObservableSet(NewSet(3, 2, 1))) || !NewSet(1).IsSubset(unwrap[int](a)) {
+		t.Error("Equal should accept observable sets")
+	}
+	if !a.IsSuperset(NewObservableSet(NewSet(1))) {
+		t.Error("IsSuperset should accept observable sets")
+	}
+}
+
+func Test_ObservableSetUnmarshalBSONValue(t *testing.T) {
+	s := NewObservableSet(NewSet[string]())
+	var r recorder
+	s.Subscribe(r.record, DeliverSync)
+
+	bt, b, err := bson.MarshalValue(NewSet("a", "b"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := bson.UnmarshalValue(bt, b, s); err != nil {
+		t.Fatal(err)
+	}
+	if got := r.get(); len(got) != 1 || len(got[0]) != 2 {
+		t.Errorf("Expected one event adding 2 elements, got %v", got)
+	}
+}
+
+func Test_DeliveryModeString(t *testing.T) {
+	if DeliverSync.String() != "Sync" || DeliverAsync.String() != "Async" || DeliveryMode(5).String() != "DeliveryMode(5)" {
+		t.Error("Unexpected DeliveryMode strings")
+	}
+}
@@ -0,0 +1,500 @@
+/*
+Open Source Initiative OSI - The MIT License (MIT):Licensing
+
+The MIT License (MIT)
+Copyright (c) 2013 - 2026 Ralph Caraveo (deckarep@gmail.com)
+
+Permission is hereby granted, free of charge, to any person obtaining a copy of
+this software and associated documentation files (the "Software"), to deal in
+the Software without restriction, including without limitation the rights to
+use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies
+of the Software, and to permit persons to whom the Software is furnished to do
+so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+
+package mapset
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/bsontype"
+)
+
+// ORSet is an observed-remove set with add-wins semantics (an AWORSet), a
+// delta-state CRDT. Unlike a TwoPhaseSet, removed elements can be added
+// again, and an add concurrent with a remove of the same element wins.
+//
+// Each add is tagged with a dot, a unique (replica, counter) pair, and a
+// remove deletes only the dots it has observed. Every replica keeps a
+// causal context of the dots it has seen, compacted into a version vector
+// per replica, so that merging can tell removed elements from ones not
+// yet seen.
+//
+// Replicas converge by merging either full states or the deltas returned
+// by Delta, in any order. Operations on an ORSet are thread-safe.
+type ORSet[T comparable] interface {
+	// Add adds an element to the set, tagged with a new dot.
+	// Returns whether the item was added, which is false if it
+	// was already present.
+	Add(val T) bool
+
+	// Append multiple elements to the set. Returns
+	// the number of elements added.
+	Append(val ...T) int
+
+	// Cardinality returns the number of elements in the set.
+	Cardinality() int
+
+	// Contains returns whether the given items
+	// are all in the set.
+	Contains(val ...T) bool
+
+	// ContainsOne returns whether the given item
+	// is in the set.
+	ContainsOne(val T) bool
+
+	// Delta returns the changes made by Add and Remove on this
+	// replica since the last call to Delta, as an ORSet that can be
+	// merged into other replicas or serialized. Merging deltas is
+	// equivalent to merging full states, but transfers only the
+	// changes. The returned delta should not itself be modified.
+	Delta() ORSet[T]
+
+	// Each iterates over elements and executes the passed func against each element.
+	// If passed func returns true, stop iteration at the time.
+	Each(func(T) bool)
+
+	// Merge combines the state of other, which may be a full state or
+	// a delta, with the set.
+	//
+	// Note that the argument to Merge must be of the same type
+	// as the receiver of the method. Otherwise, Merge will panic.
+	Merge(other ORSet[T])
+
+	// Remove removes an element from the set, deleting the dots this
+	// replica has observed for it. Returns whether the item was
+	// removed, which is false if it was not present.
+	Remove(val T) bool
+
+	// Replica returns the identifier of the replica.
+	Replica() string
+
+	// String provides a convenient string representation
+	// of the current state of the set.
+	String() string
+
+	// ToSet returns the current members as a thread-safe Set.
+	ToSet() Set[T]
+
+	// ToSlice returns the members of the set as a slice.
+	ToSlice() []T
+
+	// MarshalJSON will marshal the set's state, including its
+	// causal context, into a JSON object.
+	MarshalJSON() ([]byte, error)
+
+	// UnmarshalJSON will merge a state created by MarshalJSON
+	// into the set.
+	UnmarshalJSON(b []byte) error
+
+	// MarshalBSONValue will marshal the set's state, including its
+	// causal context, into a BSON document.
+	MarshalBSONValue() (bsontype.Type, []byte, error)
+
+	// UnmarshalBSONValue will merge a state created by
+	// MarshalBSONValue into the set.
+	UnmarshalBSONValue(bt bsontype.Type, b []byte) error
+}
+
+// NewORSet creates and returns a new, empty ORSet for the given replica.
+// Every replica of a set must have a distinct identifier.
+func NewORSet[T comparable](replica string) ORSet[T] {
+	return &orSet[T]{
+		replica: replica,
+		state:   newDotKernel[T](),
+		delta:   newDotKernel[T](),
+	}
+}
+
+// dot identifies a single add: the counter-th event of a replica.
+type dot struct {
+	Replica string `json:"replica" bson:"replica"`
+	Counter uint64 `json:"counter" bson:"counter"`
+}
+
+// causalContext is a set of dots, compacted into a version vector holding
+// the highest counter of each replica up to which all dots are present,
+// and a cloud of the dots beyond it.
+type causalContext struct {
+	versions map[string]uint64
+	cloud    map[dot]struct{}
+}
+
+func newCausalContext() causalContext {
+	return causalContext{versions: make(map[string]uint64), cloud: make(map[dot]struct{})}
+}
+
+func (c causalContext) contains(d dot) bool {
+	if d.Counter <= c.versions[d.Replica] {
+		return true
+	}
+	_, ok := c.cloud[d]
+	return ok
+}
+
+// next returns a new dot for replica. The replica's own dots are always
+// contiguous, so the next one follows its version.
+func (c causalContext) next(replica string) dot {
+	return dot{Replica: replica, Counter: c.versions[replica] + 1}
+}
+
+func (c causalContext) add(d dot) {
+	if !c.contains(d) {
+		c.cloud[d] = struct{}{}
+	}
+}
+
+func (c causalContext) merge(o causalContext) {
+	for r, v := range o.versions {
+		if v > c.versions[r] {
+			c.versions[r] = v
+		}
+	}
+	for d := range o.cloud {
+		c.add(d)
+	}
+}
+
+// compact moves dots from the cloud into the version vector while they
+// extend it contiguously.
+func (c causalContext) compact() {
+	for changed := true; changed; {
+		changed = false
+		for d := range c.cloud {
+			v := c.versions[d.Replica]
+			switch {
+			case d.Counter == v+1:
+				c.versions[d.Replica] = d.Counter
+				changed = true
+				fallthrough
+			case d.Counter <= v:
+				delete(c.cloud, d)
+			}
+		}
+	}
+}
+
+// dotKernel maps each element present to the dots of the adds that made
+// it present, together with the causal context of every dot seen.
+type dotKernel[T comparable] struct {
+	entries map[T]map[dot]struct{}
+	context causalContext
+}
+
+func newDotKernel[T comparable]() *dotKernel[T] {
+	return &dotKernel[T]{entries: make(map[T]map[dot]struct{}), context: newCausalContext()}
+}
+
+func (k *dotKernel[T]) hasEntry(v T, d dot) bool {
+	_, ok := k.entries[v][d]
+	return ok
+}
+
+func (k *dotKernel[T]) addEntry(v T, d dot) {
+	ds, ok := k.entries[v]
+	if !ok {
+		ds = make(map[dot]struct{}, 1)
+		k.entries[v] = ds
+	}
+	ds[d] = struct{}{}
+}
+
+// join merges o into k. A dot survives if both sides have it, or if one
+// side has it and the other has not seen it; a dot one side has seen but
+// no longer has was removed.
+func (k *dotKernel[T]) join(o *dotKernel[T]) {
+	for v, ds := range k.entries {
+		for d := range ds {
+			if !o.hasEntry(v, d) && o.context.contains(d) {
+				delete(ds, d)
+			}
+		}
+		if len(ds) == 0 {
+			delete(k.entries, v)
+		}
+	}
+	for v, ds := range o.entries {
+		for d := range ds {
+			if !k.context.contains(d) {
+				k.addEntry(v, d)
+			}
+		}
+	}
+	k.context.merge(o.context)
+	k.context.compact()
+}
+
+func (k *dotKernel[T]) clone() *dotKernel[T] {
+	c := newDotKernel[T]()
+	for v, ds := range k.entries {
+		for d := range ds {
+			c.addEntry(v, d)
+		}
+	}
+	c.context.merge(k.context)
+	return c
+}
+
+type orSet[T comparable] struct {
+	sync.RWMutex
+	replica string
+	state   *dotKernel[T]
+	delta   *dotKernel[T]
+}
+
+// Assert concrete type:orSet adheres to ORSet interface.
+var _ ORSet[string] = (*orSet[string])(nil)
+
+// apply joins the change d into the state and the pending delta.
+func (s *orSet[T]) apply(d *dotKernel[T]) {
+	s.state.join(d)
+	s.delta.join(d)
+}
+
+// private version of Add which expects the lock to be held
+func (s *orSet[T]) add(v T) bool {
+	_, found := s.state.entries[v]
+
+	// The new dot supersedes the dots of earlier adds of v.
+	d := newDotKernel[T]()
+	for old := range s.state.entries[v] {
+		d.context.add(old)
+	}
+	next := s.state.context.next(s.replica)
+	d.addEntry(v, next)
+	d.context.add(next)
+	s.apply(d)
+	return !found
+}
+
+func (s *orSet[T]) Add(v T) bool {
+	s.Lock()
+	defer s.Unlock()
+	return s.add(v)
+}
+
+func (s *orSet[T]) Append(vs ...T) int {
+	s.Lock()
+	defer s.Unlock()
+	var n int
+	for _, v := range vs {
+		if s.add(v) {
+			n++
+		}
+	}
+	return n
+}
+
+func (s *orSet[T]) Cardinality() int {
+	s.RLock()
+	defer s.RUnlock()
+	return len(s.state.entries)
+}
+
+func (s *orSet[T]) Contains(vs ...T) bool {
+	s.RLock()
+	defer s.RUnlock()
+	for _, v := range vs {
+		if _, ok := s.state.entries[v]; !ok {
+			return false
+		}
+	}
+	return true
+}
+
+func (s *orSet[T]) ContainsOne(v T) bool {
+	s.RLock()
+	defer s.RUnlock()
+	_, ok := s.state.entries[v]
+	return ok
+}
+
+func (s *orSet[T]) Delta() ORSet[T] {
+	s.Lock()
+	defer s.Unlock()
+	d := &orSet[T]{replica: s.replica, state: s.delta, delta: newDotKernel[T]()}
+	s.delta = newDotKernel[T]()
+	return d
+}
+
+func (s *orSet[T]) Each(cb func(T) bool) {
+	s.RLock()
+	defer s.RUnlock()
+	for elem := range s.state.entries {
+		if cb(elem) {
+			break
+		}
+	}
+}
+
+func (s *orSet[T]) Merge(other ORSet[T]) {
+	o := other.(*orSet[T])
+
+	// Copy other before locking s, so concurrent merges in opposite
+	// directions cannot deadlock.
+	o.RLock()
+	k := o.state.clone()
+	o.RUnlock()
+
+	s.Lock()
+	s.state.join(k)
+	s.Unlock()
+}
+
+func (s *orSet[T]) Remove(v T) bool {
+	s.Lock()
+	defer s.Unlock()
+	ds, found := s.state.entries[v]
+	if !found {
+		return false
+	}
+
+	d := newDotKernel[T]()
+	for old := range ds {
+		d.context.add(old)
+	}
+	s.apply(d)
+	return true
+}
+
+func (s *orSet[T]) Replica() string {
+	return s.replica
+}
+
+func (s *orSet[T]) String() string {
+	vs := s.ToSlice()
+	items := make([]string, 0, len(vs))
+	for _, v := range vs {
+		items = append(items, fmt.Sprintf("%v", v))
+	}
+	return fmt.Sprintf("ORSet{%s}", strings.Join(items, ", "))
+}
+
+func (s *orSet[T]) ToSet() Set[T] {
+	return NewSet(s.ToSlice()...)
+}
+
+func (s *orSet[T]) ToSlice() []T {
+	s.RLock()
+	defer s.RUnlock()
+	keys := make([]T, 0, len(s.state.entries))
+	for elem := range s.state.entries {
+		keys = append(keys, elem)
+	}
+	return keys
+}
+
+type orSetEntry[T comparable] struct {
+	Value T     `json:"value" bson:"value"`
+	Dots  []dot `json:"dots" bson:"dots"`
+}
+
+type orSetContext struct {
+	Versions map[string]uint64 `json:"versions" bson:"versions"`
+	Dots     []dot             `json:"dots,omitempty" bson:"dots,omitempty"`
+}
+
+type orSetState[T comparable] struct {
+	Entries []orSetEntry[T] `json:"entries" bson:"entries"`
+	Context orSetContext    `json:"context" bson:"context"`
+}
+
+func (s *orSet[T]) encodeState() orSetState[T] {
+	s.RLock()
+	defer s.RUnlock()
+
+	st := orSetState[T]{
+		Entries: make([]orSetEntry[T], 0, len(s.state.entries)),
+		Context: orSetContext{Versions: make(map[string]uint64, len(s.state.context.versions))},
+	}
+	for v, ds := range s.state.entries {
+		e := orSetEntry[T]{Value: v, Dots: make([]dot, 0, len(ds))}
+		for d := range ds {
+			e.Dots = append(e.Dots, d)
+		}
+		st.Entries = append(st.Entries, e)
+	}
+	for d := range s.state.context.cloud {
+		st.Context.Dots = append(st.Context.Dots, d)
+	}
+	for r, v := range s.state.context.versions {
+		st.Context.Versions[r] = v
+	}
+	return st
+}
+
+func (s *orSet[T]) mergeState(st orSetState[T]) {
+	k := newDotKernel[T]()
+	for r, v := range st.Context.Versions {
+		k.context.versions[r] = v
+	}
+	for _, d := range st.Context.Dots {
+		k.context.add(d)
+	}
+	for _, e := range st.Entries {
+		for _, d := range e.Dots {
+			k.addEntry(e.Value, d)
+			// An entry's dot is always in its context.
+			k.context.add(d)
+		}
+	}
+
+	s.Lock()
+	s.state.join(k)
+	s.Unlock()
+}
+
+func (s *orSet[T]) MarshalJSON() ([]byte, error) {
+	return json.Marshal(s.encodeState())
+}
+
+func (s *orSet[T]) UnmarshalJSON(b []byte) error {
+	var st orSetState[T]
+	if err := json.Unmarshal(b, &st); err != nil {
+		return err
+	}
+	s.mergeState(st)
+	return nil
+}
+
+func (s *orSet[T]) MarshalBSONValue() (bsontype.Type, []byte, error) {
+	return bson.MarshalValue(s.encodeState())
+}
+
+func (s *orSet[T]) UnmarshalBSONValue(bt bsontype.Type, b []byte) error {
+	if bt != bson.TypeEmbeddedDocument {
+		return fmt.Errorf("must use BSON Document to unmarshal ORSet")
+	}
+
+	var st orSetState[T]
+	if err := bson.UnmarshalValue(bt, b, &st); err != nil {
+		return err
+	}
+	s.mergeState(st)
+	return nil
+}
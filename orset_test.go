@@ -0,0 +1,241 @@
+/*
+Open Source Initiative OSI - The MIT License (MIT):Licensing
+
+The MIT License (MIT)
+Copyright (c) 2013 - 2026 Ralph Caraveo (deckarep@gmail.com)
+
+Permission is hereby granted, free of charge, to any person obtaining a copy of
+this software and associated documentation files (the "Software"), to deal in
+the Software without restriction, including without limitation the rights to
+use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies
+of the Software, and to permit persons to whom the Software is furnished to do
+so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+
+package mapset
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/rand"
+	"reflect"
+	"testing"
+
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// orSetEqual compares the full states of a and b, including their causal
+// contexts.
+func orSetEqual(a, b ORSet[int]) bool {
+	x, y := a.(*orSet[int]), b.(*orSet[int])
+	return reflect.DeepEqual(x.state.entries, y.state.entries) &&
+		reflect.DeepEqual(x.state.context, y.state.context)
+}
+
+func orSetMerge(a, b ORSet[int]) ORSet[int] {
+	m := NewORSet[int]("merge")
+	m.Merge(a)
+	m.Merge(b)
+	return m
+}
+
+// randomORSets returns replicas that have made random adds and removes
+// and exchanged some of their states.
+func randomORSets(r *rand.Rand, n int) []ORSet[int] {
+	sets := make([]ORSet[int], n)
+	for i := range sets {
+		sets[i] = NewORSet[int](fmt.Sprintf("r%d", i))
+	}
+	for i := r.Intn(30); i > 0; i-- {
+		s := sets[r.Intn(n)]
+		switch v := r.Intn(10); r.Intn(4) {
+		case 0:
+			s.Remove(v)
+		case 1:
+			s.Merge(sets[r.Intn(n)])
+		default:
+			s.Add(v)
+		}
+	}
+	return sets
+}
+
+func Test_ORSetMergeProperties(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	for i := 0; i < 200; i++ {
+		sets := randomORSets(r, 3)
+		a, b, c := sets[0], sets[1], sets[2]
+		if !orSetEqual(orSetMerge(a, b), orSetMerge(b, a)) {
+			t.Fatalf("Merge should be commutative: %v, %v", a, b)
+		}
+		if !orSetEqual(orSetMerge(orSetMerge(a, b), c), orSetMerge(a, orSetMerge(b, c))) {
+			t.Fatalf("Merge should be associative: %v, %v, %v", a, b, c)
+		}
+		if !orSetEqual(orSetMerge(a, a), orSetMerge(a, NewORSet[int]("other"))) {
+			t.Fatalf("Merge should be idempotent: %v", a)
+		}
+	}
+}
+
+func Test_ORSetReAdd(t *testing.T) {
+	s := NewORSet[string]("a")
+	if !s.Add("x") || s.Add("x") {
+		t.Error("Add should report whether the element was added")
+	}
+	if !s.Remove("x") || s.Remove("x") || s.ContainsOne("x") {
+		t.Error("Remove should report whether the element was removed")
+	}
+	if !s.Add("x") || !s.ContainsOne("x") {
+		t.Error("A removed element should be added again")
+	}
+	if s.Append("x", "y", "z") != 2 || s.Cardinality() != 3 || !s.Contains("x", "y", "z") {
+		t.Errorf("Unexpected contents %v", s)
+	}
+	if s.Replica() != "a" {
+		t.Errorf("Expected replica a, got %s", s.Replica())
+	}
+}
+
+func Test_ORSetAddWins(t *testing.T) {
+	a := NewORSet[string]("a")
+	b := NewORSet[string]("b")
+	a.Add("x")
+	b.Merge(a)
+
+	// a removes x while b concurrently adds it again.
+	a.Remove("x")
+	b.Add("x")
+	a.Merge(b)
+	b.Merge(a)
+	if !a.ContainsOne("x") || !b.ContainsOne("x") {
+		t.Error("A concurrent add should win over a remove")
+	}
+
+	// A remove that has observed every add takes effect everywhere.
+	a.Remove("x")
+	b.Merge(a)
+	if a.ContainsOne("x") || b.ContainsOne("x") {
+		t.Error("An observed remove should take effect")
+	}
+}
+
+func Test_ORSetUnseenAdd(t *testing.T) {
+	a := NewORSet[string]("a")
+	b := NewORSet[string]("b")
+	b.Add("x")
+	b.Remove("x")
+	b.Add("y")
+
+	// a has never seen x, so a merge must not resurrect it.
+	a.Merge(b)
+	if a.ContainsOne("x") || !a.ContainsOne("y") {
+		t.Errorf("Expected {y}, got %v", a)
+	}
+}
+
+func Test_ORSetDelta(t *testing.T) {
+	a := NewORSet[int]("a")
+	b := NewORSet[int]("b")
+	full := NewORSet[int]("full")
+
+	a.Append(1, 2, 3)
+	b.Append(3, 4)
+	da, db := a.Delta(), b.Delta()
+	a.Merge(db)
+	b.Merge(da)
+
+	a.Remove(3)
+	b.Add(5)
+	a.Add(1)
+	da, db = a.Delta(), b.Delta()
+	if da.Cardinality() != 1 || db.Cardinality() != 1 {
+		t.Errorf("Deltas should hold only recent changes, got %v and %v", da, db)
+	}
+	a.Merge(db)
+	b.Merge(da)
+
+	full.Merge(a)
+	full.Merge(b)
+	// b's 3 was observed by a's remove, so every add of 3 is removed.
+	want := NewSet(1, 2, 4, 5)
+	if !a.ToSet().Equal(want) || !b.ToSet().Equal(want) || !full.ToSet().Equal(want) {
+		t.Errorf("Expected %v, got %v and %v", want, a, b)
+	}
+	if a.Delta().Cardinality() != 0 {
+		t.Error("Delta should reset the pending changes")
+	}
+}
+
+func Test_ORSetCompaction(t *testing.T) {
+	sets := []ORSet[int]{NewORSet[int]("a"), NewORSet[int]("b"), NewORSet[int]("c")}
+	for i := 0; i < 100; i++ {
+		s := sets[i%3]
+		s.Add(i % 7)
+		s.Remove((i + 3) % 7)
+	}
+	for _, s := range sets {
+		for _, o := range sets {
+			s.Merge(o)
+		}
+	}
+	for _, s := range sets {
+		ctx := s.(*orSet[int]).state.context
+		if len(ctx.cloud) != 0 || len(ctx.versions) != 3 {
+			t.Errorf("Expected a compacted context, got %v", ctx)
+		}
+	}
+}
+
+func Test_ORSetSerialization(t *testing.T) {
+	a := NewORSet[int]("a")
+	a.Append(1, 2, 3)
+	a.Remove(2)
+	b := NewORSet[int]("b")
+	b.Add(4)
+	b.Merge(a)
+	b.Delta()
+
+	data, err := json.Marshal(b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := NewORSet[int]("c")
+	if err := json.Unmarshal(data, got); err != nil {
+		t.Fatal(err)
+	}
+	if !orSetEqual(got, orSetMerge(b, NewORSet[int]("c"))) {
+		t.Errorf("Expected %v, got %v", b, got)
+	}
+
+	// The decoded context must still suppress removed elements.
+	a.Merge(got)
+	if a.ContainsOne(2) {
+		t.Error("A decoded state should keep its causal context")
+	}
+
+	bt, raw, err := bson.MarshalValue(a.Delta())
+	if err != nil {
+		t.Fatal(err)
+	}
+	got = NewORSet[int]("d")
+	if err := bson.UnmarshalValue(bt, raw, got); err != nil {
+		t.Fatal(err)
+	}
+	if !got.ToSet().Equal(NewSet(1, 3)) {
+		t.Errorf("Expected {1, 3}, got %v", got)
+	}
+	if err := got.UnmarshalBSONValue(bson.TypeArray, raw); err == nil {
+		t.Error("Expected an error decoding an array")
+	}
+}
@@ -0,0 +1,619 @@
+/*
+Open Source Initiative OSI - The MIT License (MIT):Licensing
+
+The MIT License (MIT)
+Copyright (c) 2013 - 2026 Ralph Caraveo (deckarep@gmail.com)
+
+Permission is hereby granted, free of charge, to any person obtaining a copy of
+this software and associated documentation files (the "Software"), to deal in
+the Software without restriction, including without limitation the rights to
+use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies
+of the Software, and to permit persons to whom the Software is furnished to do
+so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+
+package mapset
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// ErrCorruptSnapshot is returned when opening a PersistentSet whose
+// snapshot file is damaged. Snapshots are replaced atomically, so this
+// indicates damage by something other than a crash.
+var ErrCorruptSnapshot = errors.New("mapset: corrupt snapshot")
+
+// ErrCorruptLog is returned when opening a PersistentSet whose log has a
+// damaged entry followed by more data. Only the last entry can be damaged
+// by a crash, so this indicates damage by something else, and the log is
+// left untouched.
+var ErrCorruptLog = errors.New("mapset: corrupt log")
+
+// errTornEntry marks a log entry that was only partly written.
+var errTornEntry = errors.New("mapset: torn log entry")
+
+// Codec converts elements to and from bytes for storage.
+type Codec[T comparable] interface {
+	Encode(val T) ([]byte, error)
+	Decode(b []byte) (T, error)
+}
+
+// JSONCodec returns a Codec that stores elements as JSON.
+func JSONCodec[T comparable]() Codec[T] {
+	return jsonCodec[T]{}
+}
+
+type jsonCodec[T comparable] struct{}
+
+func (jsonCodec[T]) Encode(v T) ([]byte, error) {
+	return json.Marshal(v)
+}
+
+func (jsonCodec[T]) Decode(b []byte) (T, error) {
+	var v T
+	err := json.Unmarshal(b, &v)
+	return v, err
+}
+
+// SyncPolicy controls when a PersistentSet forces its log to stable
+// storage.
+type SyncPolicy int
+
+const (
+	// SyncAlways syncs the log after every change, so that a change
+	// survives a power failure once the call making it returns.
+	SyncAlways SyncPolicy = iota
+
+	// SyncPeriodically syncs the log in the background every
+	// SyncInterval. Changes made since the last sync survive a crash
+	// of the process but may be lost on a power failure.
+	SyncPeriodically
+
+	// SyncNever leaves syncing to the operating system, except when
+	// compacting and closing.
+	SyncNever
+)
+
+func (p SyncPolicy) String() string {
+	switch p {
+	case SyncAlways:
+		return "SyncAlways"
+	case SyncPeriodically:
+		return "SyncPeriodically"
+	case SyncNever:
+		return "SyncNever"
+	}
+	return fmt.Sprintf("SyncPolicy(%d)", int(p))
+}
+
+// PersistentSetOptions configures a PersistentSet. The zero value is valid.
+type PersistentSetOptions[T comparable] struct {
+	// Codec encodes elements. It defaults to JSONCodec.
+	Codec Codec[T]
+
+	// Sync is the policy for syncing the log. It defaults to
+	// SyncAlways.
+	Sync SyncPolicy
+
+	// SyncInterval is the period of the background sync under
+	// SyncPeriodically. It defaults to one second.
+	SyncInterval time.Duration
+
+	// CompactAfter is the number of changes the log may hold before
+	// it is compacted into a snapshot. The log is only compacted
+	// automatically once it also holds more changes than the set has
+	// elements. It defaults to 1024, and a negative value disables
+	// automatic compaction.
+	CompactAfter int
+}
+
+// PersistentSet is a Set stored in a directory on the local filesystem.
+// Every change is appended to a write-ahead log before the call making it
+// returns, and the log is periodically compacted into a snapshot of the
+// whole set. Opening the directory again recovers the set from the
+// snapshot and the log.
+//
+// The methods of Set cannot report errors, so the first error writing the
+// log is kept and returned by Err, Sync, Compact and Close. Once an error
+// has occurred changes are still made in memory but are no longer
+// logged. Clone and the methods that return a new set return sets that
+// are not persistent.
+//
+// A directory must not be opened by more than one PersistentSet at a time.
+// Operations on a PersistentSet are thread-safe.
+type PersistentSet[T comparable] interface {
+	Set[T]
+
+	// Compact writes a snapshot of the set and empties the log.
+	Compact() error
+
+	// Sync forces the log to stable storage.
+	Sync() error
+
+	// Err returns the first error that occurred writing the log, if
+	// any.
+	Err() error
+
+	// Close syncs and closes the log. The set remains readable, but
+	// changes made afterwards are not persisted.
+	Close() error
+}
+
+const (
+	persistentSnapshotFile = "snapshot"
+	persistentLogFile      = "wal"
+
+	persistentMagic   = "MSPS"
+	persistentVersion = 1
+
+	defaultCompactAfter = 1024
+
+	// maxLogEntry is the largest body of a log entry, which bounds
+	// the length a damaged entry can claim.
+	maxLogEntry = 1 << 30
+)
+
+// Log operations. Each log entry holds the operations of one change.
+const (
+	opAdd    = '+'
+	opRemove = '-'
+	opClear  = 'c'
+)
+
+// OpenPersistentSet opens the PersistentSet stored in dir, creating dir and
+// an empty set if it does not exist. An entry at the end of the log that
+// was only partly written, as after a crash, is discarded. A damaged entry
+// anywhere else makes OpenPersistentSet fail with ErrCorruptLog.
+func OpenPersistentSet[T comparable](dir string, opts PersistentSetOptions[T]) (PersistentSet[T], error) {
+	if opts.Codec == nil {
+		opts.Codec = JSONCodec[T]()
+	}
+	if opts.SyncInterval <= 0 {
+		opts.SyncInterval = time.Second
+	}
+	if opts.CompactAfter == 0 {
+		opts.CompactAfter = defaultCompactAfter
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+
+	s := &persistentSet[T]{
+		dir:          dir,
+		codec:        opts.Codec,
+		policy:       opts.Sync,
+		compactAfter: opts.CompactAfter,
+	}
+	s.Set = newThreadSafeSet[T]()
+	s.onChange = s.log
+
+	if err := s.readSnapshot(); err != nil {
+		return nil, err
+	}
+	if err := s.openLog(); err != nil {
+		return nil, err
+	}
+
+	if s.policy == SyncPeriodically {
+		s.stop = make(chan struct{})
+		s.done = make(chan struct{})
+		go s.syncEvery(opts.SyncInterval)
+	}
+	return s, nil
+}
+
+type persistentSet[T comparable] struct {
+	recordingSet[T]
+	dir          string
+	codec        Codec[T]
+	policy       SyncPolicy
+	compactAfter int
+
+	// The fields below are guarded by recordingSet.mu.
+	file    *os.File
+	entries int
+	dirty   bool
+	err     error
+	closed  bool
+
+	stop     chan struct{}
+	stopOnce sync.Once
+	done     chan struct{}
+}
+
+// Assert concrete type:persistentSet adheres to PersistentSet interface.
+var _ PersistentSet[string] = (*persistentSet[string])(nil)
+
+// appendRecord appends an operation, with the encoded element for adds
+// and removes, to b.
+func appendRecord(b []byte, op byte, elem []byte) []byte {
+	b = append(b, op)
+	if op == opClear {
+		return b
+	}
+	var buf [binary.MaxVarintLen64]byte
+	n := binary.PutUvarint(buf[:], uint64(len(elem)))
+	return append(append(b, buf[:n]...), elem...)
+}
+
+// readRecord reads an operation written by appendRecord from b, returning
+// the operation, the element and the remaining bytes.
+func readRecord(b []byte) (op byte, elem, rest []byte, ok bool) {
+	if len(b) == 0 {
+		return 0, nil, nil, false
+	}
+	op, b = b[0], b[1:]
+	switch op {
+	case opClear:
+		return op, nil, b, true
+	case opAdd, opRemove:
+	default:
+		return 0, nil, nil, false
+	}
+	n, k := binary.Uvarint(b)
+	if k <= 0 || n > uint64(len(b)-k) {
+		return 0, nil, nil, false
+	}
+	b = b[k:]
+	return op, b[:n], b[n:], true
+}
+
+func (s *persistentSet[T]) path(name string) string {
+	return filepath.Join(s.dir, name)
+}
+
+// readSnapshot loads the snapshot, if any, into the set.
+//
+// A snapshot is the magic string and version, the number of elements as
+// a uvarint, an add operation for each element and the CRC-32 of all the
+// preceding bytes.
+func (s *persistentSet[T]) readSnapshot() error {
+	name := s.path(persistentSnapshotFile)
+	data, err := os.ReadFile(name)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+
+	corrupt := fmt.Errorf("%w: %s", ErrCorruptSnapshot, name)
+	header := len(persistentMagic) + 1
+	if len(data) < header+4 || string(data[:len(persistentMagic)]) != persistentMagic || data[len(persistentMagic)] != persistentVersion {
+		return corrupt
+	}
+	body, sum := data[:len(data)-4], binary.LittleEndian.Uint32(data[len(data)-4:])
+	if crc32.ChecksumIEEE(body) != sum {
+		return corrupt
+	}
+
+	n, k := binary.Uvarint(body[header:])
+	if k <= 0 {
+		return corrupt
+	}
+	b := body[header+k:]
+	vs := make([]T, 0, n)
+	for len(b) > 0 {
+		op, elem, rest, ok := readRecord(b)
+		if !ok || op != opAdd {
+			return corrupt
+		}
+		v, err := s.codec.Decode(elem)
+		if err != nil {
+			return fmt.Errorf("%s: %w", name, err)
+		}
+		vs = append(vs, v)
+		b = rest
+	}
+	if uint64(len(vs)) != n {
+		return corrupt
+	}
+	s.Set.Append(vs...)
+	return nil
+}
+
+// openLog replays the log into the set and opens it for appending,
+// truncating any partly written entry at its end.
+//
+// Each log entry is the CRC-32 of its body, the length of its body as a
+// uvarint and a body holding the operations of one change.
+func (s *persistentSet[T]) openLog() error {
+	name := s.path(persistentLogFile)
+	data, err := os.ReadFile(name)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+
+	b := data
+	for len(b) > 0 {
+		body, rest, err := readLogEntry(b)
+		if err == errTornEntry {
+			break
+		} else if err != nil {
+			return fmt.Errorf("%w: %s at offset %d", err, name, len(data)-len(b))
+		}
+		if err := s.replay(body); err != nil {
+			return fmt.Errorf("%s: %w", name, err)
+		}
+		s.entries++
+		b = rest
+	}
+	valid := len(data) - len(b)
+
+	f, err := os.OpenFile(name, os.O_RDWR|os.O_CREATE|os.O_APPEND, 0o644)
+	if err != nil {
+		return err
+	}
+	if valid < len(data) {
+		if err := f.Truncate(int64(valid)); err != nil {
+			f.Close()
+			return err
+		}
+	}
+	s.file = f
+	return nil
+}
+
+// readLogEntry returns the body of the first log entry in b and the bytes
+// after it. A damaged entry can only be the result of an interrupted
+// write if it is the last entry, so readLogEntry returns errTornEntry if
+// no intact entry starts after the start of a damaged one, and
+// ErrCorruptLog if one does.
+func readLogEntry(b []byte) (body, rest []byte, err error) {
+	if body, rest, ok := parseLogEntry(b); ok {
+		return body, rest, nil
+	}
+	for i := 1; i < len(b); i++ {
+		if _, _, ok := parseLogEntry(b[i:]); ok {
+			return nil, nil, ErrCorruptLog
+		}
+	}
+	return nil, nil, errTornEntry
+}
+
+// parseLogEntry returns the body of the log entry at the start of b and
+// the bytes after it, if the entry is complete and intact. Bodies are
+// never empty, which stops a run of zero bytes, as a crash can leave at
+// the end of a file, from passing as intact entries.
+func parseLogEntry(b []byte) (body, rest []byte, ok bool) {
+	if len(b) < 4 {
+		return nil, nil, false
+	}
+	sum := binary.LittleEndian.Uint32(b)
+	n, k := binary.Uvarint(b[4:])
+	if k <= 0 || n == 0 || n > maxLogEntry || n > uint64(len(b)-4-k) {
+		return nil, nil, false
+	}
+	body, rest = b[4+k:4+k+int(n)], b[4+k+int(n):]
+	return body, rest, crc32.ChecksumIEEE(body) == sum
+}
+
+// replay applies the operations in the body of a log entry to the set.
+func (s *persistentSet[T]) replay(body []byte) error {
+	for len(body) > 0 {
+		op, elem, rest, ok := readRecord(body)
+		if !ok {
+			return fmt.Errorf("mapset: invalid log operation")
+		}
+		body = rest
+		if op == opClear {
+			s.Set.Clear()
+			continue
+		}
+
+		v, err := s.codec.Decode(elem)
+		if err != nil {
+			return err
+		}
+		if op == opAdd {
+			s.Set.Add(v)
+		} else {
+			s.Set.Remove(v)
+		}
+	}
+	return nil
+}
+
+// log appends a change to the log. It is called with mu held.
+func (s *persistentSet[T]) log(added, removed []T) {
+	if s.err != nil || s.closed {
+		return
+	}
+
+	var body []byte
+	if len(removed) > 0 && s.Set.IsEmpty() {
+		body = appendRecord(body, opClear, nil)
+	} else {
+		body, s.err = s.appendRecords(body, opRemove, removed)
+	}
+	if s.err == nil {
+		body, s.err = s.appendRecords(body, opAdd, added)
+	}
+	if s.err == nil && len(body) > maxLogEntry {
+		s.err = fmt.Errorf("mapset: change of %d bytes exceeds the log entry limit", len(body))
+	}
+	if s.err != nil {
+		return
+	}
+
+	var buf [4 + binary.MaxVarintLen64]byte
+	binary.LittleEndian.PutUint32(buf[:], crc32.ChecksumIEEE(body))
+	n := binary.PutUvarint(buf[4:], uint64(len(body)))
+	entry := append(buf[:4+n:4+n], body...)
+	if _, s.err = s.file.Write(entry); s.err != nil {
+		return
+	}
+	s.entries++
+	s.dirty = true
+
+	if s.compactAfter > 0 && s.entries > s.compactAfter && s.entries > s.Set.Cardinality() {
+		s.err = s.compact()
+	} else if s.policy == SyncAlways {
+		s.err = s.sync()
+	}
+}
+
+func (s *persistentSet[T]) appendRecords(b []byte, op byte, vs []T) ([]byte, error) {
+	for _, v := range vs {
+		elem, err := s.codec.Encode(v)
+		if err != nil {
+			return nil, err
+		}
+		b = appendRecord(b, op, elem)
+	}
+	return b, nil
+}
+
+// private version of Sync which expects s.mu to be held
+func (s *persistentSet[T]) sync() error {
+	if !s.dirty || s.closed {
+		return nil
+	}
+	if err := s.file.Sync(); err != nil {
+		return err
+	}
+	s.dirty = false
+	return nil
+}
+
+// compact writes a snapshot to a temporary file, renames it over the
+// previous snapshot and empties the log. The log is replayed on top of
+// the snapshot when opening, so a crash between the two steps is
+// harmless: replaying changes the snapshot already includes leaves each
+// element as the last of them left it. It is called with mu held.
+func (s *persistentSet[T]) compact() error {
+	vs := s.Set.ToSlice()
+	data := append([]byte(persistentMagic), persistentVersion)
+	var buf [binary.MaxVarintLen64]byte
+	n := binary.PutUvarint(buf[:], uint64(len(vs)))
+	data, err := s.appendRecords(append(data, buf[:n]...), opAdd, vs)
+	if err != nil {
+		return err
+	}
+	data = appendUint32(data, crc32.ChecksumIEEE(data))
+
+	tmp := s.path(persistentSnapshotFile + ".tmp")
+	if err := writeFileSync(tmp, data); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp, s.path(persistentSnapshotFile)); err != nil {
+		return err
+	}
+	syncDir(s.dir)
+
+	if err := s.file.Truncate(0); err != nil {
+		return err
+	}
+	if err := s.file.Sync(); err != nil {
+		return err
+	}
+	s.entries = 0
+	s.dirty = false
+	return nil
+}
+
+// writeFileSync writes data to the named file and syncs it.
+func writeFileSync(name string, data []byte) error {
+	f, err := os.OpenFile(name, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0o644)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Sync(); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// syncDir syncs a directory so that a rename within it is durable. Not
+// every platform supports syncing directories, so errors are ignored.
+func syncDir(dir string) {
+	if d, err := os.Open(dir); err == nil {
+		d.Sync()
+		d.Close()
+	}
+}
+
+func (s *persistentSet[T]) Compact() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.err == nil && !s.closed {
+		s.err = s.compact()
+	}
+	return s.err
+}
+
+func (s *persistentSet[T]) Sync() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.err == nil {
+		s.err = s.sync()
+	}
+	return s.err
+}
+
+func (s *persistentSet[T]) Err() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.err
+}
+
+func (s *persistentSet[T]) Close() error {
+	if s.stop != nil {
+		s.stopOnce.Do(func() {
+			close(s.stop)
+		})
+		<-s.done
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closed {
+		return s.err
+	}
+	if s.err == nil {
+		s.err = s.sync()
+	}
+	if err := s.file.Close(); s.err == nil {
+		s.err = err
+	}
+	s.closed = true
+	return s.err
+}
+
+func (s *persistentSet[T]) syncEvery(interval time.Duration) {
+	defer close(s.done)
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-s.stop:
+			return
+		case <-ticker.C:
+			s.Sync()
+		}
+	}
+}
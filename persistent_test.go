@@ -0,0 +1,345 @@
+/*
+Open Source Initiative OSI - The MIT License (MIT):Licensing
+
+The MIT License (MIT)
+Copyright (c) 2013 - 2026 Ralph Caraveo (deckarep@gmail.com)
+
+Permission is hereby granted, free of charge, to any person obtaining a copy of
+this software and associated documentation files (the "Software"), to deal in
+the Software without restriction, including without limitation the rights to
+use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies
+of the Software, and to permit persons to whom the Software is furnished to do
+so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+
+package mapset
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func openPersistent[T comparable](t *testing.T, dir string, opts PersistentSetOptions[T]) PersistentSet[T] {
+	t.Helper()
+	s, err := OpenPersistentSet(dir, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return s
+}
+
+func closePersistent[T comparable](t *testing.T, s PersistentSet[T]) {
+	t.Helper()
+	if err := s.Close(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestPersistentSetRecovers(t *testing.T) {
+	dir := t.TempDir()
+	s := openPersistent(t, dir, PersistentSetOptions[string]{})
+	s.Append("a", "b", "c")
+	s.Remove("b")
+	s.Add("d")
+	s.Pop()
+	want := s.Clone()
+	closePersistent(t, s)
+
+	s = openPersistent(t, dir, PersistentSetOptions[string]{})
+	defer closePersistent(t, s)
+	if !s.Equal(want) {
+		t.Errorf("expected %v, got %v", want, s)
+	}
+}
+
+func TestPersistentSetClear(t *testing.T) {
+	dir := t.TempDir()
+	s := openPersistent(t, dir, PersistentSetOptions[int]{})
+	s.Append(1, 2, 3)
+	s.Clear()
+	s.Add(4)
+	closePersistent(t, s)
+
+	s = openPersistent(t, dir, PersistentSetOptions[int]{})
+	defer closePersistent(t, s)
+	if !s.Equal(NewSet(4)) {
+		t.Errorf("expected {4}, got %v", s)
+	}
+}
+
+func TestPersistentSetCompacts(t *testing.T) {
+	dir := t.TempDir()
+	opts := PersistentSetOptions[int]{Sync: SyncNever, CompactAfter: 10}
+	s := openPersistent(t, dir, opts)
+	for i := 0; i < 100; i++ {
+		s.Add(i % 5)
+		s.Remove(i % 5)
+	}
+	s.Append(1, 2)
+	closePersistent(t, s)
+
+	info, err := os.Stat(filepath.Join(dir, persistentLogFile))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Size() > 200 {
+		t.Errorf("expected log to be compacted, got %d bytes", info.Size())
+	}
+
+	s = openPersistent(t, dir, opts)
+	if !s.Equal(NewSet(1, 2)) {
+		t.Errorf("expected {1, 2}, got %v", s)
+	}
+	if err := s.Compact(); err != nil {
+		t.Fatal(err)
+	}
+	closePersistent(t, s)
+
+	s = openPersistent(t, dir, opts)
+	defer closePersistent(t, s)
+	if !s.Equal(NewSet(1, 2)) {
+		t.Errorf("expected {1, 2} after explicit compaction, got %v", s)
+	}
+}
+
+func TestPersistentSetReplaysLogOverSnapshot(t *testing.T) {
+	dir := t.TempDir()
+	s := openPersistent(t, dir, PersistentSetOptions[int]{CompactAfter: -1})
+	s.Append(1, 2, 3)
+	s.Remove(2)
+	log, err := os.ReadFile(filepath.Join(dir, persistentLogFile))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Compact(); err != nil {
+		t.Fatal(err)
+	}
+	closePersistent(t, s)
+
+	// Simulate a crash after writing the snapshot but before emptying
+	// the log.
+	if err := os.WriteFile(filepath.Join(dir, persistentLogFile), log, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	s = openPersistent(t, dir, PersistentSetOptions[int]{})
+	defer closePersistent(t, s)
+	if !s.Equal(NewSet(1, 3)) {
+		t.Errorf("expected {1, 3}, got %v", s)
+	}
+}
+
+func TestPersistentSetTornWrite(t *testing.T) {
+	dir := t.TempDir()
+	s := openPersistent(t, dir, PersistentSetOptions[string]{})
+	s.Add("a")
+	s.Append("b", "c")
+	closePersistent(t, s)
+
+	name := filepath.Join(dir, persistentLogFile)
+	log, err := os.ReadFile(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(name, log[:len(log)-2], 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	s = openPersistent(t, dir, PersistentSetOptions[string]{})
+	if !s.Equal(NewSet("a")) {
+		t.Errorf("expected torn change to be discarded, got %v", s)
+	}
+	s.Add("d")
+	closePersistent(t, s)
+
+	s = openPersistent(t, dir, PersistentSetOptions[string]{})
+	defer closePersistent(t, s)
+	if !s.Equal(NewSet("a", "d")) {
+		t.Errorf("expected changes after a torn write to be kept, got %v", s)
+	}
+}
+
+func TestPersistentSetCorruptLog(t *testing.T) {
+	dir := t.TempDir()
+	s := openPersistent(t, dir, PersistentSetOptions[int]{})
+	s.Add(1)
+	s.Add(2)
+	s.Add(3)
+	closePersistent(t, s)
+
+	name := filepath.Join(dir, persistentLogFile)
+	log, err := os.ReadFile(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	entry := len(log) / 3
+
+	for _, tc := range []struct {
+		name   string
+		offset int
+		damage func(b []byte)
+	}{
+		{"body", entry, func(b []byte) { b[entry+entry/2+1] ^= 0xff }},
+		{"first length", 0, func(b []byte) { b[4] = 0x7f }},
+		{"middle length", entry, func(b []byte) { b[entry+4] = 0x7f }},
+	} {
+		damaged := append([]byte(nil), log...)
+		tc.damage(damaged)
+		if err := os.WriteFile(name, damaged, 0o644); err != nil {
+			t.Fatal(err)
+		}
+
+		_, err = OpenPersistentSet(dir, PersistentSetOptions[int]{})
+		if !errors.Is(err, ErrCorruptLog) {
+			t.Errorf("%s: expected ErrCorruptLog, got %v", tc.name, err)
+			continue
+		}
+		if !strings.Contains(err.Error(), fmt.Sprintf("offset %d", tc.offset)) {
+			t.Errorf("%s: expected error to name offset %d, got %v", tc.name, tc.offset, err)
+		}
+		if got, err := os.ReadFile(name); err != nil || !bytes.Equal(got, damaged) {
+			t.Errorf("%s: expected damaged log to be left untouched, got %d bytes, %v", tc.name, len(got), err)
+		}
+	}
+
+	// A damaged last entry is indistinguishable from a torn write.
+	damaged := append([]byte(nil), log...)
+	damaged[len(damaged)-1] ^= 0xff
+	if err := os.WriteFile(name, damaged, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	s = openPersistent(t, dir, PersistentSetOptions[int]{})
+	if !s.Equal(NewSet(1, 2)) {
+		t.Errorf("expected damaged last entry to be discarded, got %v", s)
+	}
+	closePersistent(t, s)
+
+	// So are zero bytes left at the end of the log by a crash.
+	log, err = os.ReadFile(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(name, append(log, make([]byte, 64)...), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	s = openPersistent(t, dir, PersistentSetOptions[int]{})
+	defer closePersistent(t, s)
+	if !s.Equal(NewSet(1, 2)) {
+		t.Errorf("expected zeroed tail to be discarded, got %v", s)
+	}
+	info, err := os.Stat(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Size() != int64(len(log)) {
+		t.Errorf("expected zeroed tail to be truncated to %d bytes, got %d", len(log), info.Size())
+	}
+}
+
+func TestPersistentSetCorruptSnapshot(t *testing.T) {
+	dir := t.TempDir()
+	s := openPersistent(t, dir, PersistentSetOptions[int]{})
+	s.Append(1, 2)
+	if err := s.Compact(); err != nil {
+		t.Fatal(err)
+	}
+	closePersistent(t, s)
+
+	name := filepath.Join(dir, persistentSnapshotFile)
+	data, err := os.ReadFile(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data[len(data)/2] ^= 0xff
+	if err := os.WriteFile(name, data, 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := OpenPersistentSet(dir, PersistentSetOptions[int]{}); !errors.Is(err, ErrCorruptSnapshot) {
+		t.Errorf("expected ErrCorruptSnapshot, got %v", err)
+	}
+}
+
+type point struct {
+	X, Y int
+}
+
+func TestPersistentSetStructElements(t *testing.T) {
+	dir := t.TempDir()
+	s := openPersistent(t, dir, PersistentSetOptions[point]{Sync: SyncPeriodically, SyncInterval: time.Millisecond})
+	s.Append(point{1, 2}, point{3, 4})
+	time.Sleep(5 * time.Millisecond)
+	closePersistent(t, s)
+
+	s = openPersistent(t, dir, PersistentSetOptions[point]{})
+	defer closePersistent(t, s)
+	if !s.Equal(NewSet(point{1, 2}, point{3, 4})) {
+		t.Errorf("unexpected elements %v", s)
+	}
+}
+
+var errEncode = errors.New("cannot encode")
+
+type failingCodec struct {
+	jsonCodec[string]
+}
+
+func (failingCodec) Encode(v string) ([]byte, error) {
+	if v == "bad" {
+		return nil, errEncode
+	}
+	return jsonCodec[string]{}.Encode(v)
+}
+
+func TestPersistentSetStickyError(t *testing.T) {
+	dir := t.TempDir()
+	s := openPersistent(t, dir, PersistentSetOptions[string]{Codec: failingCodec{}})
+	s.Add("a")
+	s.Add("bad")
+	s.Add("b")
+
+	if !s.Contains("a", "bad", "b") {
+		t.Errorf("expected changes to be made in memory, got %v", s)
+	}
+	if !errors.Is(s.Err(), errEncode) {
+		t.Errorf("expected encoding error, got %v", s.Err())
+	}
+	if err := s.Close(); !errors.Is(err, errEncode) {
+		t.Errorf("expected Close to return encoding error, got %v", err)
+	}
+
+	s = openPersistent(t, dir, PersistentSetOptions[string]{})
+	defer closePersistent(t, s)
+	if !s.Equal(NewSet("a")) {
+		t.Errorf("expected only changes before the error, got %v", s)
+	}
+}
+
+func TestSyncPolicyString(t *testing.T) {
+	for p, want := range map[SyncPolicy]string{
+		SyncAlways:       "SyncAlways",
+		SyncPeriodically: "SyncPeriodically",
+		SyncNever:        "SyncNever",
+		SyncPolicy(7):    "SyncPolicy(7)",
+	} {
+		if got := p.String(); got != want {
+			t.Errorf("expected %q, got %q", want, got)
+		}
+	}
+}
@@ -0,0 +1,583 @@
+//go:build go1.21
+// +build go1.21
+
+/*
+Open Source Initiative OSI - The MIT License (MIT):Licensing
+
+The MIT License (MIT)
+Copyright (c) 2013 - 2026 Ralph Caraveo (deckarep@gmail.com)
+
+Permission is hereby granted, free of charge, to any person obtaining a copy of
+this software and associated documentation files (the "Software"), to deal in
+the Software without restriction, including without limitation the rights to
+use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies
+of the Software, and to permit persons to whom the Software is furnished to do
+so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+
+package mapset
+
+import (
+	"cmp"
+	"fmt"
+	"math/rand"
+	"strings"
+	"sync"
+)
+
+// ScoredSet is a set in which every member carries a score, modeled after
+// the Redis sorted set (ZSET). Members are unique; scores are not. Members
+// are kept ordered by ascending score and members with equal scores are
+// ordered by the time they were given that score.
+//
+// Updates, rank lookups and range queries run in O(log n).
+type ScoredSet[T comparable, S cmp.Ordered] interface {
+	// Add adds member with the given score, or updates the score
+	// of an existing member. Returns whether the member was added.
+	Add(member T, score S) bool
+
+	// IncrBy adds delta to the score of member and returns the
+	// new score. A missing member is added with a score of delta.
+	IncrBy(member T, delta S) S
+
+	// Remove removes member from the set. Returns whether
+	// the member was present.
+	Remove(member T) bool
+
+	// Score returns the score of member and whether it is
+	// in the set.
+	Score(member T) (S, bool)
+
+	// Rank returns the zero-based position of member in ascending
+	// score order and whether it is in the set.
+	Rank(member T) (int, bool)
+
+	// ContainsOne returns whether member is in the set.
+	ContainsOne(member T) bool
+
+	// Cardinality returns the number of members in the set.
+	Cardinality() int
+
+	// Clear removes all members from the set.
+	Clear()
+
+	// RangeByScore returns the members whose scores lie within
+	// [min, max], in ascending score order.
+	RangeByScore(min, max S) []ScoredMember[T, S]
+
+	// RangeByRank returns the members ranked start through stop
+	// inclusive, in ascending score order. As with Redis ZRANGE,
+	// negative indexes count from the end of the set, -1 being
+	// the member with the highest score.
+	RangeByRank(start, stop int) []ScoredMember[T, S]
+
+	// RemoveRangeByScore removes the members whose scores lie
+	// within [min, max]. Returns the number of members removed.
+	RemoveRangeByScore(min, max S) int
+
+	// PopMin removes and returns the member with the lowest score.
+	PopMin() (ScoredMember[T, S], bool)
+
+	// PopMax removes and returns the member with the highest score.
+	PopMax() (ScoredMember[T, S], bool)
+
+	// Each iterates over members in ascending score order and executes
+	// the passed func against each one. If passed func returns true,
+	// stop iteration at the time.
+	Each(func(member T, score S) bool)
+
+	// ToSet returns the members, without their scores, as a Set.
+	// The returned set is thread-safe if and only if this set is.
+	ToSet() Set[T]
+
+	// String provides a convenient string representation
+	// of the current state of the set.
+	String() string
+}
+
+// ScoredMember is a member of a ScoredSet together with its score.
+type ScoredMember[T comparable, S cmp.Ordered] struct {
+	Member T
+	Score  S
+}
+
+// NewScoredSet creates and returns a new, empty scored set.
+// Operations on the resulting set are thread-safe.
+func NewScoredSet[T comparable, S cmp.Ordered]() ScoredSet[T, S] {
+	return &threadSafeScoredSet[T, S]{
+		uss: newThreadUnsafeScoredSet[T, S](),
+	}
+}
+
+// NewThreadUnsafeScoredSet creates and returns a new, empty scored set.
+// Operations on the resulting set are not thread-safe.
+func NewThreadUnsafeScoredSet[T comparable, S cmp.Ordered]() ScoredSet[T, S] {
+	return newThreadUnsafeScoredSet[T, S]()
+}
+
+const (
+	scoredSetMaxLevel = 32
+	scoredSetP        = 0.25
+)
+
+// scoredNode is a node of the skip list backing a scored set. The seq field
+// breaks ties between equal scores so that every node has a unique position.
+type scoredNode[T comparable, S cmp.Ordered] struct {
+	member   T
+	score    S
+	seq      uint64
+	backward *scoredNode[T, S]
+	level    []scoredLevel[T, S]
+}
+
+type scoredLevel[T comparable, S cmp.Ordered] struct {
+	forward *scoredNode[T, S]
+	span    int
+}
+
+// before reports whether n is ordered before the position (score, seq).
+func (n *scoredNode[T, S]) before(score S, seq uint64) bool {
+	c := cmp.Compare(n.score, score)
+	return c < 0 || (c == 0 && n.seq < seq)
+}
+
+type threadUnsafeScoredSet[T comparable, S cmp.Ordered] struct {
+	dict   map[T]*scoredNode[T, S]
+	head   *scoredNode[T, S]
+	tail   *scoredNode[T, S]
+	level  int
+	length int
+	seq    uint64
+}
+
+// Assert concrete type:threadUnsafeScoredSet adheres to ScoredSet interface.
+var _ ScoredSet[string, int] = (*threadUnsafeScoredSet[string, int])(nil)
+
+func newThreadUnsafeScoredSet[T comparable, S cmp.Ordered]() *threadUnsafeScoredSet[T, S] {
+	return &threadUnsafeScoredSet[T, S]{
+		dict:  make(map[T]*scoredNode[T, S]),
+		head:  &scoredNode[T, S]{level: make([]scoredLevel[T, S], scoredSetMaxLevel)},
+		level: 1,
+	}
+}
+
+func randomScoredLevel() int {
+	level := 1
+	for level < scoredSetMaxLevel && rand.Float64() < scoredSetP {
+		level++
+	}
+	return level
+}
+
+// insert links a new node for member into the skip list.
+func (s *threadUnsafeScoredSet[T, S]) insert(member T, score S) *scoredNode[T, S] {
+	var update [scoredSetMaxLevel]*scoredNode[T, S]
+	var rank [scoredSetMaxLevel]int
+
+	s.seq++
+	seq := s.seq
+
+	x := s.head
+	for i := s.level - 1; i >= 0; i-- {
+		if i != s.level-1 {
+			rank[i] = rank[i+1]
+		}
+		for x.level[i].forward != nil && x.level[i].forward.before(score, seq) {
+			rank[i] += x.level[i].span
+			x = x.level[i].forward
+		}
+		update[i] = x
+	}
+
+	level := randomScoredLevel()
+	if level > s.level {
+		for i := s.level; i < level; i++ {
+			rank[i] = 0
+			update[i] = s.head
+			update[i].level[i].span = s.length
+		}
+		s.level = level
+	}
+
+	x = &scoredNode[T, S]{
+		member: member,
+		score:  score,
+		seq:    seq,
+		level:  make([]scoredLevel[T, S], level),
+	}
+	for i := 0; i < level; i++ {
+		x.level[i].forward = update[i].level[i].forward
+		update[i].level[i].forward = x
+		x.level[i].span = update[i].level[i].span - (rank[0] - rank[i])
+		update[i].level[i].span = rank[0] - rank[i] + 1
+	}
+	for i := level; i < s.level; i++ {
+		update[i].level[i].span++
+	}
+
+	if update[0] != s.head {
+		x.backward = update[0]
+	}
+	if x.level[0].forward != nil {
+		x.level[0].forward.backward = x
+	} else {
+		s.tail = x
+	}
+	s.length++
+	return x
+}
+
+// unlink removes x from the skip list given its predecessors at every level.
+func (s *threadUnsafeScoredSet[T, S]) unlink(x *scoredNode[T, S], update []*scoredNode[T, S]) {
+	for i := 0; i < s.level; i++ {
+		if update[i].level[i].forward == x {
+			update[i].level[i].span += x.level[i].span - 1
+			update[i].level[i].forward = x.level[i].forward
+		} else {
+			update[i].level[i].span--
+		}
+	}
+	if x.level[0].forward != nil {
+		x.level[0].forward.backward = x.backward
+	} else {
+		s.tail = x.backward
+	}
+	for s.level > 1 && s.head.level[s.level-1].forward == nil {
+		s.level--
+	}
+	s.length--
+}
+
+// delete removes node n from both the skip list and the member index.
+func (s *threadUnsafeScoredSet[T, S]) delete(n *scoredNode[T, S]) {
+	var update [scoredSetMaxLevel]*scoredNode[T, S]
+
+	x := s.head
+	for i := s.level - 1; i >= 0; i-- {
+		for x.level[i].forward != nil && x.level[i].forward.before(n.score, n.seq) {
+			x = x.level[i].forward
+		}
+		update[i] = x
+	}
+	s.unlink(n, update[:])
+	delete(s.dict, n.member)
+}
+
+// nodeByRank returns the node at the given one-based rank.
+func (s *threadUnsafeScoredSet[T, S]) nodeByRank(rank int) *scoredNode[T, S] {
+	var traversed int
+
+	x := s.head
+	for i := s.level - 1; i >= 0; i-- {
+		for x.level[i].forward != nil && traversed+x.level[i].span <= rank {
+			traversed += x.level[i].span
+			x = x.level[i].forward
+		}
+		if traversed == rank {
+			return x
+		}
+	}
+	return nil
+}
+
+// firstAtLeast returns the first node whose score is not less than min.
+func (s *threadUnsafeScoredSet[T, S]) firstAtLeast(min S) *scoredNode[T, S] {
+	x := s.head
+	for i := s.level - 1; i >= 0; i-- {
+		for x.level[i].forward != nil && cmp.Less(x.level[i].forward.score, min) {
+			x = x.level[i].forward
+		}
+	}
+	return x.level[0].forward
+}
+
+func (s *threadUnsafeScoredSet[T, S]) Add(member T, score S) bool {
+	if n, ok := s.dict[member]; ok {
+		if cmp.Compare(n.score, score) != 0 {
+			s.delete(n)
+			s.dict[member] = s.insert(member, score)
+		}
+		return false
+	}
+	s.dict[member] = s.insert(member, score)
+	return true
+}
+
+func (s *threadUnsafeScoredSet[T, S]) IncrBy(member T, delta S) S {
+	score := delta
+	if n, ok := s.dict[member]; ok {
+		score = n.score + delta
+	}
+	s.Add(member, score)
+	return score
+}
+
+func (s *threadUnsafeScoredSet[T, S]) Remove(member T) bool {
+	n, ok := s.dict[member]
+	if ok {
+		s.delete(n)
+	}
+	return ok
+}
+
+func (s *threadUnsafeScoredSet[T, S]) Score(member T) (score S, ok bool) {
+	n, ok := s.dict[member]
+	if !ok {
+		return score, false
+	}
+	return n.score, true
+}
+
+func (s *threadUnsafeScoredSet[T, S]) Rank(member T) (int, bool) {
+	n, ok := s.dict[member]
+	if !ok {
+		return 0, false
+	}
+
+	var rank int
+	x := s.head
+	for i := s.level - 1; i >= 0; i-- {
+		for x.level[i].forward != nil && !n.before(x.level[i].forward.score, x.level[i].forward.seq) {
+			rank += x.level[i].span
+			x = x.level[i].forward
+		}
+		if x == n {
+			break
+		}
+	}
+	return rank - 1, true
+}
+
+func (s *threadUnsafeScoredSet[T, S]) ContainsOne(member T) bool {
+	_, ok := s.dict[member]
+	return ok
+}
+
+func (s *threadUnsafeScoredSet[T, S]) Cardinality() int {
+	return s.length
+}
+
+func (s *threadUnsafeScoredSet[T, S]) Clear() {
+	*s = *newThreadUnsafeScoredSet[T, S]()
+}
+
+func (s *threadUnsafeScoredSet[T, S]) RangeByScore(min, max S) []ScoredMember[T, S] {
+	var items []ScoredMember[T, S]
+	for x := s.firstAtLeast(min); x != nil && !cmp.Less(max, x.score); x = x.level[0].forward {
+		items = append(items, ScoredMember[T, S]{x.member, x.score})
+	}
+	return items
+}
+
+func (s *threadUnsafeScoredSet[T, S]) RangeByRank(start, stop int) []ScoredMember[T, S] {
+	if start < 0 {
+		start += s.length
+	}
+	if stop < 0 {
+		stop += s.length
+	}
+	if start < 0 {
+		start = 0
+	}
+	if stop >= s.length {
+		stop = s.length - 1
+	}
+	if start > stop {
+		return []ScoredMember[T, S]{}
+	}
+
+	items := make([]ScoredMember[T, S], 0, stop-start+1)
+	for x := s.nodeByRank(start + 1); x != nil && len(items) < cap(items); x = x.level[0].forward {
+		items = append(items, ScoredMember[T, S]{x.member, x.score})
+	}
+	return items
+}
+
+func (s *threadUnsafeScoredSet[T, S]) RemoveRangeByScore(min, max S) int {
+	var update [scoredSetMaxLevel]*scoredNode[T, S]
+
+	x := s.head
+	for i := s.level - 1; i >= 0; i-- {
+		for x.level[i].forward != nil && cmp.Less(x.level[i].forward.score, min) {
+			x = x.level[i].forward
+		}
+		update[i] = x
+	}
+
+	var removed int
+	x = x.level[0].forward
+	for x != nil && !cmp.Less(max, x.score) {
+		next := x.level[0].forward
+		s.unlink(x, update[:])
+		delete(s.dict, x.member)
+		removed++
+		x = next
+	}
+	return removed
+}
+
+func (s *threadUnsafeScoredSet[T, S]) PopMin() (m ScoredMember[T, S], ok bool) {
+	x := s.head.level[0].forward
+	if x == nil {
+		return m, false
+	}
+	s.delete(x)
+	return ScoredMember[T, S]{x.member, x.score}, true
+}
+
+func (s *threadUnsafeScoredSet[T, S]) PopMax() (m ScoredMember[T, S], ok bool) {
+	x := s.tail
+	if x == nil {
+		return m, false
+	}
+	s.delete(x)
+	return ScoredMember[T, S]{x.member, x.score}, true
+}
+
+func (s *threadUnsafeScoredSet[T, S]) Each(cb func(T, S) bool) {
+	for x := s.head.level[0].forward; x != nil; x = x.level[0].forward {
+		if cb(x.member, x.score) {
+			break
+		}
+	}
+}
+
+func (s *threadUnsafeScoredSet[T, S]) ToSet() Set[T] {
+	members := newThreadUnsafeSetWithSize[T](s.length)
+	for member := range s.dict {
+		members.add(member)
+	}
+	return members
+}
+
+func (s *threadUnsafeScoredSet[T, S]) String() string {
+	items := make([]string, 0, s.length)
+	for x := s.head.level[0].forward; x != nil; x = x.level[0].forward {
+		items = append(items, fmt.Sprintf("%v:%v", x.member, x.score))
+	}
+	return fmt.Sprintf("ScoredSet{%s}", strings.Join(items, ", "))
+}
+
+type threadSafeScoredSet[T comparable, S cmp.Ordered] struct {
+	sync.RWMutex
+	uss *threadUnsafeScoredSet[T, S]
+}
+
+// Assert concrete type:threadSafeScoredSet adheres to ScoredSet interface.
+var _ ScoredSet[string, int] = (*threadSafeScoredSet[string, int])(nil)
+
+func (t *threadSafeScoredSet[T, S]) Add(member T, score S) bool {
+	t.Lock()
+	ret := t.uss.Add(member, score)
+	t.Unlock()
+	return ret
+}
+
+func (t *threadSafeScoredSet[T, S]) IncrBy(member T, delta S) S {
+	t.Lock()
+	ret := t.uss.IncrBy(member, delta)
+	t.Unlock()
+	return ret
+}
+
+func (t *threadSafeScoredSet[T, S]) Remove(member T) bool {
+	t.Lock()
+	ret := t.uss.Remove(member)
+	t.Unlock()
+	return ret
+}
+
+func (t *threadSafeScoredSet[T, S]) Score(member T) (S, bool) {
+	t.RLock()
+	defer t.RUnlock()
+	return t.uss.Score(member)
+}
+
+func (t *threadSafeScoredSet[T, S]) Rank(member T) (int, bool) {
+	t.RLock()
+	defer t.RUnlock()
+	return t.uss.Rank(member)
+}
+
+func (t *threadSafeScoredSet[T, S]) ContainsOne(member T) bool {
+	t.RLock()
+	ret := t.uss.ContainsOne(member)
+	t.RUnlock()
+	return ret
+}
+
+func (t *threadSafeScoredSet[T, S]) Cardinality() int {
+	t.RLock()
+	defer t.RUnlock()
+	return t.uss.Cardinality()
+}
+
+func (t *threadSafeScoredSet[T, S]) Clear() {
+	t.Lock()
+	t.uss.Clear()
+	t.Unlock()
+}
+
+func (t *threadSafeScoredSet[T, S]) RangeByScore(min, max S) []ScoredMember[T, S] {
+	t.RLock()
+	ret := t.uss.RangeByScore(min, max)
+	t.RUnlock()
+	return ret
+}
+
+func (t *threadSafeScoredSet[T, S]) RangeByRank(start, stop int) []ScoredMember[T, S] {
+	t.RLock()
+	ret := t.uss.RangeByRank(start, stop)
+	t.RUnlock()
+	return ret
+}
+
+func (t *threadSafeScoredSet[T, S]) RemoveRangeByScore(min, max S) int {
+	t.Lock()
+	ret := t.uss.RemoveRangeByScore(min, max)
+	t.Unlock()
+	return ret
+}
+
+func (t *threadSafeScoredSet[T, S]) PopMin() (ScoredMember[T, S], bool) {
+	t.Lock()
+	defer t.Unlock()
+	return t.uss.PopMin()
+}
+
+func (t *threadSafeScoredSet[T, S]) PopMax() (ScoredMember[T, S], bool) {
+	t.Lock()
+	defer t.Unlock()
+	return t.uss.PopMax()
+}
+
+func (t *threadSafeScoredSet[T, S]) Each(cb func(T, S) bool) {
+	t.RLock()
+	defer t.RUnlock()
+	t.uss.Each(cb)
+}
+
+func (t *threadSafeScoredSet[T, S]) ToSet() Set[T] {
+	t.RLock()
+	members := t.uss.ToSet().(*threadUnsafeSet[T])
+	t.RUnlock()
+	return &threadSafeSet[T]{uss: members}
+}
+
+func (t *threadSafeScoredSet[T, S]) String() string {
+	t.RLock()
+	ret := t.uss.String()
+	t.RUnlock()
+	return ret
+}
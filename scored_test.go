@@ -0,0 +1,251 @@
+//go:build go1.21
+// +build go1.21
+
+/*
+Open Source Initiative OSI - The MIT License (MIT):Licensing
+
+The MIT License (MIT)
+Copyright (c) 2013 - 2026 Ralph Caraveo (deckarep@gmail.com)
+
+Permission is hereby granted, free of charge, to any person obtaining a copy of
+this software and associated documentation files (the "Software"), to deal in
+the Software without restriction, including without limitation the rights to
+use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies
+of the Software, and to permit persons to whom the Software is furnished to do
+so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+
+package mapset
+
+import (
+	"math/rand"
+	"sort"
+	"testing"
+)
+
+func testScoredSet(t *testing.T, test func(t *testing.T, ctor func() ScoredSet[string, int])) {
+	t.Run("Safe", func(t *testing.T) {
+		test(t, NewScoredSet[string, int])
+	})
+	t.Run("Unsafe", func(t *testing.T) {
+		test(t, NewThreadUnsafeScoredSet[string, int])
+	})
+}
+
+func Test_ScoredSetAdd(t *testing.T) {
+	testScoredSet(t, func(t *testing.T, ctor func() ScoredSet[string, int]) {
+		s := ctor()
+
+		if !s.Add("a", 3) {
+			t.Error("Add of a new member should return true")
+		}
+		if s.Add("a", 1) {
+			t.Error("Add of an existing member should return false")
+		}
+		if score, ok := s.Score("a"); !ok || score != 1 {
+			t.Errorf("Expected score 1 for a, got %d (%v)", score, ok)
+		}
+		if _, ok := s.Score("b"); ok {
+			t.Error("Score should report missing members")
+		}
+		if s.Cardinality() != 1 {
+			t.Errorf("Expected cardinality 1, got %d", s.Cardinality())
+		}
+		if !s.ContainsOne("a") || s.ContainsOne("b") {
+			t.Error("ContainsOne reported the wrong membership")
+		}
+	})
+}
+
+func Test_ScoredSetIncrBy(t *testing.T) {
+	testScoredSet(t, func(t *testing.T, ctor func() ScoredSet[string, int]) {
+		s := ctor()
+		s.Add("a", 1)
+		s.Add("b", 2)
+
+		if score := s.IncrBy("a", 5); score != 6 {
+			t.Errorf("Expected IncrBy to return 6, got %d", score)
+		}
+		if score := s.IncrBy("c", 4); score != 4 {
+			t.Errorf("Expected IncrBy on a missing member to return 4, got %d", score)
+		}
+		if rank, _ := s.Rank("a"); rank != 2 {
+			t.Errorf("Expected a to be ranked last after IncrBy, got %d", rank)
+		}
+	})
+}
+
+func Test_ScoredSetRangeByScore(t *testing.T) {
+	testScoredSet(t, func(t *testing.T, ctor func() ScoredSet[string, int]) {
+		s := ctor()
+		s.Add("d", 40)
+		s.Add("a", 10)
+		s.Add("c", 30)
+		s.Add("b", 20)
+		s.Add("b2", 20)
+
+		got := s.RangeByScore(15, 30)
+		want := []ScoredMember[string, int]{{"b", 20}, {"b2", 20}, {"c", 30}}
+		if len(got) != len(want) {
+			t.Fatalf("Expected %v, got %v", want, got)
+		}
+		for i := range want {
+			if got[i] != want[i] {
+				t.Errorf("Expected %v at %d, got %v", want[i], i, got[i])
+			}
+		}
+
+		if got := s.RangeByScore(50, 60); len(got) != 0 {
+			t.Errorf("Expected no members above the highest score, got %v", got)
+		}
+	})
+}
+
+func Test_ScoredSetRangeByRank(t *testing.T) {
+	testScoredSet(t, func(t *testing.T, ctor func() ScoredSet[string, int]) {
+		s := ctor()
+		s.Add("a", 1)
+		s.Add("b", 2)
+		s.Add("c", 3)
+		s.Add("d", 4)
+
+		cases := []struct {
+			start, stop int
+			want        []string
+		}{
+			{0, 1, []string{"a", "b"}},
+			{2, 10, []string{"c", "d"}},
+			{-2, -1, []string{"c", "d"}},
+			{0, -1, []string{"a", "b", "c", "d"}},
+			{3, 1, []string{}},
+			{5, 6, []string{}},
+		}
+		for _, c := range cases {
+			got := s.RangeByRank(c.start, c.stop)
+			if len(got) != len(c.want) {
+				t.Errorf("RangeByRank(%d, %d): expected %v, got %v", c.start, c.stop, c.want, got)
+				continue
+			}
+			for i := range c.want {
+				if got[i].Member != c.want[i] {
+					t.Errorf("RangeByRank(%d, %d): expected %v, got %v", c.start, c.stop, c.want, got)
+				}
+			}
+		}
+	})
+}
+
+func Test_ScoredSetRemoveRangeByScore(t *testing.T) {
+	testScoredSet(t, func(t *testing.T, ctor func() ScoredSet[string, int]) {
+		s := ctor()
+		for i, m := range []string{"a", "b", "c", "d", "e"} {
+			s.Add(m, i)
+		}
+
+		if n := s.RemoveRangeByScore(1, 3); n != 3 {
+			t.Errorf("Expected 3 members removed, got %d", n)
+		}
+		if s.Cardinality() != 2 || !s.ContainsOne("a") || !s.ContainsOne("e") {
+			t.Errorf("Unexpected members after RemoveRangeByScore: %v", s)
+		}
+		if rank, _ := s.Rank("e"); rank != 1 {
+			t.Errorf("Expected e at rank 1, got %d", rank)
+		}
+	})
+}
+
+func Test_ScoredSetPop(t *testing.T) {
+	testScoredSet(t, func(t *testing.T, ctor func() ScoredSet[string, int]) {
+		s := ctor()
+		if _, ok := s.PopMin(); ok {
+			t.Error("PopMin on an empty set should return false")
+		}
+		if _, ok := s.PopMax(); ok {
+			t.Error("PopMax on an empty set should return false")
+		}
+
+		s.Add("b", 2)
+		s.Add("a", 1)
+		s.Add("c", 3)
+
+		if m, ok := s.PopMin(); !ok || m.Member != "a" || m.Score != 1 {
+			t.Errorf("Expected PopMin to return a:1, got %v", m)
+		}
+		if m, ok := s.PopMax(); !ok || m.Member != "c" || m.Score != 3 {
+			t.Errorf("Expected PopMax to return c:3, got %v", m)
+		}
+		if s.Cardinality() != 1 || s.ContainsOne("a") || s.ContainsOne("c") {
+			t.Errorf("Popped members should have been removed: %v", s)
+		}
+	})
+}
+
+func Test_ScoredSetToSet(t *testing.T) {
+	testScoredSet(t, func(t *testing.T, ctor func() ScoredSet[string, int]) {
+		s := ctor()
+		s.Add("a", 1)
+		s.Add("b", 2)
+
+		members := s.ToSet()
+		if !members.Contains("a", "b") || members.Cardinality() != 2 {
+			t.Errorf("Unexpected members: %v", members)
+		}
+	})
+
+	if _, ok := NewScoredSet[string, int]().ToSet().(*threadSafeSet[string]); !ok {
+		t.Error("A thread-safe scored set should convert to a thread-safe set")
+	}
+	if _, ok := NewThreadUnsafeScoredSet[string, int]().ToSet().(*threadUnsafeSet[string]); !ok {
+		t.Error("A thread-unsafe scored set should convert to a thread-unsafe set")
+	}
+}
+
+func Test_ScoredSetRandomized(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	s := NewThreadUnsafeScoredSet[int, int]()
+	scores := make(map[int]int)
+
+	for i := 0; i < 5000; i++ {
+		m := r.Intn(500)
+		switch r.Intn(3) {
+		case 0, 1:
+			score := r.Intn(100)
+			s.Add(m, score)
+			scores[m] = score
+		case 2:
+			s.Remove(m)
+			delete(scores, m)
+		}
+	}
+
+	if s.Cardinality() != len(scores) {
+		t.Fatalf("Expected cardinality %d, got %d", len(scores), s.Cardinality())
+	}
+
+	all := s.RangeByRank(0, -1)
+	if !sort.SliceIsSorted(all, func(i, j int) bool { return all[i].Score < all[j].Score }) {
+		t.Error("Members should be ordered by ascending score")
+	}
+	for i, m := range all {
+		if scores[m.Member] != m.Score {
+			t.Errorf("Expected score %d for %d, got %d", scores[m.Member], m.Member, m.Score)
+		}
+		if rank, _ := s.Rank(m.Member); rank != i {
+			t.Errorf("Expected rank %d for %d, got %d", i, m.Member, rank)
+		}
+		if got := s.RangeByRank(i, i); len(got) != 1 || got[0] != m {
+			t.Errorf("Expected %v at rank %d, got %v", m, i, got)
+		}
+	}
+}
@@ -0,0 +1,143 @@
+/*
+Open Source Initiative OSI - The MIT License (MIT):Licensing
+
+The MIT License (MIT)
+Copyright (c) 2013 - 2026 Ralph Caraveo (deckarep@gmail.com)
+
+Permission is hereby granted, free of charge, to any person obtaining a copy of
+this software and associated documentation files (the "Software"), to deal in
+the Software without restriction, including without limitation the rights to
+use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies
+of the Software, and to permit persons to whom the Software is furnished to do
+so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+
+package mapset
+
+import "math"
+
+// intersectionCardinality returns the cardinalities of a, b and their
+// intersection without building the intersection. Like ContainsAnyElement
+// it loops over the smaller set, but it accepts any two implementations.
+func intersectionCardinality[T comparable](a, b Set[T]) (na, nb, n int) {
+	switch x := a.(type) {
+	case *threadUnsafeSet[T]:
+		if y, ok := b.(*threadUnsafeSet[T]); ok {
+			return x.intersectionCardinality(y)
+		}
+	case *threadSafeSet[T]:
+		if y, ok := b.(*threadSafeSet[T]); ok {
+			if x == y {
+				// Avoid taking the same read lock twice.
+				n = x.Cardinality()
+				return n, n, n
+			}
+			x.RLock()
+			y.RLock()
+			na, nb, n = x.uss.intersectionCardinality(y.uss)
+			x.RUnlock()
+			y.RUnlock()
+			return na, nb, n
+		}
+	}
+
+	// The smaller set is copied so that no two locks are held at once.
+	na, nb = a.Cardinality(), b.Cardinality()
+	small, large := a, b
+	if nb < na {
+		small, large = b, a
+	}
+	vs := small.ToSlice()
+	for _, v := range vs {
+		if large.ContainsOne(v) {
+			n++
+		}
+	}
+	if small == a {
+		na = len(vs)
+	} else {
+		nb = len(vs)
+	}
+	return na, nb, n
+}
+
+func (s *threadUnsafeSet[T]) intersectionCardinality(o *threadUnsafeSet[T]) (ns, no, n int) {
+	small, large := s, o
+	if len(*o) < len(*s) {
+		small, large = o, s
+	}
+	for elem := range *small {
+		if large.contains(elem) {
+			n++
+		}
+	}
+	return len(*s), len(*o), n
+}
+
+// similarity returns num/den, or, when den is zero because the sets are
+// empty, 1 if both sets are empty and 0 otherwise.
+func similarity(num, den float64, na, nb int) float64 {
+	if den == 0 {
+		if na == 0 && nb == 0 {
+			return 1
+		}
+		return 0
+	}
+	return num / den
+}
+
+// JaccardIndex returns the size of the intersection of a and b divided by
+// the size of their union. Two empty sets have an index of 1.
+//
+// Unlike the methods of Set, the similarity functions accept any two Set
+// implementations.
+func JaccardIndex[T comparable](a, b Set[T]) float64 {
+	na, nb, n := intersectionCardinality(a, b)
+	return similarity(float64(n), float64(na+nb-n), na, nb)
+}
+
+// DiceCoefficient returns the Sørensen–Dice coefficient of a and b: twice
+// the size of their intersection divided by the sum of their sizes. Two
+// empty sets have a coefficient of 1.
+func DiceCoefficient[T comparable](a, b Set[T]) float64 {
+	na, nb, n := intersectionCardinality(a, b)
+	return similarity(2*float64(n), float64(na+nb), na, nb)
+}
+
+// OverlapCoefficient returns the size of the intersection of a and b
+// divided by the size of the smaller set. It is 1 whenever one set is a
+// subset of the other, except that it is 0 if exactly one set is empty.
+func OverlapCoefficient[T comparable](a, b Set[T]) float64 {
+	na, nb, n := intersectionCardinality(a, b)
+	min := na
+	if nb < min {
+		min = nb
+	}
+	return similarity(float64(n), float64(min), na, nb)
+}
+
+// CosineSimilarity returns the cosine similarity of a and b viewed as
+// binary vectors: the size of their intersection divided by the geometric
+// mean of their sizes. Two empty sets have a similarity of 1.
+func CosineSimilarity[T comparable](a, b Set[T]) float64 {
+	na, nb, n := intersectionCardinality(a, b)
+	return similarity(float64(n), math.Sqrt(float64(na)*float64(nb)), na, nb)
+}
+
+// HammingDistance returns the number of elements in exactly one of a and
+// b, which is the cardinality of their symmetric difference.
+func HammingDistance[T comparable](a, b Set[T]) int {
+	na, nb, n := intersectionCardinality(a, b)
+	return na + nb - 2*n
+}
@@ -0,0 +1,80 @@
+/*
+Open Source Initiative OSI - The MIT License (MIT):Licensing
+
+The MIT License (MIT)
+Copyright (c) 2013 - 2026 Ralph Caraveo (deckarep@gmail.com)
+
+Permission is hereby granted, free of charge, to any person obtaining a copy of
+this software and associated documentation files (the "Software"), to deal in
+the Software without restriction, including without limitation the rights to
+use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies
+of the Software, and to permit persons to whom the Software is furnished to do
+so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+
+package mapset
+
+import (
+	"math"
+	"testing"
+)
+
+func Test_SimilarityMetrics(t *testing.T) {
+	ctors := map[string]func(vals ...int) Set[int]{
+		"Safe":   NewSet[int],
+		"Unsafe": NewThreadUnsafeSet[int],
+		"Bounded": func(vals ...int) Set[int] {
+			s := NewBoundedSet[int](10, BoundedSetOptions[int]{})
+			s.Append(vals...)
+			return s
+		},
+	}
+
+	for nameA, ctorA := range ctors {
+		for nameB, ctorB := range ctors {
+			a := ctorA(1, 2, 3, 4)
+			b := ctorB(3, 4, 5, 6, 7, 8)
+
+			assertFloat(t, nameA+"/"+nameB+" Jaccard", JaccardIndex(a, b), 2.0/8)
+			assertFloat(t, nameA+"/"+nameB+" Dice", DiceCoefficient(a, b), 4.0/10)
+			assertFloat(t, nameA+"/"+nameB+" Overlap", OverlapCoefficient(a, b), 2.0/4)
+			assertFloat(t, nameA+"/"+nameB+" Cosine", CosineSimilarity(a, b), 2/math.Sqrt(24))
+			if d := HammingDistance(a, b); d != 6 {
+				t.Errorf("%s/%s Hamming: expected 6, got %d", nameA, nameB, d)
+			}
+		}
+	}
+}
+
+func Test_SimilarityMetricsEdgeCases(t *testing.T) {
+	empty := NewSet[int]()
+	a := NewSet(1, 2)
+
+	assertFloat(t, "Jaccard of empty sets", JaccardIndex(empty, NewSet[int]()), 1)
+	assertFloat(t, "Jaccard with an empty set", JaccardIndex(empty, a), 0)
+	assertFloat(t, "Overlap with an empty set", OverlapCoefficient(a, empty), 0)
+	assertFloat(t, "Overlap with a subset", OverlapCoefficient(a, NewSet(1, 2, 3)), 1)
+	assertFloat(t, "Cosine with itself", CosineSimilarity(a, a), 1)
+	assertFloat(t, "Dice with itself", DiceCoefficient(a, a), 1)
+	if d := HammingDistance(a, a); d != 0 {
+		t.Errorf("Expected a Hamming distance of 0 to itself, got %d", d)
+	}
+}
+
+func assertFloat(t *testing.T, name string, got, want float64) {
+	t.Helper()
+	if math.Abs(got-want) > 1e-9 {
+		t.Errorf("%s: expected %v, got %v", name, want, got)
+	}
+}
@@ -0,0 +1,498 @@
+//go:build go1.21
+// +build go1.21
+
+/*
+Open Source Initiative OSI - The MIT License (MIT):Licensing
+
+The MIT License (MIT)
+Copyright (c) 2013 - 2026 Ralph Caraveo (deckarep@gmail.com)
+
+Permission is hereby granted, free of charge, to any person obtaining a copy of
+this software and associated documentation files (the "Software"), to deal in
+the Software without restriction, including without limitation the rights to
+use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies
+of the Software, and to permit persons to whom the Software is furnished to do
+so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+
+package mapset
+
+import (
+	"bufio"
+	"cmp"
+	"container/heap"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+)
+
+// ErrUnsortedStream is returned when an input stream that must be sorted
+// is not in ascending order.
+var ErrUnsortedStream = errors.New("mapset: stream is not sorted")
+
+// ElementReader is a stream of elements. Read returns io.EOF at the end of
+// the stream.
+type ElementReader[T comparable] interface {
+	Read() (T, error)
+}
+
+// ElementWriter consumes a stream of elements.
+type ElementWriter[T comparable] interface {
+	Write(val T) error
+}
+
+// maxStreamElement is the largest encoded element a StreamReader accepts,
+// which stops a damaged length from causing a huge allocation.
+const maxStreamElement = 1 << 30
+
+// StreamReader reads elements written by a StreamWriter from an
+// io.Reader.
+type StreamReader[T comparable] struct {
+	r     *bufio.Reader
+	codec Codec[T]
+	buf   []byte
+}
+
+// NewStreamReader returns a StreamReader that reads from r, decoding
+// elements with codec, or JSONCodec if codec is nil.
+func NewStreamReader[T comparable](r io.Reader, codec Codec[T]) *StreamReader[T] {
+	if codec == nil {
+		codec = JSONCodec[T]()
+	}
+	return &StreamReader[T]{r: bufio.NewReader(r), codec: codec}
+}
+
+// Read returns the next element, or io.EOF at the end of the stream.
+func (r *StreamReader[T]) Read() (T, error) {
+	var zero T
+	n, err := binary.ReadUvarint(r.r)
+	if err != nil {
+		return zero, err
+	}
+	if n > maxStreamElement {
+		return zero, fmt.Errorf("mapset: stream element of %d bytes is too large", n)
+	}
+	if uint64(cap(r.buf)) < n {
+		r.buf = make([]byte, n)
+	}
+	b := r.buf[:n]
+	if _, err := io.ReadFull(r.r, b); err != nil {
+		if err == io.EOF {
+			err = io.ErrUnexpectedEOF
+		}
+		return zero, err
+	}
+	return r.codec.Decode(b)
+}
+
+// StreamWriter writes elements to an io.Writer, each as its encoded
+// length as a uvarint followed by its encoding. Writes are buffered, so
+// Flush must be called after the last element.
+type StreamWriter[T comparable] struct {
+	w     *bufio.Writer
+	codec Codec[T]
+}
+
+// NewStreamWriter returns a StreamWriter that writes to w, encoding
+// elements with codec, or JSONCodec if codec is nil.
+func NewStreamWriter[T comparable](w io.Writer, codec Codec[T]) *StreamWriter[T] {
+	if codec == nil {
+		codec = JSONCodec[T]()
+	}
+	return &StreamWriter[T]{w: bufio.NewWriter(w), codec: codec}
+}
+
+// Write writes an element to the stream.
+func (w *StreamWriter[T]) Write(v T) error {
+	b, err := w.codec.Encode(v)
+	if err != nil {
+		return err
+	}
+	var buf [binary.MaxVarintLen64]byte
+	n := binary.PutUvarint(buf[:], uint64(len(b)))
+	if _, err := w.w.Write(buf[:n]); err != nil {
+		return err
+	}
+	_, err = w.w.Write(b)
+	return err
+}
+
+// Flush writes any buffered elements to the underlying io.Writer.
+func (w *StreamWriter[T]) Flush() error {
+	return w.w.Flush()
+}
+
+type sliceReader[T comparable] struct {
+	vs []T
+}
+
+func (r *sliceReader[T]) Read() (T, error) {
+	if len(r.vs) == 0 {
+		var zero T
+		return zero, io.EOF
+	}
+	v := r.vs[0]
+	r.vs = r.vs[1:]
+	return v, nil
+}
+
+// NewSetReader returns a reader of the elements of s in ascending order.
+func NewSetReader[T cmp.Ordered](s Set[T]) ElementReader[T] {
+	vs := s.ToSlice()
+	sortElements(vs)
+	return &sliceReader[T]{vs: vs}
+}
+
+type setWriter[T comparable] struct {
+	s Set[T]
+}
+
+func (w setWriter[T]) Write(v T) error {
+	w.s.Add(v)
+	return nil
+}
+
+// NewSetWriter returns a writer that adds elements to s.
+func NewSetWriter[T comparable](s Set[T]) ElementWriter[T] {
+	return setWriter[T]{s: s}
+}
+
+// ReadSet reads the remaining elements of r into a new thread-safe Set.
+func ReadSet[T comparable](r ElementReader[T]) (Set[T], error) {
+	s := newThreadSafeSet[T]()
+	for {
+		v, err := r.Read()
+		if err == io.EOF {
+			return s, nil
+		} else if err != nil {
+			return nil, err
+		}
+		s.uss.add(v)
+	}
+}
+
+func sortElements[T cmp.Ordered](vs []T) {
+	sort.Slice(vs, func(i, j int) bool {
+		return cmp.Less(vs[i], vs[j])
+	})
+}
+
+// sortedInput reads a sorted stream, skipping repeated elements. v holds
+// the current element while ok is true.
+type sortedInput[T cmp.Ordered] struct {
+	r  ElementReader[T]
+	v  T
+	ok bool
+}
+
+// advance moves to the next distinct element, returning ErrUnsortedStream
+// if it is smaller than the current one.
+func (in *sortedInput[T]) advance() error {
+	for {
+		v, err := in.r.Read()
+		if err == io.EOF {
+			in.ok = false
+			return nil
+		} else if err != nil {
+			return err
+		}
+		if !in.ok {
+			in.v, in.ok = v, true
+			return nil
+		}
+		switch c := cmp.Compare(v, in.v); {
+		case c < 0:
+			return ErrUnsortedStream
+		case c > 0:
+			in.v = v
+			return nil
+		}
+	}
+}
+
+// StreamUnion writes the elements in either a or b to w in ascending
+// order. The inputs must be sorted in ascending order; repeated elements
+// are written once.
+func StreamUnion[T cmp.Ordered](w ElementWriter[T], a, b ElementReader[T]) error {
+	return mergeStreams(w, a, b, true, true, true)
+}
+
+// StreamIntersect writes the elements in both a and b to w in ascending
+// order. The inputs must be sorted in ascending order.
+func StreamIntersect[T cmp.Ordered](w ElementWriter[T], a, b ElementReader[T]) error {
+	return mergeStreams(w, a, b, false, true, false)
+}
+
+// StreamDifference writes the elements of a that are not in b to w in
+// ascending order. The inputs must be sorted in ascending order.
+func StreamDifference[T cmp.Ordered](w ElementWriter[T], a, b ElementReader[T]) error {
+	return mergeStreams(w, a, b, true, false, false)
+}
+
+// StreamSymmetricDifference writes the elements in exactly one of a and b
+// to w in ascending order. The inputs must be sorted in ascending order.
+func StreamSymmetricDifference[T cmp.Ordered](w ElementWriter[T], a, b ElementReader[T]) error {
+	return mergeStreams(w, a, b, true, false, true)
+}
+
+// mergeStreams merges sorted streams a and b, writing the elements only
+// in a if onlyA, in both if both, and only in b if onlyB. It stops
+// reading once no further element can be written.
+func mergeStreams[T cmp.Ordered](w ElementWriter[T], a, b ElementReader[T], onlyA, both, onlyB bool) error {
+	x, y := &sortedInput[T]{r: a}, &sortedInput[T]{r: b}
+	if err := x.advance(); err != nil {
+		return err
+	}
+	if err := y.advance(); err != nil {
+		return err
+	}
+
+	for (x.ok && (onlyA || y.ok)) || (y.ok && onlyB) {
+		var c int
+		switch {
+		case !y.ok:
+			c = -1
+		case !x.ok:
+			c = 1
+		default:
+			c = cmp.Compare(x.v, y.v)
+		}
+
+		var v T
+		var emit bool
+		var err error
+		switch {
+		case c < 0:
+			v, emit = x.v, onlyA
+			err = x.advance()
+		case c > 0:
+			v, emit = y.v, onlyB
+			err = y.advance()
+		default:
+			v, emit = x.v, both
+			if err = x.advance(); err == nil {
+				err = y.advance()
+			}
+		}
+		if emit {
+			if werr := w.Write(v); werr != nil {
+				return werr
+			}
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ExternalSortOptions configures SortStream. The zero value is valid.
+type ExternalSortOptions[T comparable] struct {
+	// MaxInMemory is the largest number of elements held in memory.
+	// Larger inputs are sorted in runs of this size that are spilled
+	// to temporary files and merged. It defaults to 1 << 20.
+	MaxInMemory int
+
+	// MaxOpenRuns is the largest number of spilled runs read at
+	// once. When there are more, runs are merged into longer runs in
+	// passes until few enough remain, so that large inputs do not
+	// need a file descriptor per run. It defaults to 64, and is at
+	// least 2.
+	MaxOpenRuns int
+
+	// TempDir is the directory for spilled runs. It defaults to
+	// os.TempDir().
+	TempDir string
+
+	// Codec encodes spilled elements. It defaults to JSONCodec.
+	Codec Codec[T]
+}
+
+// SortStream writes the distinct elements of r to w in ascending order,
+// spilling to temporary files when r holds more than opts.MaxInMemory
+// elements. The temporary files are removed before SortStream returns.
+func SortStream[T cmp.Ordered](w ElementWriter[T], r ElementReader[T], opts ExternalSortOptions[T]) error {
+	if opts.MaxInMemory <= 0 {
+		opts.MaxInMemory = 1 << 20
+	}
+	if opts.MaxOpenRuns <= 0 {
+		opts.MaxOpenRuns = 64
+	} else if opts.MaxOpenRuns < 2 {
+		opts.MaxOpenRuns = 2
+	}
+	if opts.Codec == nil {
+		opts.Codec = JSONCodec[T]()
+	}
+
+	// runs holds the names of the spilled runs, which are closed
+	// except while being written or merged.
+	var runs []string
+	defer func() {
+		for _, name := range runs {
+			os.Remove(name)
+		}
+	}()
+
+	var buf []T
+	for {
+		v, err := r.Read()
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			return err
+		}
+		buf = append(buf, v)
+		if len(buf) < opts.MaxInMemory {
+			continue
+		}
+
+		sortElements(buf)
+		name, err := writeRun(opts, func(w ElementWriter[T]) error {
+			for _, v := range buf {
+				if err := w.Write(v); err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+		runs = append(runs, name)
+		buf = buf[:0]
+	}
+
+	// Merge runs in passes until they fit, with the elements still in
+	// memory, in the final merge.
+	for len(runs) >= opts.MaxOpenRuns {
+		name, err := mergeRuns(opts, runs[:opts.MaxOpenRuns])
+		if err != nil {
+			return err
+		}
+		for _, merged := range runs[:opts.MaxOpenRuns] {
+			os.Remove(merged)
+		}
+		runs = append(runs[opts.MaxOpenRuns:], name)
+	}
+
+	sortElements(buf)
+	return withRuns(opts, runs, func(inputs []ElementReader[T]) error {
+		return mergeSorted(w, append(inputs, &sliceReader[T]{vs: buf}))
+	})
+}
+
+// writeRun creates a temporary file, calls fill to write a run to it and
+// returns its name.
+func writeRun[T cmp.Ordered](opts ExternalSortOptions[T], fill func(ElementWriter[T]) error) (string, error) {
+	f, err := os.CreateTemp(opts.TempDir, "mapset-sort-*")
+	if err != nil {
+		return "", err
+	}
+	sw := NewStreamWriter(f, opts.Codec)
+	err = fill(sw)
+	if err == nil {
+		err = sw.Flush()
+	}
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		os.Remove(f.Name())
+		return "", err
+	}
+	return f.Name(), nil
+}
+
+// mergeRuns merges the named runs into a new run and returns its name.
+func mergeRuns[T cmp.Ordered](opts ExternalSortOptions[T], names []string) (string, error) {
+	var name string
+	err := withRuns(opts, names, func(inputs []ElementReader[T]) error {
+		var err error
+		name, err = writeRun(opts, func(w ElementWriter[T]) error {
+			return mergeSorted(w, inputs)
+		})
+		return err
+	})
+	return name, err
+}
+
+// withRuns opens the named runs, calls fn with readers of them and closes
+// them.
+func withRuns[T cmp.Ordered](opts ExternalSortOptions[T], names []string, fn func([]ElementReader[T]) error) error {
+	inputs := make([]ElementReader[T], 0, len(names)+1)
+	for _, name := range names {
+		f, err := os.Open(name)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		inputs = append(inputs, NewStreamReader(f, opts.Codec))
+	}
+	return fn(inputs)
+}
+
+// inputHeap orders sorted inputs by their current elements.
+type inputHeap[T cmp.Ordered] []*sortedInput[T]
+
+func (h inputHeap[T]) Len() int           { return len(h) }
+func (h inputHeap[T]) Less(i, j int) bool { return cmp.Less(h[i].v, h[j].v) }
+func (h inputHeap[T]) Swap(i, j int)      { h[i], h[j] = h[j], h[i] }
+func (h *inputHeap[T]) Push(x any)        { *h = append(*h, x.(*sortedInput[T])) }
+
+func (h *inputHeap[T]) Pop() any {
+	old := *h
+	in := old[len(old)-1]
+	*h = old[:len(old)-1]
+	return in
+}
+
+// mergeSorted writes the distinct elements of the sorted inputs to w in
+// ascending order.
+func mergeSorted[T cmp.Ordered](w ElementWriter[T], inputs []ElementReader[T]) error {
+	h := make(inputHeap[T], 0, len(inputs))
+	for _, r := range inputs {
+		in := &sortedInput[T]{r: r}
+		if err := in.advance(); err != nil {
+			return err
+		}
+		if in.ok {
+			h = append(h, in)
+		}
+	}
+	heap.Init(&h)
+
+	var last T
+	var wrote bool
+	for len(h) > 0 {
+		in := h[0]
+		if !wrote || cmp.Compare(in.v, last) != 0 {
+			if err := w.Write(in.v); err != nil {
+				return err
+			}
+			last, wrote = in.v, true
+		}
+		if err := in.advance(); err != nil {
+			return err
+		}
+		if in.ok {
+			heap.Fix(&h, 0)
+		} else {
+			heap.Pop(&h)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,243 @@
+//go:build go1.21
+// +build go1.21
+
+/*
+Open Source Initiative OSI - The MIT License (MIT):Licensing
+
+The MIT License (MIT)
+Copyright (c) 2013 - 2026 Ralph Caraveo (deckarep@gmail.com)
+
+Permission is hereby granted, free of charge, to any person obtaining a copy of
+this software and associated documentation files (the "Software"), to deal in
+the Software without restriction, including without limitation the rights to
+use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies
+of the Software, and to permit persons to whom the Software is furnished to do
+so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+
+package mapset
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+// sliceWriter collects written elements.
+type sliceWriter[T comparable] struct {
+	vs []T
+}
+
+func (w *sliceWriter[T]) Write(v T) error {
+	w.vs = append(w.vs, v)
+	return nil
+}
+
+func TestStreamReaderWriter(t *testing.T) {
+	var buf bytes.Buffer
+	w := NewStreamWriter[string](&buf, nil)
+	for _, v := range []string{"a", "", "ccc"} {
+		if err := w.Write(v); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := w.Flush(); err != nil {
+		t.Fatal(err)
+	}
+
+	r := NewStreamReader[string](bytes.NewReader(buf.Bytes()), nil)
+	var got sliceWriter[string]
+	for {
+		v, err := r.Read()
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			t.Fatal(err)
+		}
+		got.Write(v)
+	}
+	if !reflect.DeepEqual(got.vs, []string{"a", "", "ccc"}) {
+		t.Errorf("unexpected elements %q", got.vs)
+	}
+
+	r = NewStreamReader[string](bytes.NewReader(buf.Bytes()[:buf.Len()-1]), nil)
+	r.Read()
+	r.Read()
+	if _, err := r.Read(); err != io.ErrUnexpectedEOF {
+		t.Errorf("expected io.ErrUnexpectedEOF, got %v", err)
+	}
+}
+
+func TestStreamSetAlgebra(t *testing.T) {
+	a := NewSet(1, 3, 5, 7, 9, 10)
+	b := NewSet(2, 3, 4, 9, 11)
+
+	for name, tc := range map[string]struct {
+		op   func(ElementWriter[int], ElementReader[int], ElementReader[int]) error
+		want Set[int]
+	}{
+		"union":               {StreamUnion[int], a.Union(b)},
+		"intersect":           {StreamIntersect[int], a.Intersect(b)},
+		"difference":          {StreamDifference[int], a.Difference(b)},
+		"symmetricDifference": {StreamSymmetricDifference[int], a.SymmetricDifference(b)},
+	} {
+		var got sliceWriter[int]
+		if err := tc.op(&got, NewSetReader(a), NewSetReader(b)); err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		want := tc.want.ToSlice()
+		sortElements(want)
+		if !reflect.DeepEqual(got.vs, want) {
+			t.Errorf("%s: expected %v, got %v", name, want, got.vs)
+		}
+	}
+}
+
+func TestStreamSetAlgebraDuplicates(t *testing.T) {
+	var got sliceWriter[int]
+	a := &sliceReader[int]{vs: []int{1, 1, 2, 2, 2}}
+	b := &sliceReader[int]{vs: []int{2, 3, 3}}
+	if err := StreamUnion[int](&got, a, b); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got.vs, []int{1, 2, 3}) {
+		t.Errorf("expected repeated elements once, got %v", got.vs)
+	}
+}
+
+func TestStreamUnsorted(t *testing.T) {
+	var got sliceWriter[int]
+	a := &sliceReader[int]{vs: []int{1, 3, 2}}
+	b := &sliceReader[int]{vs: []int{1, 2, 3}}
+	if err := StreamUnion[int](&got, a, b); !errors.Is(err, ErrUnsortedStream) {
+		t.Errorf("expected ErrUnsortedStream, got %v", err)
+	}
+}
+
+func TestStreamIntersectStopsEarly(t *testing.T) {
+	var got sliceWriter[int]
+	a := &sliceReader[int]{vs: []int{1}}
+	b := &sliceReader[int]{vs: []int{1, 2, 3, 4}}
+	if err := StreamIntersect[int](&got, a, b); err != nil {
+		t.Fatal(err)
+	}
+	if len(b.vs) != 2 {
+		t.Errorf("expected to stop reading once a was exhausted, %d elements left", len(b.vs))
+	}
+}
+
+func TestSortStreamSpills(t *testing.T) {
+	dir := t.TempDir()
+	var vs []int
+	for i := 0; i < 1000; i++ {
+		vs = append(vs, int(mix64(uint64(i))%500))
+	}
+
+	var got sliceWriter[int]
+	opts := ExternalSortOptions[int]{MaxInMemory: 64, TempDir: dir}
+	if err := SortStream[int](&got, &sliceReader[int]{vs: vs}, opts); err != nil {
+		t.Fatal(err)
+	}
+
+	want := NewThreadUnsafeSet(vs...).ToSlice()
+	sortElements(want)
+	if !reflect.DeepEqual(got.vs, want) {
+		t.Errorf("expected %d sorted distinct elements, got %d", len(want), len(got.vs))
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 0 {
+		t.Errorf("expected spilled runs to be removed, found %d files", len(entries))
+	}
+}
+
+func TestSortStreamMergesInPasses(t *testing.T) {
+	dir := t.TempDir()
+	var vs []int
+	for i := 0; i < 2000; i++ {
+		vs = append(vs, int(mix64(uint64(i))%1500))
+	}
+
+	// 500 runs merged at most 3 at a time.
+	var got sliceWriter[int]
+	opts := ExternalSortOptions[int]{MaxInMemory: 4, MaxOpenRuns: 3, TempDir: dir}
+	if err := SortStream[int](&got, &sliceReader[int]{vs: vs}, opts); err != nil {
+		t.Fatal(err)
+	}
+
+	want := NewThreadUnsafeSet(vs...).ToSlice()
+	sortElements(want)
+	if !reflect.DeepEqual(got.vs, want) {
+		t.Errorf("expected %d sorted distinct elements, got %d", len(want), len(got.vs))
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 0 {
+		t.Errorf("expected spilled runs to be removed, found %d files", len(entries))
+	}
+}
+
+func TestStreamFiles(t *testing.T) {
+	dir := t.TempDir()
+	writeFile := func(name string, s Set[string]) *os.File {
+		f, err := os.Create(filepath.Join(dir, name))
+		if err != nil {
+			t.Fatal(err)
+		}
+		t.Cleanup(func() { f.Close() })
+		w := NewStreamWriter[string](f, nil)
+		if err := SortStream[string](w, NewSetReader(s), ExternalSortOptions[string]{}); err != nil {
+			t.Fatal(err)
+		}
+		if err := w.Flush(); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := f.Seek(0, io.SeekStart); err != nil {
+			t.Fatal(err)
+		}
+		return f
+	}
+	a := writeFile("a", NewSet("x", "y", "z"))
+	b := writeFile("b", NewSet("w", "y"))
+
+	got := NewSet[string]()
+	err := StreamDifference[string](NewSetWriter(got), NewStreamReader[string](a, nil), NewStreamReader[string](b, nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !got.Equal(NewSet("x", "z")) {
+		t.Errorf("expected {x, z}, got %v", got)
+	}
+
+	if _, err := a.Seek(0, io.SeekStart); err != nil {
+		t.Fatal(err)
+	}
+	s, err := ReadSet[string](NewStreamReader[string](a, nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !s.Equal(NewSet("x", "y", "z")) {
+		t.Errorf("expected {x, y, z}, got %v", s)
+	}
+}
@@ -407,3 +407,15 @@ func (s threadUnsafeSet[T]) UnmarshalBSONValue(bt bsontype.Type, b []byte) error
 
 	return nil
 }
+
+// toThreadUnsafeSet returns the elements of any Set implementation as a
+// threadUnsafeSet, copying them only when other is not already one.
+func toThreadUnsafeSet[T comparable](other Set[T]) *threadUnsafeSet[T] {
+	if o, ok := other.(*threadUnsafeSet[T]); ok {
+		return o
+	}
+	vs := other.ToSlice()
+	o := newThreadUnsafeSetWithSize[T](len(vs))
+	o.append(vs...)
+	return o
+}
@@ -0,0 +1,95 @@
+/*
+Open Source Initiative OSI - The MIT License (MIT):Licensing
+
+The MIT License (MIT)
+Copyright (c) 2013 - 2026 Ralph Caraveo (deckarep@gmail.com)
+
+Permission is hereby granted, free of charge, to any person obtaining a copy of
+this software and associated documentation files (the "Software"), to deal in
+the Software without restriction, including without limitation the rights to
+use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies
+of the Software, and to permit persons to whom the Software is furnished to do
+so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+
+package mapset
+
+import (
+	"sort"
+	"unsafe"
+)
+
+// Transact runs fn with exclusive access to a group of thread-safe sets,
+// created by NewSet or NewSetWithSize, so that changes across them are
+// atomic: other goroutines observe either none of the changes or all of
+// them. It panics if any set is of another implementation.
+//
+// fn receives views of the sets, in the same order, that are not
+// thread-safe and must not be used after fn returns. If fn returns an
+// error or panics, every change made through the views is rolled back
+// and the error is returned or the panic resumed.
+//
+// The sets are locked in a fixed global order, so concurrent calls to
+// Transact never deadlock with each other whatever order they list their
+// sets in. That guarantee holds only between calls to Transact: methods
+// that take two sets, such as Union and Equal, lock the receiver and then
+// the argument, and can deadlock with a concurrent Transact that locks
+// the same sets in the other order. A set may be listed more than once.
+// fn must not call methods of the sets themselves, nor Transact with any
+// of them, as their locks are held.
+func Transact[T comparable](sets []Set[T], fn func(views []Set[T]) error) error {
+	journals := make(map[*threadSafeSet[T]]JournaledSet[T], len(sets))
+	views := make([]Set[T], len(sets))
+	for i, s := range sets {
+		t := s.(*threadSafeSet[T])
+		j, ok := journals[t]
+		if !ok {
+			j = NewJournaledSet[T](t.uss, JournaledSetOptions{})
+			journals[t] = j
+		}
+		views[i] = j
+	}
+
+	locked := make([]*threadSafeSet[T], 0, len(journals))
+	for t := range journals {
+		locked = append(locked, t)
+	}
+	// Order by address, which does not change as the garbage
+	// collector does not move heap objects.
+	sort.Slice(locked, func(i, j int) bool {
+		return uintptr(unsafe.Pointer(locked[i])) < uintptr(unsafe.Pointer(locked[j]))
+	})
+	for _, t := range locked {
+		t.Lock()
+	}
+
+	committed := false
+	defer func() {
+		if !committed {
+			for _, j := range journals {
+				for j.Undo() {
+				}
+			}
+		}
+		for _, t := range locked {
+			t.Unlock()
+		}
+	}()
+
+	if err := fn(views); err != nil {
+		return err
+	}
+	committed = true
+	return nil
+}
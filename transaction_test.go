@@ -0,0 +1,156 @@
+/*
+Open Source Initiative OSI - The MIT License (MIT):Licensing
+
+The MIT License (MIT)
+Copyright (c) 2013 - 2026 Ralph Caraveo (deckarep@gmail.com)
+
+Permission is hereby granted, free of charge, to any person obtaining a copy of
+this software and associated documentation files (the "Software"), to deal in
+the Software without restriction, including without limitation the rights to
+use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies
+of the Software, and to permit persons to whom the Software is furnished to do
+so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+
+package mapset
+
+import (
+	"errors"
+	"sync"
+	"testing"
+)
+
+var errNotPending = errors.New("not pending")
+
+// activate moves v from pending to active.
+func activate(pending, active Set[string], v string) error {
+	return Transact([]Set[string]{pending, active}, func(views []Set[string]) error {
+		p, a := views[0], views[1]
+		if !p.ContainsOne(v) {
+			return errNotPending
+		}
+		p.Remove(v)
+		a.Add(v)
+		return nil
+	})
+}
+
+func Test_TransactCommit(t *testing.T) {
+	pending := NewSet("a", "b")
+	active := NewSet[string]()
+	if err := activate(pending, active, "a"); err != nil {
+		t.Fatal(err)
+	}
+	if !pending.Equal(NewSet("b")) || !active.Equal(NewSet("a")) {
+		t.Errorf("Expected the element to move, got %v and %v", pending, active)
+	}
+}
+
+func Test_TransactRollback(t *testing.T) {
+	a := NewSet(1, 2, 3)
+	b := NewSet(4)
+	errAbort := errors.New("abort")
+
+	err := Transact([]Set[int]{a, b}, func(views []Set[int]) error {
+		views[0].Clear()
+		views[1].Append(5, 6)
+		views[1].Remove(4)
+		views[0].Add(9)
+		return errAbort
+	})
+	if !errors.Is(err, errAbort) {
+		t.Errorf("Expected the callback's error, got %v", err)
+	}
+	if !a.Equal(NewSet(1, 2, 3)) || !b.Equal(NewSet(4)) {
+		t.Errorf("Expected changes to be rolled back, got %v and %v", a, b)
+	}
+}
+
+func Test_TransactPanic(t *testing.T) {
+	a := NewSet(1)
+	func() {
+		defer func() {
+			if recover() == nil {
+				t.Error("Expected the panic to be resumed")
+			}
+		}()
+		_ = Transact([]Set[int]{a}, func(views []Set[int]) error {
+			views[0].Add(2)
+			panic("boom")
+		})
+	}()
+	if !a.Equal(NewSet(1)) {
+		t.Errorf("Expected changes to be rolled back, got %v", a)
+	}
+	// The lock must have been released.
+	a.Add(3)
+}
+
+func Test_TransactDuplicateSets(t *testing.T) {
+	a := NewSet(1)
+	err := Transact([]Set[int]{a, a}, func(views []Set[int]) error {
+		views[0].Add(2)
+		if !views[1].ContainsOne(2) {
+			t.Error("Views of the same set should share changes")
+		}
+		return errors.New("abort")
+	})
+	if err == nil || !a.Equal(NewSet(1)) {
+		t.Errorf("Expected changes to be rolled back, got %v", a)
+	}
+}
+
+func Test_TransactRequiresThreadSafeSets(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Error("Expected a panic for a thread-unsafe set")
+		}
+	}()
+	_ = Transact([]Set[int]{NewThreadUnsafeSet[int]()}, func([]Set[int]) error { return nil })
+}
+
+func Test_TransactConcurrent(t *testing.T) {
+	x := NewSet[int]()
+	y := NewSet[int]()
+	for i := 0; i < 100; i++ {
+		x.Add(i)
+	}
+
+	// Move elements back and forth, listing the sets in both orders.
+	var wg sync.WaitGroup
+	for g := 0; g < 8; g++ {
+		wg.Add(1)
+		go func(g int) {
+			defer wg.Done()
+			from, to := x, y
+			if g%2 == 1 {
+				from, to = y, x
+			}
+			for i := 0; i < 200; i++ {
+				_ = Transact([]Set[int]{from, to}, func(views []Set[int]) error {
+					v, ok := views[0].Pop()
+					if ok {
+						views[1].Add(v)
+					}
+					return nil
+				})
+			}
+		}(g)
+	}
+	wg.Wait()
+
+	if x.Cardinality()+y.Cardinality() != 100 || x.ContainsAnyElement(y) {
+		t.Errorf("Elements should be conserved, got %d and %d", x.Cardinality(), y.Cardinality())
+	}
+}
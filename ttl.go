@@ -0,0 +1,359 @@
+/*
+Open Source Initiative OSI - The MIT License (MIT):Licensing
+
+The MIT License (MIT)
+Copyright (c) 2013 - 2026 Ralph Caraveo (deckarep@gmail.com)
+
+Permission is hereby granted, free of charge, to any person obtaining a copy of
+this software and associated documentation files (the "Software"), to deal in
+the Software without restriction, including without limitation the rights to
+use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies
+of the Software, and to permit persons to whom the Software is furnished to do
+so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+
+package mapset
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+)
+
+// TTLSet is a set whose elements expire after a time-to-live. Expired
+// elements are never reported as members; they are evicted lazily when
+// encountered and, optionally, by a background sweeper.
+//
+// Operations on a TTLSet are thread-safe.
+type TTLSet[T comparable] interface {
+	// Add adds an element to the set using the default TTL,
+	// refreshing its expiry if it is already present. Returns
+	// whether the item was added.
+	Add(val T) bool
+
+	// AddWithTTL adds an element to the set that expires after
+	// ttl, refreshing its expiry if it is already present. A ttl
+	// that is not positive means the element never expires.
+	// Returns whether the item was added.
+	AddWithTTL(val T, ttl time.Duration) bool
+
+	// Append multiple elements to the set using the default TTL.
+	// Returns the number of elements added.
+	Append(val ...T) int
+
+	// Cardinality returns the number of unexpired elements in the set.
+	Cardinality() int
+
+	// Clear removes all elements from the set.
+	Clear()
+
+	// Contains returns whether the given items are all
+	// unexpired members of the set.
+	Contains(val ...T) bool
+
+	// ContainsOne returns whether the given item is an
+	// unexpired member of the set.
+	ContainsOne(val T) bool
+
+	// Each iterates over unexpired elements and executes the passed func
+	// against each element. If passed func returns true, stop iteration
+	// at the time.
+	Each(func(T) bool)
+
+	// ExpiresAt returns the time at which the given item expires. The
+	// returned time is zero if the item never expires. The boolean
+	// result reports whether the item is an unexpired member.
+	ExpiresAt(val T) (time.Time, bool)
+
+	// EvictExpired removes every expired element from the set and
+	// returns the number of elements evicted.
+	EvictExpired() int
+
+	// Remove removes a single element from the set.
+	Remove(val T)
+
+	// String provides a convenient string representation
+	// of the current state of the set.
+	String() string
+
+	// ToSet returns the unexpired elements as a thread-safe Set.
+	ToSet() Set[T]
+
+	// ToSlice returns the unexpired elements as a slice.
+	ToSlice() []T
+
+	// Close stops the background sweeper, if any. The set remains
+	// usable afterwards and continues to evict lazily.
+	Close()
+}
+
+// TTLSetOptions configures a TTLSet. The zero value is valid.
+type TTLSetOptions[T comparable] struct {
+	// Clock returns the current time. It defaults to time.Now and
+	// can be replaced to control expiry in tests.
+	Clock func() time.Time
+
+	// SweepInterval is the period of the background sweeper. If it
+	// is not positive no sweeper is started and expired elements are
+	// only evicted lazily.
+	SweepInterval time.Duration
+
+	// OnEvict, if set, is called for every element evicted because it
+	// expired. It is called without holding the set's lock.
+	OnEvict func(val T)
+}
+
+// NewTTLSet creates and returns a new, empty TTLSet whose elements expire
+// after defaultTTL unless added with AddWithTTL. A defaultTTL that is not
+// positive means elements added with Add never expire.
+//
+// If opts.SweepInterval is positive a background goroutine evicts expired
+// elements periodically; call Close to stop it.
+func NewTTLSet[T comparable](defaultTTL time.Duration, opts TTLSetOptions[T]) TTLSet[T] {
+	if opts.Clock == nil {
+		opts.Clock = time.Now
+	}
+
+	s := &ttlSet[T]{
+		items:      make(map[T]time.Time),
+		defaultTTL: defaultTTL,
+		now:        opts.Clock,
+		onEvict:    opts.OnEvict,
+	}
+
+	if opts.SweepInterval > 0 {
+		s.stop = make(chan struct{})
+		go s.sweep(opts.SweepInterval)
+	}
+
+	return s
+}
+
+type ttlSet[T comparable] struct {
+	sync.RWMutex
+	items      map[T]time.Time
+	defaultTTL time.Duration
+	now        func() time.Time
+	onEvict    func(T)
+	stop       chan struct{}
+	stopOnce   sync.Once
+}
+
+// Assert concrete type:ttlSet adheres to TTLSet interface.
+var _ TTLSet[string] = (*ttlSet[string])(nil)
+
+// expired reports whether an element expiring at exp has expired at now.
+func expired(exp, now time.Time) bool {
+	return !exp.IsZero() && !now.Before(exp)
+}
+
+func (s *ttlSet[T]) expiry(ttl time.Duration) time.Time {
+	if ttl <= 0 {
+		return time.Time{}
+	}
+	return s.now().Add(ttl)
+}
+
+func (s *ttlSet[T]) Add(v T) bool {
+	return s.AddWithTTL(v, s.defaultTTL)
+}
+
+func (s *ttlSet[T]) AddWithTTL(v T, ttl time.Duration) bool {
+	exp := s.expiry(ttl)
+
+	s.Lock()
+	prev, found := s.items[v]
+	s.items[v] = exp
+	s.Unlock()
+
+	// An element that had expired but was not yet evicted counts as added.
+	if found && expired(prev, s.now()) {
+		s.evicted([]T{v})
+		return true
+	}
+	return !found
+}
+
+func (s *ttlSet[T]) Append(vs ...T) int {
+	exp := s.expiry(s.defaultTTL)
+
+	s.Lock()
+	now := s.now()
+	var added int
+	var evicted []T
+	for _, v := range vs {
+		prev, found := s.items[v]
+		if found && expired(prev, now) {
+			evicted = append(evicted, v)
+			found = false
+		}
+		if !found {
+			added++
+		}
+		s.items[v] = exp
+	}
+	s.Unlock()
+
+	s.evicted(evicted)
+	return added
+}
+
+func (s *ttlSet[T]) Cardinality() int {
+	s.EvictExpired()
+
+	s.RLock()
+	defer s.RUnlock()
+	return len(s.items)
+}
+
+func (s *ttlSet[T]) Clear() {
+	s.Lock()
+	s.items = make(map[T]time.Time)
+	s.Unlock()
+}
+
+func (s *ttlSet[T]) Contains(vs ...T) bool {
+	for _, v := range vs {
+		if !s.ContainsOne(v) {
+			return false
+		}
+	}
+	return true
+}
+
+func (s *ttlSet[T]) ContainsOne(v T) bool {
+	_, ok := s.ExpiresAt(v)
+	return ok
+}
+
+func (s *ttlSet[T]) ExpiresAt(v T) (time.Time, bool) {
+	s.RLock()
+	exp, found := s.items[v]
+	s.RUnlock()
+
+	if !found {
+		return time.Time{}, false
+	}
+	if expired(exp, s.now()) {
+		s.evictIfExpired(v)
+		return time.Time{}, false
+	}
+	return exp, true
+}
+
+// evictIfExpired removes v if it is still present and expired. The element
+// may have been refreshed between dropping the read lock and taking the
+// write lock, so expiry is checked again.
+func (s *ttlSet[T]) evictIfExpired(v T) {
+	s.Lock()
+	exp, found := s.items[v]
+	if !found || !expired(exp, s.now()) {
+		s.Unlock()
+		return
+	}
+	delete(s.items, v)
+	s.Unlock()
+
+	s.evicted([]T{v})
+}
+
+func (s *ttlSet[T]) EvictExpired() int {
+	s.Lock()
+	now := s.now()
+	var evicted []T
+	for v, exp := range s.items {
+		if expired(exp, now) {
+			delete(s.items, v)
+			evicted = append(evicted, v)
+		}
+	}
+	s.Unlock()
+
+	s.evicted(evicted)
+	return len(evicted)
+}
+
+// evicted notifies the eviction callback, if any, of expired elements.
+func (s *ttlSet[T]) evicted(vs []T) {
+	if s.onEvict == nil {
+		return
+	}
+	for _, v := range vs {
+		s.onEvict(v)
+	}
+}
+
+func (s *ttlSet[T]) Each(cb func(T) bool) {
+	for _, v := range s.ToSlice() {
+		if cb(v) {
+			break
+		}
+	}
+}
+
+func (s *ttlSet[T]) Remove(v T) {
+	s.Lock()
+	delete(s.items, v)
+	s.Unlock()
+}
+
+func (s *ttlSet[T]) String() string {
+	vs := s.ToSlice()
+	items := make([]string, 0, len(vs))
+	for _, v := range vs {
+		items = append(items, fmt.Sprintf("%v", v))
+	}
+	return fmt.Sprintf("TTLSet{%s}", strings.Join(items, ", "))
+}
+
+func (s *ttlSet[T]) ToSet() Set[T] {
+	return NewSet(s.ToSlice()...)
+}
+
+func (s *ttlSet[T]) ToSlice() []T {
+	s.RLock()
+	now := s.now()
+	keys := make([]T, 0, len(s.items))
+	for v, exp := range s.items {
+		if !expired(exp, now) {
+			keys = append(keys, v)
+		}
+	}
+	s.RUnlock()
+	return keys
+}
+
+func (s *ttlSet[T]) Close() {
+	if s.stop == nil {
+		return
+	}
+	s.stopOnce.Do(func() {
+		close(s.stop)
+	})
+}
+
+func (s *ttlSet[T]) sweep(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-s.stop:
+			return
+		case <-ticker.C:
+			s.EvictExpired()
+		}
+	}
+}
@@ -0,0 +1,164 @@
+/*
+Open Source Initiative OSI - The MIT License (MIT):Licensing
+
+The MIT License (MIT)
+Copyright (c) 2013 - 2026 Ralph Caraveo (deckarep@gmail.com)
+
+Permission is hereby granted, free of charge, to any person obtaining a copy of
+this software and associated documentation files (the "Software"), to deal in
+the Software without restriction, including without limitation the rights to
+use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies
+of the Software, and to permit persons to whom the Software is furnished to do
+so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+
+package mapset
+
+import (
+	"sync"
+	"testing"
+	"time"
+)
+
+type fakeClock struct {
+	sync.Mutex
+	now time.Time
+}
+
+func newFakeClock() *fakeClock {
+	return &fakeClock{now: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)}
+}
+
+func (c *fakeClock) Now() time.Time {
+	c.Lock()
+	defer c.Unlock()
+	return c.now
+}
+
+func (c *fakeClock) Advance(d time.Duration) {
+	c.Lock()
+	c.now = c.now.Add(d)
+	c.Unlock()
+}
+
+func Test_TTLSetExpiry(t *testing.T) {
+	clock := newFakeClock()
+	s := NewTTLSet[string](10*time.Minute, TTLSetOptions[string]{Clock: clock.Now})
+
+	if !s.Add("a") {
+		t.Error("Add of a new element should return true")
+	}
+	s.AddWithTTL("b", time.Minute)
+	s.AddWithTTL("forever", 0)
+
+	if !s.Contains("a", "b", "forever") {
+		t.Error("All elements should be present before expiry")
+	}
+
+	clock.Advance(time.Minute)
+	if s.ContainsOne("b") {
+		t.Error("b should have expired after one minute")
+	}
+	if !s.ContainsOne("a") {
+		t.Error("a should not have expired after one minute")
+	}
+
+	clock.Advance(time.Hour)
+	if s.ContainsOne("a") {
+		t.Error("a should have expired after ten minutes")
+	}
+	if !s.ContainsOne("forever") {
+		t.Error("Elements with no TTL should never expire")
+	}
+	if s.Cardinality() != 1 {
+		t.Errorf("Expected cardinality 1, got %d", s.Cardinality())
+	}
+}
+
+func Test_TTLSetRefresh(t *testing.T) {
+	clock := newFakeClock()
+	s := NewTTLSet[string](time.Minute, TTLSetOptions[string]{Clock: clock.Now})
+
+	s.Add("a")
+	clock.Advance(30 * time.Second)
+	if s.Add("a") {
+		t.Error("Re-adding an unexpired element should return false")
+	}
+	clock.Advance(45 * time.Second)
+	if !s.ContainsOne("a") {
+		t.Error("Re-adding an element should refresh its expiry")
+	}
+
+	exp, ok := s.ExpiresAt("a")
+	if !ok || !exp.Equal(clock.Now().Add(15*time.Second)) {
+		t.Errorf("Unexpected expiry %v (%v)", exp, ok)
+	}
+
+	clock.Advance(time.Minute)
+	if !s.Add("a") {
+		t.Error("Re-adding an expired element should return true")
+	}
+}
+
+func Test_TTLSetOnEvict(t *testing.T) {
+	clock := newFakeClock()
+	var evicted []string
+	s := NewTTLSet[string](time.Minute, TTLSetOptions[string]{
+		Clock: clock.Now,
+		OnEvict: func(v string) {
+			evicted = append(evicted, v)
+		},
+	})
+
+	s.Append("a", "b")
+	s.AddWithTTL("c", time.Hour)
+	s.Remove("b")
+
+	clock.Advance(2 * time.Minute)
+	if n := s.EvictExpired(); n != 1 {
+		t.Errorf("Expected one eviction, got %d", n)
+	}
+	if len(evicted) != 1 || evicted[0] != "a" {
+		t.Errorf("Expected only a to be reported as evicted, got %v", evicted)
+	}
+
+	got := s.ToSet()
+	if !got.Equal(NewSet("c")) {
+		t.Errorf("Expected Set{c}, got %v", got)
+	}
+}
+
+func Test_TTLSetSweeper(t *testing.T) {
+	evicted := make(chan string, 1)
+	s := NewTTLSet[string](time.Millisecond, TTLSetOptions[string]{
+		SweepInterval: time.Millisecond,
+		OnEvict: func(v string) {
+			evicted <- v
+		},
+	})
+	defer s.Close()
+
+	s.Add("a")
+	select {
+	case v := <-evicted:
+		if v != "a" {
+			t.Errorf("Expected a to be evicted, got %v", v)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("The sweeper did not evict the expired element")
+	}
+
+	s.Close()
+	s.Close()
+}
@@ -0,0 +1,230 @@
+/*
+Open Source Initiative OSI - The MIT License (MIT):Licensing
+
+The MIT License (MIT)
+Copyright (c) 2013 - 2026 Ralph Caraveo (deckarep@gmail.com)
+
+Permission is hereby granted, free of charge, to any person obtaining a copy of
+this software and associated documentation files (the "Software"), to deal in
+the Software without restriction, including without limitation the rights to
+use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies
+of the Software, and to permit persons to whom the Software is furnished to do
+so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+
+package mapset
+
+import (
+	"encoding/json"
+	"fmt"
+	"sync"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/bsontype"
+)
+
+// setWrapper is implemented by Set implementations that add behavior to
+// another Set.
+type setWrapper[T comparable] interface {
+	wrapped() Set[T]
+}
+
+// unwrap returns the innermost set wrapped by other, so that it can be
+// passed to the methods of a wrapped set.
+func unwrap[T comparable](other Set[T]) Set[T] {
+	for {
+		w, ok := other.(setWrapper[T])
+		if !ok {
+			return other
+		}
+		other = w.wrapped()
+	}
+}
+
+// wrappedSet delegates to the Set it wraps, unwrapping the arguments of
+// methods that take another set so that wrappers can be combined with
+// each other and with the wrapped implementation.
+type wrappedSet[T comparable] struct {
+	Set[T]
+}
+
+func (s *wrappedSet[T]) wrapped() Set[T] {
+	return s.Set
+}
+
+func (s *wrappedSet[T]) ContainsAnyElement(other Set[T]) bool {
+	return s.Set.ContainsAnyElement(unwrap(other))
+}
+
+func (s *wrappedSet[T]) Difference(other Set[T]) Set[T] {
+	return s.Set.Difference(unwrap(other))
+}
+
+func (s *wrappedSet[T]) Equal(other Set[T]) bool {
+	return s.Set.Equal(unwrap(other))
+}
+
+func (s *wrappedSet[T]) Intersect(other Set[T]) Set[T] {
+	return s.Set.Intersect(unwrap(other))
+}
+
+func (s *wrappedSet[T]) IsProperSubset(other Set[T]) bool {
+	return s.Set.IsProperSubset(unwrap(other))
+}
+
+func (s *wrappedSet[T]) IsProperSuperset(other Set[T]) bool {
+	return s.Set.IsProperSuperset(unwrap(other))
+}
+
+func (s *wrappedSet[T]) IsSubset(other Set[T]) bool {
+	return s.Set.IsSubset(unwrap(other))
+}
+
+func (s *wrappedSet[T]) IsSuperset(other Set[T]) bool {
+	return s.Set.IsSuperset(unwrap(other))
+}
+
+func (s *wrappedSet[T]) SymmetricDifference(other Set[T]) Set[T] {
+	return s.Set.SymmetricDifference(unwrap(other))
+}
+
+func (s *wrappedSet[T]) Union(other Set[T]) Set[T] {
+	return s.Set.Union(unwrap(other))
+}
+
+// recordingSet is a wrapper whose mutating methods report the elements
+// they actually added and removed to onChange. Calls that change nothing
+// are not reported.
+type recordingSet[T comparable] struct {
+	wrappedSet[T]
+
+	// mu serializes changes and their reports, so that reports are
+	// made in order and describe the changes exactly. onChange is
+	// called with mu held.
+	mu       sync.Mutex
+	onChange func(added, removed []T)
+}
+
+func (s *recordingSet[T]) record(added, removed []T) {
+	if len(added) > 0 || len(removed) > 0 {
+		s.onChange(added, removed)
+	}
+}
+
+// private version of Append which expects s.mu to be held
+func (s *recordingSet[T]) append(vs []T) int {
+	var added []T
+	for _, v := range vs {
+		if s.Set.Add(v) {
+			added = append(added, v)
+		}
+	}
+	s.record(added, nil)
+	return len(added)
+}
+
+func (s *recordingSet[T]) Add(v T) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.append([]T{v}) == 1
+}
+
+func (s *recordingSet[T]) Append(vs ...T) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.append(vs)
+}
+
+func (s *recordingSet[T]) AppendFrom(other Set[T]) int {
+	// Copy other before locking s, which may be other.
+	vs := other.ToSlice()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.append(vs)
+}
+
+func (s *recordingSet[T]) Clear() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	removed := s.Set.ToSlice()
+	s.Set.Clear()
+	s.record(nil, removed)
+}
+
+func (s *recordingSet[T]) Pop() (T, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	v, ok := s.Set.Pop()
+	if ok {
+		s.record(nil, []T{v})
+	}
+	return v, ok
+}
+
+func (s *recordingSet[T]) PopN(n int) ([]T, int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	items, count := s.Set.PopN(n)
+	s.record(nil, items)
+	return items, count
+}
+
+// private version of RemoveAll which expects s.mu to be held
+func (s *recordingSet[T]) removeAll(vs []T) {
+	var removed []T
+	for _, v := range vs {
+		if s.Set.ContainsOne(v) {
+			s.Set.Remove(v)
+			removed = append(removed, v)
+		}
+	}
+	s.record(nil, removed)
+}
+
+func (s *recordingSet[T]) Remove(v T) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.removeAll([]T{v})
+}
+
+func (s *recordingSet[T]) RemoveAll(vs ...T) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.removeAll(vs)
+}
+
+// UnmarshalJSON adds the elements of a JSON array to the set.
+func (s *recordingSet[T]) UnmarshalJSON(b []byte) error {
+	var i []T
+	if err := json.Unmarshal(b, &i); err != nil {
+		return err
+	}
+	s.Append(i...)
+	return nil
+}
+
+// UnmarshalBSONValue adds the elements of a BSON array to the set.
+func (s *recordingSet[T]) UnmarshalBSONValue(bt bsontype.Type, b []byte) error {
+	if bt != bson.TypeArray {
+		return fmt.Errorf("must use BSON Array to unmarshal Set")
+	}
+
+	var i []T
+	if err := bson.UnmarshalValue(bt, b, &i); err != nil {
+		return err
+	}
+	s.Append(i...)
+	return nil
+}